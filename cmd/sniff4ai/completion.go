@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// progName is the command the completion scripts register for.
+const progName = "sniff4ai"
+
+// flagChoices are the values offered after flags that take a fixed set.
+var flagChoices = map[string][]string{
+	"format":       {"text", "json", "json-array", "ndjson", "sarif", "html", "csv", "junit", "gha"},
+	"json-version": {"1", "2"},
+	"color":        {"auto", "always", "never"},
+	"unicode-norm": {"NFC", "NFD", "NFKC", "NFKD"},
+	"min-severity": {"info", "warn", "error"},
+	"log-level":    {"debug", "info", "warn", "error"},
+	"log-format":   {"text", "json"},
+	"summary":      {"stderr", "stdout", "off"},
+}
+
+// fileFlags take a file path and dirFlags a directory.
+var (
+	fileFlags = map[string]bool{"dict": true, "ignore-file": true, "allowlist": true, "output": true, "write-baseline": true, "compare-baseline": true, "profile-output": true, "trace-ignore": true}
+	dirFlags  = map[string]bool{"cache-dir": true}
+)
+
+// completionFlag is one flag as the completion scripts see it.
+type completionFlag struct {
+	name    string
+	usage   string
+	value   bool // takes an argument
+	repeat  bool // may be given more than once
+	choices []string
+}
+
+// completionFlags lists the flags of fs in name order.
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	var out []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{name: f.Name, usage: f.Usage, value: true, choices: flagChoices[f.Name]}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.value = false
+		}
+		_, cf.repeat = f.Value.(*listFlag)
+		out = append(out, cf)
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// runCompletion handles "sniff4ai completion <shell>" and exits.
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fatal("usage: sniff4ai completion bash|zsh|fish")
+	}
+	if err := writeCompletion(os.Stdout, args[0], flag.CommandLine); err != nil {
+		fatal(err)
+	}
+	os.Exit(0)
+}
+
+// writeCompletion emits the completion script for shell.
+func writeCompletion(w io.Writer, shell string, fs *flag.FlagSet) error {
+	flags := completionFlags(fs)
+	switch shell {
+	case "bash":
+		writeBash(w, flags)
+	case "zsh":
+		writeZsh(w, flags)
+	case "fish":
+		writeFish(w, flags)
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+	}
+	return nil
+}
+
+func writeBash(w io.Writer, flags []completionFlag) {
+	var names, files, dirs, values []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		switch {
+		case fileFlags[f.name]:
+			files = append(files, "-"+f.name, "--"+f.name)
+		case dirFlags[f.name]:
+			dirs = append(dirs, "-"+f.name, "--"+f.name)
+		case f.value && f.choices == nil:
+			values = append(values, "-"+f.name, "--"+f.name)
+		}
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s\n", progName)
+	fmt.Fprintf(w, "_%s() {\n", progName)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    case "$prev" in`)
+	bashCase(w, files, `COMPREPLY=($(compgen -f -- "$cur")); return ;;`)
+	bashCase(w, dirs, `COMPREPLY=($(compgen -d -- "$cur")); return ;;`)
+	for _, f := range flags {
+		if f.choices != nil {
+			fmt.Fprintf(w, "        -%s|--%s)\n            COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n",
+				f.name, f.name, strings.Join(f.choices, " "))
+		}
+	}
+	bashCase(w, values, "return ;;")
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, `    elif [[ $COMP_CWORD -eq 1 ]]; then`)
+	fmt.Fprintln(w, `        COMPREPLY=($(compgen -W "completion dump-rules explain generate install-hook test-rule" -- "$cur") $(compgen -f -- "$cur"))`)
+	fmt.Fprintln(w, "    else")
+	fmt.Fprintln(w, `        COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o filenames -F _%s %s\n", progName, progName)
+}
+
+// bashCase writes one case arm, or nothing when no flag needs it.
+func bashCase(w io.Writer, patterns []string, body string) {
+	if len(patterns) > 0 {
+		fmt.Fprintf(w, "        %s)\n            %s\n", strings.Join(patterns, "|"), body)
+	}
+}
+
+// zshEscape makes s safe inside a single-quoted _arguments spec.
+var zshEscape = strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+
+func writeZsh(w io.Writer, flags []completionFlag) {
+	fmt.Fprintf(w, "#compdef %s\n\n", progName)
+	fmt.Fprintf(w, "_%s() {\n", progName)
+	fmt.Fprintln(w, "  _arguments \\")
+	for _, f := range flags {
+		spec := "-" + f.name + "[" + zshEscape.Replace(f.usage) + "]"
+		if f.repeat {
+			spec = "*" + spec
+		}
+		switch {
+		case fileFlags[f.name]:
+			spec += ":file:_files"
+		case dirFlags[f.name]:
+			spec += ":directory:_files -/"
+		case f.choices != nil:
+			spec += ":" + f.name + ":(" + strings.Join(f.choices, " ") + ")"
+		case f.value:
+			spec += ":" + f.name + ": "
+		}
+		fmt.Fprintf(w, "    '%s' \\\n", spec)
+	}
+	fmt.Fprintln(w, "    '*:path:_files'")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "\n_%s \"$@\"\n", progName)
+}
+
+// fishEscape makes s safe inside a single-quoted fish string.
+var fishEscape = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func writeFish(w io.Writer, flags []completionFlag) {
+	fmt.Fprintf(w, "# fish completion for %s\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a completion -d 'print a shell completion script'\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a dump-rules -d 'print the effective rules as a dict'\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a explain -d 'show why a file scored what it did'\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a generate -d 'print a pre-commit hook definition'\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a install-hook -d 'add the pre-commit hook to .pre-commit-config.yaml'\n", progName)
+	fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a test-rule -d 'run one rule over example files'\n", progName)
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c %s -o %s", progName, f.name)
+		switch {
+		case fileFlags[f.name]:
+			line += " -r -F"
+		case dirFlags[f.name]:
+			line += " -r -a '(__fish_complete_directories)'"
+		case f.choices != nil:
+			line += " -x -a '" + strings.Join(f.choices, " ") + "'"
+		case f.value:
+			line += " -x"
+		}
+		fmt.Fprintf(w, "%s -d '%s'\n", line, fishEscape.Replace(f.usage))
+	}
+}
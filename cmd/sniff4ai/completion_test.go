@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completionFlagSet mirrors the kinds of flags parseFlags defines.
+func completionFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(progName, flag.ContinueOnError)
+	var dicts listFlag
+	fs.Var(&dicts, "dict", "JSON/YAML/TOML with extra rules")
+	fs.String("ignore-file", "", "custom ignore file path")
+	fs.String("cache-dir", "", "cache stored here")
+	fs.String("format", "text", "output format: text, json, sarif or html")
+	fs.String("t", "", "score threshold")
+	fs.Bool("vv", false, "very verbose [with rule names]")
+	return fs
+}
+
+func TestWriteCompletion(t *testing.T) {
+	fs := completionFlagSet()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		require.NoError(t, writeCompletion(&buf, shell, fs), shell)
+		for _, name := range []string{"dict", "ignore-file", "cache-dir", "format", "vv"} {
+			assert.Contains(t, buf.String(), name, "%s script lists -%s", shell, name)
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.Error(t, writeCompletion(&buf, "tcsh", fs))
+}
+
+func TestWriteCompletionBash(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCompletion(&buf, "bash", completionFlagSet()))
+	out := buf.String()
+	assert.Contains(t, out, "-dict|--dict|-ignore-file|--ignore-file)\n            COMPREPLY=($(compgen -f")
+	assert.Contains(t, out, "-cache-dir|--cache-dir)\n            COMPREPLY=($(compgen -d")
+	assert.Contains(t, out, `compgen -W "text json json-array ndjson sarif html csv junit gha"`)
+	assert.Contains(t, out, "complete -o filenames -F _sniff4ai sniff4ai")
+}
+
+func TestWriteCompletionZsh(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCompletion(&buf, "zsh", completionFlagSet()))
+	out := buf.String()
+	assert.Contains(t, out, "#compdef sniff4ai")
+	assert.Contains(t, out, "'*-dict[JSON/YAML/TOML with extra rules]:file:_files'", "repeatable, completes files")
+	assert.Contains(t, out, "'-ignore-file[custom ignore file path]:file:_files'")
+	assert.Contains(t, out, `'-vv[very verbose \[with rule names\]]'`, "brackets are escaped")
+	assert.Contains(t, out, `'-format[output format\: text, json, sarif or html]:format:(text json json-array ndjson sarif html csv junit gha)'`)
+}
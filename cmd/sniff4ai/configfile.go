@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+)
+
+// discoverConfig loads the nearest .synthsniff.yaml/.json/.toml above the working
+// directory. Only that file is used; parent files are not merged in.
+func discoverConfig() (sniff.Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return sniff.Config{}, err
+	}
+	path, err := sniff.FindConfigFile(wd)
+	if err != nil || path == "" {
+		return sniff.Config{}, err
+	}
+	return sniff.LoadConfigFile(path)
+}
+
+// setFlags returns the names of flags given on the command line.
+func setFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// applyConfigFile copies config file values into cfg for every flag the
+// user did not set explicitly. The threshold is resolved by the caller
+// because the environment variable sits between flags and the file.
+func applyConfigFile(cfg *sniff.Config, file sniff.Config, set map[string]bool) {
+	if !set["dict"] && len(file.DictPaths) > 0 {
+		cfg.DictPaths = file.DictPaths
+	}
+	if !set["strict-dict"] && file.StrictDict {
+		cfg.StrictDict = true
+	}
+	if !set["disable-rule"] && len(file.DisabledRules) > 0 {
+		cfg.DisabledRules = file.DisabledRules
+	}
+	if !set["only-tags"] && len(file.OnlyTags) > 0 {
+		cfg.OnlyTags = file.OnlyTags
+	}
+	if !set["exclude-tags"] && len(file.ExcludeTags) > 0 {
+		cfg.ExcludeTags = file.ExcludeTags
+	}
+	if !set["set-weight"] && len(file.WeightOverrides) > 0 {
+		cfg.WeightOverrides = file.WeightOverrides
+	}
+	if !set["min-severity"] && file.MinSeverity != "" {
+		cfg.MinSeverity = file.MinSeverity
+	}
+	if !set["confidence-scale"] && file.ConfidenceScale > 0 {
+		cfg.ConfidenceScale = file.ConfidenceScale
+	}
+	if !set["normalize"] && file.Normalize {
+		cfg.Normalize = true
+	}
+	if !set["unicode-norm"] && file.UnicodeNorm != "" {
+		cfg.UnicodeNorm = file.UnicodeNorm
+	}
+	if !set["max"] && file.MaxSize > 0 {
+		cfg.MaxSize = file.MaxSize
+	}
+	if !set["min-size"] && file.MinSize > 0 {
+		cfg.MinSize = file.MinSize
+	}
+	if !set["chunked"] && file.ChunkedScan {
+		cfg.ChunkedScan = true
+	}
+	if !set["chunk-size"] && file.ChunkSize > 0 {
+		cfg.ChunkSize = file.ChunkSize
+	}
+	if !set["force-binary"] && file.ForceBinary {
+		cfg.ForceBinary = true
+	}
+	if !set["force-ext"] && len(file.ForcedExts) > 0 {
+		cfg.ForcedExts = file.ForcedExts
+	}
+	if !set["mmap-threshold"] && file.MmapThreshold > 0 {
+		cfg.MmapThreshold = file.MmapThreshold
+	}
+	if !set["max-errors"] && file.MaxErrors > 0 {
+		cfg.MaxErrors = file.MaxErrors
+	}
+	if !set["j"] && file.Workers > 0 {
+		cfg.Workers = file.Workers
+	}
+	if !set["v"] && file.Verbose {
+		cfg.Verbose = true
+	}
+	if !set["vv"] && file.VeryVerbose {
+		cfg.VeryVerbose = true
+	}
+	if !set["vvv"] && file.UltraVerbose {
+		cfg.UltraVerbose = true
+	}
+	if !set["ci"] && file.CIMode {
+		cfg.CIMode = true
+	}
+	if !set["exit-clean"] && file.ExitCodeClean != 0 {
+		cfg.ExitCodeClean = file.ExitCodeClean
+	}
+	if !set["exit-smelly"] && file.ExitCodeSmelly != 0 {
+		cfg.ExitCodeSmelly = file.ExitCodeSmelly
+	}
+	if !set["exit-warning"] && file.ExitCodeWarning != 0 {
+		cfg.ExitCodeWarning = file.ExitCodeWarning
+	}
+	if !set["exit-error"] && file.ExitCodeError != 0 {
+		cfg.ExitCodeError = file.ExitCodeError
+	}
+	if !set["exit-timeout"] && file.ExitCodeTimeout != 0 {
+		cfg.ExitCodeTimeout = file.ExitCodeTimeout
+	}
+	if !set["format"] && !set["json"] && file.Format != "" {
+		cfg.Format = file.Format
+	}
+	if !set["json-version"] && file.JSONVersion != 0 {
+		cfg.JSONVersion = file.JSONVersion
+	}
+	if !set["color"] && !set["no-color"] && file.Color != "" {
+		cfg.Color = file.Color
+	}
+	if !set["no-emoji"] && file.NoEmoji {
+		cfg.NoEmoji = true
+	}
+	if !set["use-gitignore"] && file.UseGitignore {
+		cfg.UseGitignore = true
+	}
+	if !set["auto-discover-rules"] && file.AutoDiscoverRules {
+		cfg.AutoDiscoverRules = true
+	}
+	if !set["follow-symlinks"] && file.FollowSymlinks {
+		cfg.FollowSymlinks = true
+	}
+	if !set["name"] && len(file.NamePatterns) > 0 {
+		cfg.NamePatterns = file.NamePatterns
+	}
+	if !set["include"] && len(file.IncludePatterns) > 0 {
+		cfg.IncludePatterns = file.IncludePatterns
+	}
+	if !set["exclude"] && len(file.ExcludePatterns) > 0 {
+		cfg.ExcludePatterns = file.ExcludePatterns
+	}
+	if !set["depth"] && file.MaxDepth != nil {
+		cfg.MaxDepth = file.MaxDepth
+	}
+	if !set["ignore-file"] && file.IgnoreFile != "" {
+		cfg.IgnoreFile = file.IgnoreFile
+	}
+	if !set["allowlist"] && file.Allowlist != "" {
+		cfg.Allowlist = file.Allowlist
+	}
+	if !set["cache-dir"] && file.CacheDir != "" {
+		cfg.CacheDir = file.CacheDir
+	}
+	if !set["scan-archives-recursively"] && file.ScanArchivesRecursively {
+		cfg.ScanArchivesRecursively = true
+	}
+	if !set["dedup"] && file.DeduplicateContent {
+		cfg.DeduplicateContent = true
+	}
+	if !set["git-diff"] && file.GitDiff {
+		cfg.GitDiff = true
+	}
+	if !set["git-base"] && file.GitBase != "" {
+		cfg.GitBase = file.GitBase
+	}
+	if !set["stdin-ext"] && file.StdinExt != "" {
+		cfg.StdinExt = file.StdinExt
+	}
+	if !set["detect-mime"] && file.DetectMIME {
+		cfg.DetectMIME = true
+	}
+	if !set["detect-language"] && file.DetectLanguage {
+		cfg.DetectLanguage = true
+	}
+	if !set["exclude-code-blocks"] && file.ExcludeCodeBlocks {
+		cfg.ExcludeCodeBlocks = true
+	}
+	if !set["strip-front-matter"] && file.StripFrontMatter {
+		cfg.StripFrontMatter = true
+	}
+	if !set["snippets"] && file.Snippets {
+		cfg.Snippets = true
+	}
+	if !set["snippet-width"] && file.SnippetWidth > 0 {
+		cfg.SnippetWidth = file.SnippetWidth
+	}
+	if !set["quiet"] && file.Quiet {
+		cfg.Quiet = true
+	}
+	if !set["print0"] && file.Print0 {
+		cfg.Print0 = true
+	}
+	if !set["invert"] && file.Invert {
+		cfg.Invert = true
+	}
+	if !set["count"] && file.CountOnly {
+		cfg.CountOnly = true
+	}
+	if !set["top"] && file.Top > 0 {
+		cfg.Top = file.Top
+	}
+	if !set["top-all"] && file.TopAll {
+		cfg.TopAll = true
+	}
+	if !set["timing"] && file.TimingMode {
+		cfg.TimingMode = true
+	}
+	if !set["grades"] && file.Grades {
+		cfg.Grades = true
+	}
+	if !set["heatmap"] && file.Heatmap > 0 {
+		cfg.Heatmap = file.Heatmap
+	}
+	if !set["rule-stats"] && file.RuleStats {
+		cfg.RuleStats = true
+	}
+	if !set["grade-thresholds"] && len(file.GradeBoundaries) > 0 {
+		cfg.GradeBoundaries, cfg.Grades = file.GradeBoundaries, true
+	}
+	cfg.ExtraRules = append(cfg.ExtraRules, file.ExtraRules...)
+}
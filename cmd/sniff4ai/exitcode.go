@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+)
+
+// exit ends the process; tests replace it.
+var exit = os.Exit
+
+// errorExit is the status fatal exits with: -exit-error once the flags
+// are parsed.
+var errorExit = sniff.DefaultExitCodeError
+
+// fatal logs v like log.Fatal, then exits with errorExit.
+func fatal(v ...any) {
+	log.Print(v...)
+	exit(errorExit)
+}
+
+// fatalf logs like log.Fatalf, then exits with errorExit.
+func fatalf(format string, v ...any) {
+	log.Printf(format, v...)
+	exit(errorExit)
+}
+
+// checkExitCodes rejects exit codes outside 0..255 and two outcomes
+// mapped to the same code, which a script could not tell apart.
+func checkExitCodes(cfg sniff.Config) error {
+	codes := []struct {
+		flag string
+		code int
+	}{
+		{"-exit-clean", cfg.ExitCodeClean},
+		{"-exit-smelly", cfg.ExitCodeSmelly},
+		{"-exit-warning", cfg.ExitCodeWarning},
+		{"-exit-error", cfg.ExitCodeError},
+		{"-exit-timeout", cfg.ExitCodeTimeout},
+	}
+	seen := make(map[int]string, len(codes))
+	for _, c := range codes {
+		if c.code < 0 || c.code > 255 {
+			return fmt.Errorf("invalid %s %d (want 0 to 255)", c.flag, c.code)
+		}
+		if prev, ok := seen[c.code]; ok {
+			return fmt.Errorf("%s and %s are both %d", prev, c.flag, c.code)
+		}
+		seen[c.code] = c.flag
+	}
+	return nil
+}
+
+// scanOutcome is what decides a finished scan's exit code.
+type scanOutcome struct {
+	timedOut  bool
+	rendered  sniff.RenderResult
+	clean     bool // some file was scored and is not smelly
+	baseline  bool // compared against -compare-baseline
+	regressed bool // and some file got worse
+}
+
+// exitStatus returns the exit code of a finished scan: the timeout code
+// when it timed out, else with -ci the smelly code for a smelly file (a
+// clean one with -invert) or a baseline regression and the warning code
+// for warnings alone, else the clean code.
+func exitStatus(cfg sniff.Config, o scanOutcome) int {
+	switch {
+	case o.timedOut:
+		return cfg.ExitCodeTimeout
+	case !cfg.CIMode:
+		return cfg.ExitCodeClean
+	case cfg.Invert:
+		// Every file must be smelly, say in a synthetic data set
+		if o.clean {
+			return cfg.ExitCodeSmelly
+		}
+	case o.baseline:
+		// Against a baseline only regressions fail
+		if o.regressed {
+			return cfg.ExitCodeSmelly
+		}
+	case o.rendered.AnyErrors:
+		return cfg.ExitCodeSmelly
+	case o.rendered.AnyWarnings:
+		return cfg.ExitCodeWarning
+	}
+	return cfg.ExitCodeClean
+}
+
+// anyClean reports whether some file in results was read, scored and
+// found not smelly.
+func anyClean(results []sniff.Result) bool {
+	for _, r := range results {
+		if r.Clean() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+	"github.com/stretchr/testify/assert"
+)
+
+// exitCodes returns the CLI's default exit codes.
+func exitCodes() sniff.Config {
+	return sniff.Config{
+		ExitCodeClean:   sniff.DefaultExitCodeClean,
+		ExitCodeSmelly:  sniff.DefaultExitCodeSmelly,
+		ExitCodeWarning: sniff.DefaultExitCodeWarning,
+		ExitCodeError:   sniff.DefaultExitCodeError,
+		ExitCodeTimeout: sniff.DefaultExitCodeTimeout,
+	}
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	assert.NoError(t, checkExitCodes(exitCodes()))
+
+	cfg := exitCodes()
+	cfg.ExitCodeSmelly = 5
+	assert.NoError(t, checkExitCodes(cfg), "1 is free for other pipeline errors")
+
+	cfg = exitCodes()
+	cfg.ExitCodeSmelly = 0
+	assert.EqualError(t, checkExitCodes(cfg), "-exit-clean and -exit-smelly are both 0")
+
+	cfg = exitCodes()
+	cfg.ExitCodeTimeout = 2
+	assert.EqualError(t, checkExitCodes(cfg), "-exit-error and -exit-timeout are both 2")
+
+	cfg = exitCodes()
+	cfg.ExitCodeSmelly = sniff.DefaultExitCodeError
+	assert.EqualError(t, checkExitCodes(cfg), "-exit-smelly and -exit-error are both 2")
+
+	cfg = exitCodes()
+	cfg.ExitCodeWarning = sniff.DefaultExitCodeError
+	assert.EqualError(t, checkExitCodes(cfg), "-exit-warning and -exit-error are both 2", "warnings and tool errors stay apart")
+
+	cfg = exitCodes()
+	cfg.ExitCodeError = 256
+	assert.EqualError(t, checkExitCodes(cfg), "invalid -exit-error 256 (want 0 to 255)")
+}
+
+func TestExitStatus(t *testing.T) {
+	cfg := exitCodes()
+	cfg.ExitCodeClean, cfg.ExitCodeSmelly, cfg.ExitCodeWarning, cfg.ExitCodeTimeout = 10, 5, 8, 7
+	smelly := scanOutcome{rendered: sniff.RenderResult{AnyErrors: true}}
+	warned := scanOutcome{rendered: sniff.RenderResult{AnyWarnings: true}, clean: true}
+
+	assert.Equal(t, 10, exitStatus(cfg, smelly), "without -ci")
+	assert.Equal(t, 7, exitStatus(cfg, scanOutcome{timedOut: true}))
+
+	cfg.CIMode = true
+	assert.Equal(t, 5, exitStatus(cfg, smelly))
+	assert.Equal(t, 8, exitStatus(cfg, warned))
+	assert.Equal(t, 10, exitStatus(cfg, scanOutcome{clean: true}))
+	assert.Equal(t, 10, exitStatus(cfg, scanOutcome{rendered: smelly.rendered, baseline: true}), "no regression")
+	assert.Equal(t, 5, exitStatus(cfg, scanOutcome{baseline: true, regressed: true}))
+	assert.Equal(t, 7, exitStatus(cfg, scanOutcome{timedOut: true, rendered: smelly.rendered}))
+
+	cfg.Invert = true
+	assert.Equal(t, 10, exitStatus(cfg, smelly), "every file is smelly")
+	assert.Equal(t, 5, exitStatus(cfg, scanOutcome{rendered: smelly.rendered, clean: true}))
+	assert.Equal(t, 5, exitStatus(cfg, warned), "a warning file is not smelly")
+}
+
+func TestAnyClean(t *testing.T) {
+	assert.False(t, anyClean([]sniff.Result{{Path: "a.md", Smelly: true}}))
+	assert.False(t, anyClean([]sniff.Result{{Path: "x.zip", Err: "zip: not a valid zip file"}}), "unreadable files are not clean")
+	assert.False(t, anyClean([]sniff.Result{{Path: "big.md", Skipped: true}}), "nor are skipped ones")
+	assert.True(t, anyClean([]sniff.Result{{Path: "a.md", Smelly: true}, {Path: "b.md", Warning: true}}))
+}
+
+func TestFatalExitCode(t *testing.T) {
+	var code int
+	oldExit, oldCode := exit, errorExit
+	t.Cleanup(func() { exit, errorExit = oldExit, oldCode })
+	exit = func(c int) { code = c }
+
+	fatal("boom")
+	assert.Equal(t, sniff.DefaultExitCodeError, code)
+
+	errorExit = 9
+	fatalf("boom %d", 2)
+	assert.Equal(t, 9, code)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// listFlag collects the values of a flag given more than once.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// commaListFlag collects comma-separated values, from one flag or many.
+type commaListFlag []string
+
+func (l *commaListFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *commaListFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// weightFlag collects -set-weight name=N into a map of rule weights.
+type weightFlag map[string]int
+
+func (w *weightFlag) String() string {
+	if w == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*w))
+	for name, n := range *w {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", name, n))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (w *weightFlag) Set(v string) error {
+	name, weight, ok := strings.Cut(v, "=")
+	n, err := strconv.Atoi(strings.TrimSpace(weight))
+	if !ok || strings.TrimSpace(name) == "" || err != nil {
+		return fmt.Errorf("want <rule>=<weight>, got %q", v)
+	}
+	if *w == nil {
+		*w = make(weightFlag)
+	}
+	(*w)[strings.TrimSpace(name)] = n
+	return nil
+}
+
+// optionalIntFlag is an int flag that may be given bare, as -heatmap for
+// -heatmap=10. A value must follow an equals sign.
+type optionalIntFlag struct {
+	n    *int
+	bare int // value of the bare flag
+}
+
+func (f *optionalIntFlag) String() string {
+	if f == nil || f.n == nil {
+		return "0"
+	}
+	return strconv.Itoa(*f.n)
+}
+
+func (f *optionalIntFlag) Set(v string) error {
+	switch v {
+	case "true":
+		*f.n = f.bare
+		return nil
+	case "false":
+		*f.n = 0
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("want a count of at least 0, got %q", v)
+	}
+	*f.n = n
+	return nil
+}
+
+// IsBoolFlag lets the flag package accept the bare form.
+func (f *optionalIntFlag) IsBoolFlag() bool { return true }
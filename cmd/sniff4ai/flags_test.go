@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommaListFlag(t *testing.T) {
+	var tags []string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var((*commaListFlag)(&tags), "only-tags", "")
+
+	require.NoError(t, fs.Parse([]string{"-only-tags=typography, structure", "-only-tags", "x,"}))
+	assert.Equal(t, []string{"typography", "structure", "x"}, tags)
+}
+
+func TestWeightFlag(t *testing.T) {
+	var w map[string]int
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var((*weightFlag)(&w), "set-weight", "")
+
+	require.NoError(t, fs.Parse([]string{"-set-weight", "em-dash=0", "-set-weight=delve=-5", "-set-weight", "em-dash=2"}))
+	assert.Equal(t, map[string]int{"em-dash": 2, "delve": -5}, w, "the last value wins")
+	assert.Equal(t, "delve=-5,em-dash=2", fs.Lookup("set-weight").Value.String())
+
+	for _, bad := range []string{"em-dash", "em-dash=x", "=3"} {
+		assert.Error(t, fs.Parse([]string{"-set-weight", bad}), bad)
+	}
+}
+
+func TestOptionalIntFlag(t *testing.T) {
+	parse := func(args ...string) (int, error) {
+		var n int
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(&optionalIntFlag{&n, 10}, "heatmap", "")
+		err := fs.Parse(args)
+		return n, err
+	}
+
+	n, err := parse()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = parse("-heatmap")
+	require.NoError(t, err)
+	assert.Equal(t, 10, n, "bare flag")
+
+	n, err = parse("-heatmap=4")
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	_, err = parse("-heatmap=-1")
+	assert.Error(t, err)
+}
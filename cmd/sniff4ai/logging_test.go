@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "warn", "json")
+	require.NoError(t, err)
+	logger.Info("hidden")
+	logger.Warn("shown", "path", "a.md")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), "one JSON object per entry")
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "shown", entry["msg"])
+	assert.Equal(t, "a.md", entry["path"])
+
+	buf.Reset()
+	logger, err = newLogger(&buf, "DEBUG", "text")
+	require.NoError(t, err)
+	logger.Debug("detail")
+	assert.Contains(t, buf.String(), "level=DEBUG msg=detail")
+
+	_, err = newLogger(&buf, "loud", "text")
+	assert.Error(t, err)
+	_, err = newLogger(&buf, "info", "xml")
+	assert.Error(t, err)
+}
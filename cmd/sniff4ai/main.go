@@ -2,70 +2,723 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
+	"slices"
+	"syscall"
+	"time"
 
 	"github.com/JoobyPM/synthsniff/internal/sniff"
+	"golang.org/x/term"
 )
 
 const (
 	envThreshold     = "SYNTHSNIFF_THRESHOLD"
-	defaultThreshold = 30
-	exitSmelly       = 1
+	exitInterrupted  = 130
+	progressInterval = 100 * time.Millisecond
 )
 
 func main() {
-	// Set GOMAXPROCS to the number of available cores, but not more than 4
-	maxProcs := runtime.NumCPU()
-	if maxProcs > 4 {
-		maxProcs = 4
+	// GOMAXPROCS caps the OS threads running Go code; the runtime default
+	// already honours the GOMAXPROCS variable and container CPU limits.
+	// -j sets the number of scan workers, which is a separate knob.
+	cfg, paths, opts := parseFlags()
+	setMaxProcs(opts.maxProcs)
+	logger, err := newLogger(os.Stderr, opts.logLevel, opts.logFormat)
+	if err != nil {
+		fatal(err)
 	}
-	runtime.GOMAXPROCS(maxProcs)
+	slog.SetDefault(logger)
 
-	cfg, paths := parseFlags()
-	if len(paths) == 0 {
-		log.Fatal("at least one file or directory is required")
+	if opts.dumpRules != "" {
+		rules, err := sniff.EffectiveRules(cfg)
+		if err != nil {
+			fatal(err)
+		}
+		if err := sniff.DumpRules(rules, opts.dumpRules, os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if opts.explain {
+		runExplain(cfg, paths)
+		return
+	}
+	if opts.testRule {
+		runTestRule(cfg, opts.ruleName, paths)
+		return
+	}
+	if opts.traceIgnore != "" {
+		runTraceIgnore(cfg, opts.traceIgnore, paths)
+		return
 	}
 
-	results, err := sniff.Scan(paths, cfg)
+	// Ctrl-C cancels the scan instead of killing the process mid-write
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if opts.serveAddr != "" {
+		err := serve(ctx, opts.serveAddr, cfg)
+		stop()
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+	// Git modes pick their own files
+	gitFiles := cfg.GitDiff || opts.staged || opts.unstaged
+	if (opts.staged || opts.unstaged) && len(paths) > 0 {
+		slog.Warn("path arguments are ignored with -staged and -unstaged")
+	}
+	if len(paths) == 0 && !gitFiles {
+		fatal("at least one file or directory is required")
+	}
+	// A bad -output path fails before any file is scanned
+	out, err := cfg.OpenOutput()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+	var baseline *sniff.Baseline
+	if opts.compareBaseline != "" {
+		b, err := sniff.LoadBaseline(opts.compareBaseline)
+		if err != nil {
+			fatal(err)
+		}
+		baseline = &b
+	}
+	if opts.dryRun {
+		err := runDryRun(ctx, out, paths, cfg)
+		stop()
+		if err != nil {
+			fatal(err)
+		}
+		if err := out.Close(); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if opts.watch {
+		err := runWatch(ctx, out, paths, cfg, opts.summary)
+		stop()
+		if err != nil {
+			fatal(err)
+		}
+		if err := out.Close(); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	var results []sniff.Result
+	var rr sniff.RenderResult
+	start := time.Now()
+	// NDJSON lines go out as files finish, so it renders while scanning
+	streamed := cfg.Format == sniff.FormatNDJSON && !gitFiles
+	stopProgress := func() {}
+	if !gitFiles && !streamed {
+		stopProgress = startProgress(&cfg)
+	}
+	// One rule load serves the scan and the JSON v2 rule count
+	scanner, err := sniff.NewScanner(sniff.WithConfig(cfg))
+	if err != nil {
+		fatal(err)
+	}
+	switch {
+	case cfg.GitDiff:
+		results, err = scanner.ScanGitDiff(ctx, cfg.GitBase, paths)
+	case opts.staged:
+		results, err = scanner.ScanGitStaged(ctx)
+	case opts.unstaged:
+		results, err = scanner.ScanGitUnstaged(ctx)
+	case streamed:
+		results, rr, err = streamScan(ctx, out, paths, scanner, cfg)
+	default:
+		results, err = scanner.Scan(ctx, paths)
+	}
+	stopProgress()
+	stop()
+	if errors.Is(err, context.Canceled) {
+		slog.Warn("scan cancelled")
+		exit(exitInterrupted)
+	}
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !timedOut {
+		fatal(err)
+	}
+
+	// A timed-out scan still reports what it finished
+	cfg.Started, cfg.Elapsed = start, time.Since(start)
+	if cfg.Format == sniff.FormatJSON && cfg.JSONVersion == sniff.JSONReportV2 {
+		cfg.RuleCount = len(scanner.Rules())
+	}
+	if !streamed {
+		rr = sniff.Render(out, results, cfg)
+	}
+	printSummary(out, results, cfg, opts.summary)
+	var changes []sniff.BaselineChange
+	if baseline != nil {
+		changes = sniff.CompareBaseline(*baseline, results)
+		// Only text reports have room for the diff on stdout, not -print0
+		// or -count
+		var w io.Writer = os.Stderr
+		if cfg.Format == sniff.FormatText && !cfg.Print0 && !cfg.CountOnly {
+			w = out
+		}
+		sniff.RenderBaselineDiff(w, changes, cfg)
+	}
+	if err := out.Close(); err != nil {
+		fatal(err)
+	}
+	if opts.writeBaseline != "" {
+		// A partial scan would drop every unfinished file from the baseline
+		if timedOut {
+			fatalf("scan timed out; baseline %s not written", opts.writeBaseline)
+		}
+		if err := sniff.WriteBaseline(opts.writeBaseline, results); err != nil {
+			fatal(err)
+		}
+	}
+	reportMetrics(cfg.Metrics, opts.pushgateway)
+	reportProfile(cfg.Profiler, opts.profileOutput)
+	if opts.ignoreStats {
+		reportIgnoreStats(cfg.IgnoreStats)
+	}
+	reportFileErrors(results, cfg)
+	if timedOut {
+		icon := "⏱ "
+		if cfg.NoEmoji {
+			icon = ""
+		}
+		fmt.Fprintf(os.Stderr, "%sscan timed out after %v, %d files scanned\n", icon, cfg.Timeout, len(results))
+		exit(exitStatus(cfg, scanOutcome{timedOut: true}))
+	}
+	exit(exitStatus(cfg, scanOutcome{
+		rendered:  rr,
+		clean:     anyClean(results),
+		baseline:  baseline != nil,
+		regressed: sniff.BaselineFailed(changes),
+	}))
+}
+
+// reportFileErrors tells on stderr how many files could not be read;
+// each is listed in the report with its error.
+func reportFileErrors(results []sniff.Result, cfg sniff.Config) {
+	n := 0
+	for _, r := range results {
+		if r.Err != "" {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	icon := "❌ "
+	if cfg.NoEmoji {
+		icon = ""
+	}
+	fmt.Fprintf(os.Stderr, "%s%d file(s) could not be read\n", icon, n)
+}
+
+// printSummary prints the scan summary line, and the rule stats with
+// -rule-stats, where -summary says; "stdout" means out, the report's
+// destination. Only text reports get it there: JSON carries the summary
+// and rule stats itself, and the other formats, -print0 lists and
+// -count must stay parseable.
+func printSummary(out io.Writer, results []sniff.Result, cfg sniff.Config, dest string) {
+	var w io.Writer = os.Stderr
+	switch {
+	case dest == "off":
+		return
+	case dest == "stdout" && (cfg.Format != sniff.FormatText || cfg.Print0 || cfg.CountOnly):
+		return
+	case dest == "stdout":
+		w = out
+	}
+	sniff.RenderSummary(w, sniff.ComputeSummary(results, cfg.Elapsed), cfg)
+	if cfg.RuleStats && cfg.Format != sniff.FormatJSON {
+		sniff.RenderRuleStats(w, sniff.AggregateRuleStats(results), cfg)
 	}
+}
+
+// streamScan renders each result as the scan produces it and returns them
+// all for the summary.
+func streamScan(ctx context.Context, out io.Writer, paths []string, scanner *sniff.Scanner, cfg sniff.Config) ([]sniff.Result, sniff.RenderResult, error) {
+	stream, errs := scanner.ScanStream(ctx, paths)
+	var results []sniff.Result
+	tee := make(chan sniff.Result)
+	go func() {
+		defer close(tee)
+		for r := range stream {
+			results = append(results, r)
+			tee <- r
+		}
+	}()
+	rr := sniff.RenderStream(out, tee, cfg)
+	return results, rr, <-errs
+}
 
-	if sniff.Render(results, cfg) && cfg.CIMode {
-		os.Exit(exitSmelly)
+// startProgress shows a live progress line on stderr when it is a terminal
+// and the output is meant for people. The returned func erases it.
+func startProgress(cfg *sniff.Config) func() {
+	if cfg.Quiet || cfg.Format == sniff.FormatJSON || cfg.Format == sniff.FormatJSONArray || cfg.Format == sniff.FormatNDJSON || cfg.Format == sniff.FormatCSV || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return func() {}
 	}
+	cfg.Progress = &sniff.Progress{}
+	return cfg.Progress.Report(os.Stderr, progressInterval)
+}
+
+// cliOptions are the flags that steer the command rather than the scan.
+type cliOptions struct {
+	serveAddr       string // -serve
+	pushgateway     string // -metrics-pushgateway
+	profileOutput   string // -profile-output: rule timings file, "" = stderr
+	logLevel        string // -log-level
+	logFormat       string // -log-format
+	maxProcs        int    // -max-procs, 0 = runtime default
+	dumpRules       string // dump-rules subcommand: the rule format, json or yaml
+	testRule        bool   // test-rule subcommand
+	explain         bool   // explain subcommand
+	ruleName        string // -rule, the rule test-rule runs
+	traceIgnore     string // -trace-ignore: explain whether a scan ignores this path
+	ignoreStats     bool   // -ignore-stats: print the matches per ignore pattern
+	summary         string // -summary: stderr, stdout or off
+	watch           bool   // -watch: rescan changed files until interrupted
+	dryRun          bool   // -dry-run: list the files a scan would score
+	writeBaseline   string // -write-baseline: save the results as a baseline here
+	compareBaseline string // -compare-baseline: report and fail on changes since this baseline
+	staged          bool   // -staged: scan the files staged for commit, as staged
+	unstaged        bool   // -unstaged: scan changed files not staged yet
 }
 
-func parseFlags() (sniff.Config, []string) {
+func parseFlags() (sniff.Config, []string, cliOptions) {
+	var opts cliOptions
 	var cfg sniff.Config
-	flag.StringVar(&cfg.DictPath, "dict", "", "JSON/YAML with extra rules")
-	flag.IntVar(&cfg.Threshold, "t", -1, "score threshold (env SYNTHSNIFF_THRESHOLD)")
-	flag.Int64Var(&cfg.MaxSize, "max", 10<<20, "max file size (bytes)")
-	flag.IntVar(&cfg.Workers, "j", 0, "parallel workers (default = CPUs)")
+	flag.Var((*listFlag)(&cfg.DictPaths), "dict", "JSON/YAML/TOML file or http(s) URL with extra rules (repeatable, merged in order)")
+	flag.DurationVar(&cfg.DictTimeout, "dict-timeout", sniff.DefaultDictTimeout, "timeout for downloading an http(s) -dict")
+	flag.BoolVar(&cfg.InsecureDict, "insecure-dict", false, "accept invalid TLS certificates from an https -dict")
+	flag.BoolVar(&cfg.StrictDict, "strict-dict", false, "fail when two rules share a pattern")
+	flag.Var((*listFlag)(&cfg.DisabledRules), "disable-rule", "skip the rule with this name (repeatable)")
+	flag.Var((*commaListFlag)(&cfg.OnlyTags), "only-tags", "load only rules with one of these comma-separated tags, e.g. typography,structure")
+	flag.Var((*commaListFlag)(&cfg.ExcludeTags), "exclude-tags", "skip rules with one of these comma-separated tags")
+	flag.Var((*weightFlag)(&cfg.WeightOverrides), "set-weight", "give a rule a new weight, as <rule>=<weight>; 0 stops it scoring (repeatable)")
+	flag.StringVar(&cfg.MinSeverity, "min-severity", "", "load only rules of this severity or higher: info, warn or error")
+	threshold := flag.String("t", "", "score threshold (env SYNTHSNIFF_THRESHOLD); decimals with -normalize")
+	flag.StringVar(threshold, "error-threshold", "", "same as -t")
+	warnThreshold := flag.String("warn-threshold", "", "flag files scoring from here up to the threshold as warnings (exit 4 with -ci)")
+	flag.Float64Var(&cfg.ConfidenceScale, "confidence-scale", sniff.DefaultConfidenceScale, "confidence reaches 100% at this many times the threshold")
+	flag.BoolVar(&cfg.Normalize, "normalize", false, "score per KB of content instead of per file")
+	flag.StringVar(&cfg.UnicodeNorm, "unicode-norm", "", "normalize content and patterns before matching: NFC, NFD, NFKC or NFKD")
+	flag.Int64Var(&cfg.MaxSize, "max", sniff.DefaultMaxSize, "max file size (bytes)")
+	flag.Int64Var(&cfg.MinSize, "min-size", 0, "skip files smaller than this many bytes unread (listed as skipped with -vvv)")
+	flag.BoolVar(&cfg.ChunkedScan, "chunked", false, "score files larger than -max in chunks instead of skipping them")
+	flag.Int64Var(&cfg.ChunkSize, "chunk-size", sniff.DefaultChunkSize, "bytes per chunk with -chunked")
+	flag.BoolVar(&cfg.ForceBinary, "force-binary", false, "score files even when they contain NUL bytes")
+	flag.Var((*listFlag)(&cfg.ForcedExts), "force-ext", "score files with this extension despite NUL bytes, e.g. .ipynb (repeatable)")
+	flag.Int64Var(&cfg.MmapThreshold, "mmap-threshold", sniff.DefaultMmapThreshold, "read files up to this size (bytes) with ReadFile, memory-map larger ones")
+	flag.IntVar(&cfg.MaxErrors, "max-errors", 0, "stop once more than this many files cannot be read (0 = no limit)")
+	flag.IntVar(&cfg.Workers, "j", 0, "parallel workers (default = CPUs, up to 4)")
+	flag.IntVar(&opts.maxProcs, "max-procs", 0, "OS threads running Go code at once (default GOMAXPROCS env or CPUs)")
+	flag.DurationVar(&cfg.Timeout, "timeout", 0, "stop after this long (e.g. 60s), report partial results and exit 3 (see -exit-timeout)")
 
 	flag.BoolVar(&cfg.Verbose, "v", false, "verbose per‑file counts")
 	flag.BoolVar(&cfg.VeryVerbose, "vv", false, "very verbose with rule names")
 	flag.BoolVar(&cfg.UltraVerbose, "vvv", false, "ultra verbose with rule metadata")
 
-	flag.BoolVar(&cfg.CIMode, "ci", false, "exit non‑zero on AI smell")
-	flag.BoolVar(&cfg.JSON, "json", false, "machine‑readable JSON output")
+	flag.BoolVar(&cfg.CIMode, "ci", false, "exit non‑zero on AI smell (1 = smelly, 4 = warnings only; see -exit-smelly and -exit-warning)")
+	flag.IntVar(&cfg.ExitCodeClean, "exit-clean", sniff.DefaultExitCodeClean, "exit code when the scan passes")
+	flag.IntVar(&cfg.ExitCodeSmelly, "exit-smelly", sniff.DefaultExitCodeSmelly, "exit code with -ci when a file is smelly")
+	flag.IntVar(&cfg.ExitCodeWarning, "exit-warning", sniff.DefaultExitCodeWarning, "exit code with -ci when files have warnings but none is smelly")
+	flag.IntVar(&cfg.ExitCodeError, "exit-error", sniff.DefaultExitCodeError, "exit code on an error")
+	flag.IntVar(&cfg.ExitCodeTimeout, "exit-timeout", sniff.DefaultExitCodeTimeout, "exit code when -timeout stops the scan")
+	jsonOut := flag.Bool("json", false, "machine‑readable JSON output (same as -format=json)")
+	flag.StringVar(&cfg.Format, "format", sniff.FormatText, "output format: text, json, json-array, ndjson, sarif, html, csv, junit or gha (default gha on GitHub Actions)")
+	flag.IntVar(&cfg.JSONVersion, "json-version", sniff.JSONReportV1, "JSON report layout: 1, or 2 to add version, config and scan_time")
+	flag.StringVar(&cfg.Color, "color", sniff.ColorAuto, "colorize text output: auto, always or never")
+	noColor := flag.Bool("no-color", false, "disable colors (same as -color=never)")
+	flag.BoolVar(&cfg.NoEmoji, "no-emoji", false, "drop the 🚨/✅ markers from text output")
 	flag.BoolVar(&cfg.UseGitignore, "use-gitignore", false, "respect .gitignore files")
+	flag.BoolVar(&cfg.AutoDiscoverRules, "auto-discover-rules", false, "add the rules in each scanned directory's "+sniff.RulesFileName+" for that subtree")
+	flag.BoolVar(&cfg.FollowSymlinks, "follow-symlinks", false, "scan symlinked files and directories (cycles are skipped)")
+	flag.Var((*listFlag)(&cfg.NamePatterns), "name", "only scan files whose base name matches this glob, like find -name (repeatable)")
+	flag.Var((*listFlag)(&cfg.IncludePatterns), "include", "only scan files whose name or path matches this glob (repeatable)")
+	flag.Var((*listFlag)(&cfg.ExcludePatterns), "exclude", "skip files whose name or path matches this glob, even when named (repeatable)")
+	depth := flag.Int("depth", -1, "subdirectory levels to descend, 0 = only the named directories (default unlimited)")
 	flag.StringVar(&cfg.IgnoreFile, "ignore-file", "", "custom ignore file path")
-	flag.Parse()
+	flag.BoolVar(&opts.ignoreStats, "ignore-stats", false, "after a scan, print how many paths each ignore pattern matched to stderr, most first")
+	flag.StringVar(&cfg.Allowlist, "allowlist", "", "file of path globs, one per line, for files accepted as AI-generated (scored, never smelly)")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", "", "reuse results for unchanged files (cache stored here)")
+	flag.BoolVar(&cfg.ScanArchivesRecursively, "scan-archives-recursively", false, "also scan archives nested inside archives")
+	flag.BoolVar(&cfg.DeduplicateContent, "dedup", false, "score files with identical content and extension once; the other paths are listed as aliases")
+	flag.BoolVar(&cfg.GitDiff, "git-diff", false, "score only lines added since -git-base (paths become a pathspec)")
+	flag.BoolVar(&opts.staged, "staged", false, "scan only the files staged for commit, as they are in the index (path arguments are ignored)")
+	flag.BoolVar(&opts.unstaged, "unstaged", false, "scan only changed or untracked files not staged yet (path arguments are ignored)")
+	flag.StringVar(&cfg.GitBase, "git-base", sniff.DefaultGitBase, "ref to diff against in -git-diff mode")
+	flag.StringVar(&cfg.StdinExt, "stdin-ext", "", "treat content read from - as this file type (e.g. .md)")
+	flag.BoolVar(&cfg.DetectMIME, "detect-mime", false, "sniff each file's content type for rules with a mime filter")
+	flag.BoolVar(&cfg.DetectLanguage, "detect-language", false, "detect each file's language for rules with a language filter")
+	flag.BoolVar(&cfg.ExcludeCodeBlocks, "exclude-code-blocks", false, "do not match inside fenced code blocks and <code> elements of Markdown files")
+	flag.BoolVar(&cfg.StripFrontMatter, "strip-front-matter", false, "do not match in the YAML or TOML front matter of Markdown files")
+	flag.BoolVar(&cfg.Snippets, "snippets", false, "show context around each match (with -vv, -vvv or -json)")
+	flag.IntVar(&cfg.SnippetWidth, "snippet-width", sniff.DefaultSnippetWidth, "characters of context per snippet")
+	flag.StringVar(&cfg.Output, "output", "", "write the report to this file (created or truncated) instead of stdout")
+	flag.BoolVar(&cfg.Quiet, "quiet", false, "no progress line on stderr")
+	flag.BoolVar(&cfg.Invert, "invert", false, "show only the files read, scored and not smelly; with -ci fail when any file is clean")
+	flag.BoolVar(&cfg.CountOnly, "count", false, "print only the number of smelly files (clean ones with -invert); -format json adds clean and total counts")
+	flag.BoolVar(&cfg.Print0, "print0", false, "print only the smelly paths, separated by NUL bytes, for xargs -0")
+	flag.IntVar(&cfg.Top, "top", 0, "only show the N highest-scoring smelly or warning files (exit status still covers all)")
+	flag.BoolVar(&cfg.TopAll, "top-all", false, "rank clean files for -top too")
+	flag.BoolVar(&cfg.Grades, "grades", false, "add a letter grade to each score, A to F in quarters of the threshold")
+	flag.BoolVar(&cfg.RuleStats, "rule-stats", false, "after the summary, rank rules by hits across all files (\"rule_stats\" in JSON)")
+	flag.Var(&optionalIntFlag{&cfg.Heatmap, sniff.DefaultHeatmapSections}, "heatmap", "split each file into N sections (-heatmap=N, bare = 10) and show where matches cluster")
+	gradeThresholds := flag.String("grade-thresholds", "", "lowest score per grade for -grades, e.g. A:0,B:10,C:20,D:30,F:40 (implies -grades)")
+	flag.BoolVar(&cfg.TimingMode, "timing", false, "time each file's analysis (shown with -vvv and -json, summarised in text output)")
+	flag.StringVar(&opts.serveAddr, "serve", "", "run an HTTP API on this address instead of scanning (\":8080\" binds to localhost)")
+	enableMetrics := flag.Bool("enable-metrics", false, "collect Prometheus metrics; printed to stderr after a scan unless pushed")
+	flag.StringVar(&opts.pushgateway, "metrics-pushgateway", "", "push the scan's metrics to this Pushgateway URL (implies -enable-metrics)")
+	profileRules := flag.Bool("profile-rules", false, "time each rule's pattern matching per file; mean, p50 and p95 printed to stderr after a scan")
+	flag.StringVar(&opts.profileOutput, "profile-output", "", "write the -profile-rules table to this file instead of stderr (implies -profile-rules)")
+	flag.StringVar(&opts.writeBaseline, "write-baseline", "", "save each file's score to this JSON baseline file")
+	flag.StringVar(&opts.compareBaseline, "compare-baseline", "", "show changes since this baseline; with -ci fail only on regressions")
+	flag.StringVar(&opts.traceIgnore, "trace-ignore", "", "print to stderr how the ignore files decide whether a scan of the paths (default .) ignores this path, then exit")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "list the files that would be scanned, one per line (a JSON array with -json), without scoring them")
+	flag.BoolVar(&opts.watch, "watch", false, "after the scan, rescan files as they change until interrupted")
+	flag.StringVar(&opts.summary, "summary", "stderr", "where to print the scan summary line: stderr, stdout or off")
+	flag.StringVar(&opts.logLevel, "log-level", "info", "stderr log level: debug, info, warn or error")
+	flag.StringVar(&opts.logFormat, "log-format", "text", "stderr log format: text or json")
+	flag.StringVar(&opts.ruleName, "rule", "", "rule to run in test-rule mode")
+	noConfig := flag.Bool("no-config", false, "skip .synthsniff.yaml/.json/.toml discovery and per-directory config files")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	// "completion <shell>" is a subcommand; its scripts list the flags above
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		runCompletion(os.Args[2:])
+	}
+	// "generate pre-commit" prints a hook definition, "install-hook" adds it
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		runGenerate(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "install-hook" {
+		runInstallHook(os.Args[2:])
+	}
+	// "dump-rules" takes the scan's flags, with -format json or yaml
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "dump-rules" {
+		opts.dumpRules, args = "yaml", args[1:]
+	}
+	// "test-rule -rule <name> files..." runs one rule over example files
+	if len(args) > 0 && args[0] == "test-rule" {
+		opts.testRule, args = true, args[1:]
+	}
+	// "explain <file>" walks through one file's score rule by rule
+	if len(args) > 0 && args[0] == "explain" {
+		opts.explain, args = true, args[1:]
+	}
+	_ = flag.CommandLine.Parse(args) // exits on error
+	// Before any other check, so it works without paths or a valid config
+	if *showVersion {
+		fmt.Println(sniff.Version())
+		os.Exit(0)
+	}
+	if opts.dumpRules != "" {
+		if set := setFlags(); set["format"] {
+			opts.dumpRules = cfg.Format
+		} else if *jsonOut {
+			opts.dumpRules = "json"
+		}
+		cfg.Format, *jsonOut = sniff.FormatText, false
+	}
 
-	if cfg.Threshold == -1 {
+	if *jsonOut {
+		cfg.Format = sniff.FormatJSON
+	}
+	if *noColor {
+		cfg.Color = sniff.ColorNever
+	}
+	if *enableMetrics || opts.pushgateway != "" {
+		cfg.Metrics = sniff.NewMetrics()
+	}
+	if *profileRules || opts.profileOutput != "" {
+		cfg.Profiler = sniff.NewRuleProfiler()
+	}
+	// Always collected: the text report lists the loaded ignore files
+	cfg.IgnoreStats = sniff.NewIgnoreStats()
+	if *depth >= 0 {
+		cfg.MaxDepth = depth
+	}
+	if *gradeThresholds != "" {
+		bounds, err := sniff.ParseGradeThresholds(*gradeThresholds)
+		if err != nil {
+			fatal(err)
+		}
+		cfg.GradeBoundaries, cfg.Grades = bounds, true
+	}
+	if opts.testRule && opts.ruleName == "" {
+		fatal("test-rule needs -rule <name>")
+	}
+	switch opts.summary {
+	case "stderr", "stdout", "off":
+	default:
+		fatalf("invalid -summary %q (want stderr, stdout or off)", opts.summary)
+	}
+	if cfg.Top < 0 {
+		fatalf("invalid -top %d", cfg.Top)
+	}
+	if opts.maxProcs < 0 {
+		fatalf("invalid -max-procs %d", opts.maxProcs)
+	}
+	if cfg.ConfidenceScale <= 0 {
+		fatalf("invalid -confidence-scale %v", cfg.ConfidenceScale)
+	}
+
+	var fileCfg sniff.Config
+	if !*noConfig {
+		var err error
+		if fileCfg, err = discoverConfig(); err != nil {
+			fatal(err)
+		}
+		applyConfigFile(&cfg, fileCfg, setFlags())
+		cfg.ConfigFile = fileCfg.ConfigFile
+	}
+	if err := checkExitCodes(cfg); err != nil {
+		fatal(err)
+	}
+	errorExit = cfg.ExitCodeError
+	// On GitHub Actions, annotate the pull request unless told otherwise
+	if set := setFlags(); !set["format"] && !set["json"] && fileCfg.Format == "" && opts.dumpRules == "" && !cfg.Print0 && !cfg.CountOnly && inGitHubActions(os.Getenv) {
+		cfg.Format = sniff.FormatGHA
+	}
+	cfg.NoDirConfigs = *noConfig
+	format, err := sniff.ParseFormat(cfg.Format)
+	if err != nil {
+		fatal(err)
+	}
+	cfg.Format = format
+	if cfg.Print0 && (cfg.Format != sniff.FormatText || cfg.Verbose || cfg.VeryVerbose || cfg.UltraVerbose) {
+		fatal("-print0 cannot be used with -json, -format or -v, -vv, -vvv")
+	}
+	if cfg.CountOnly && (cfg.Print0 || cfg.Verbose || cfg.VeryVerbose || cfg.UltraVerbose) {
+		fatal("-count cannot be used with -print0 or -v, -vv, -vvv")
+	}
+	if cfg.CountOnly && cfg.Format != sniff.FormatText && cfg.Format != sniff.FormatJSON {
+		fatalf("-count writes text or json, not %s", cfg.Format)
+	}
+	if cfg.JSONVersion, err = sniff.ParseJSONVersion(cfg.JSONVersion); err != nil {
+		fatal(err)
+	}
+	// Line numbers cost an extra pass, so only collect them when shown
+	cfg.CollectLines = cfg.CollectLines || cfg.VeryVerbose || cfg.UltraVerbose || cfg.Format == sniff.FormatJSON || cfg.Format == sniff.FormatJSONArray
+	color, err := sniff.ParseColor(cfg.Color)
+	if err != nil {
+		fatal(err)
+	}
+	cfg.Color = color
+	form, err := sniff.ParseNormForm(cfg.UnicodeNorm)
+	if err != nil {
+		fatal(err)
+	}
+	cfg.UnicodeNorm = form
+	severity, err := sniff.ParseSeverity(cfg.MinSeverity)
+	if err != nil {
+		fatal(err)
+	}
+	cfg.MinSeverity = severity
+
+	// Threshold priority: -t, then the environment, then the config file
+	cfg.Threshold = -1
+	if *threshold != "" {
+		th, err := sniff.ParseThreshold(*threshold, cfg.Normalize)
+		if err != nil {
+			fatal(err)
+		}
+		cfg.Threshold = th
+	}
+	if cfg.Threshold < 0 {
 		if v := os.Getenv(envThreshold); v != "" {
-			if th, err := sniff.ParseThreshold(v); err == nil {
+			if th, err := sniff.ParseThreshold(v, cfg.Normalize); err == nil {
 				cfg.Threshold = th
 			}
 		}
 	}
+	if cfg.Threshold < 0 && fileCfg.Threshold > 0 {
+		cfg.Threshold = fileCfg.Threshold
+	}
 	if cfg.Threshold < 0 {
-		cfg.Threshold = defaultThreshold
+		cfg.Threshold = sniff.DefaultThreshold
+	}
+	if *warnThreshold != "" {
+		th, err := sniff.ParseThreshold(*warnThreshold, cfg.Normalize)
+		if err != nil {
+			fatal(err)
+		}
+		cfg.WarnThreshold = th
+	} else {
+		cfg.WarnThreshold = fileCfg.WarnThreshold
+	}
+	if cfg.WarnThreshold > 0 && cfg.WarnThreshold >= cfg.Threshold {
+		fatalf("warn threshold %v must be below the error threshold %v", cfg.WarnThreshold, cfg.Threshold)
+	}
+	if opts.staged && opts.unstaged {
+		fatal("-staged and -unstaged cannot be used together")
+	}
+	if (opts.staged || opts.unstaged) && cfg.GitDiff {
+		fatal("-staged and -unstaged cannot be used with -git-diff")
+	}
+	if opts.watch && (cfg.CIMode || cfg.GitDiff || opts.staged || opts.unstaged) {
+		fatal("-watch cannot be used with -ci, -git-diff, -staged or -unstaged")
+	}
+	if opts.dryRun && (cfg.GitDiff || opts.staged || opts.unstaged || opts.watch) {
+		fatal("-dry-run cannot be used with -git-diff, -staged, -unstaged or -watch")
+	}
+	if opts.watch && slices.Contains(flag.Args(), sniff.StdinPath) {
+		fatal("-watch cannot read standard input")
 	}
 
-	return cfg, flag.Args()
+	return cfg, flag.Args(), opts
+}
+
+// runTestRule runs the named rule from the effective rule set over files
+// and prints MATCH or NO MATCH for each; an unknown rule exits 1.
+func runTestRule(cfg sniff.Config, name string, files []string) {
+	if len(files) == 0 {
+		fatal("test-rule needs at least one file")
+	}
+	rules, err := sniff.EffectiveRules(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	for _, r := range rules {
+		if r.Name != name {
+			continue
+		}
+		results, err := sniff.TestRule(r, files, cfg)
+		if err != nil {
+			fatal(err)
+		}
+		sniff.RenderRuleTest(os.Stdout, results)
+		return
+	}
+	fatalf("unknown rule %q", name)
+}
+
+// runDryRun writes the files a scan would score, one per line or as a
+// JSON array, and how many there are: after the list in text, on stderr
+// with JSON so the output stays parseable.
+func runDryRun(ctx context.Context, out io.Writer, paths []string, cfg sniff.Config) error {
+	files, err := sniff.ListFiles(ctx, paths, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Format == sniff.FormatJSON || cfg.Format == sniff.FormatJSONArray {
+		if files == nil {
+			files = []string{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Would scan %d files\n", len(files))
+		return nil
+	}
+	for _, f := range files {
+		fmt.Fprintln(out, f)
+	}
+	fmt.Fprintf(out, "Would scan %d files\n", len(files))
+	return nil
+}
+
+// runExplain prints how the one file in files was scored.
+func runExplain(cfg sniff.Config, files []string) {
+	if len(files) != 1 {
+		fatal("usage: sniff4ai explain [flags] <file>")
+	}
+	rules, err := sniff.EffectiveRules(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	e, err := sniff.Explain(files[0], rules, cfg)
+	if err != nil {
+		fatal(err)
+	}
+	sniff.RenderExplanation(os.Stdout, e, cfg)
+}
+
+// runTraceIgnore prints to stderr each ignore pattern a scan of roots
+// would check path against and which one decided.
+func runTraceIgnore(cfg sniff.Config, path string, roots []string) {
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	rules, err := sniff.LoadIgnoreRules(roots, path, cfg)
+	if err != nil {
+		fatal(err)
+	}
+	rules.TraceIgnore(path, os.Stderr)
+}
+
+// inGitHubActions reports whether the command runs in a GitHub Actions job.
+func inGitHubActions(getenv func(string) string) bool {
+	return getenv("CI") == "true" && getenv("GITHUB_ACTIONS") == "true"
+}
+
+// setMaxProcs applies -max-procs; 0 leaves the runtime default alone.
+func setMaxProcs(n int) {
+	if n > 0 {
+		runtime.GOMAXPROCS(n)
+	}
+}
+
+// reportProfile prints the rule timings of a -profile-rules scan on
+// stderr, or writes them to path.
+func reportProfile(p *sniff.RuleProfiler, path string) {
+	if p == nil {
+		return
+	}
+	if path == "" {
+		if err := sniff.RenderRuleProfile(os.Stderr, p.Stats()); err != nil {
+			slog.Error("rule profile write failed", "err", err)
+		}
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		slog.Error("rule profile write failed", "path", path, "err", err)
+		return
+	}
+	err = sniff.RenderRuleProfile(f, p.Stats())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		slog.Error("rule profile write failed", "path", path, "err", err)
+	}
+}
+
+// reportIgnoreStats prints how many paths each ignore pattern of an
+// -ignore-stats scan matched on stderr.
+func reportIgnoreStats(s *sniff.IgnoreStats) {
+	if err := sniff.RenderIgnoreStats(os.Stderr, s.Stats()); err != nil {
+		slog.Error("ignore stats write failed", "err", err)
+	}
+}
+
+// reportMetrics pushes a one-shot run's metrics to the Pushgateway, or
+// prints them on stderr when there is none.
+func reportMetrics(m *sniff.Metrics, pushgateway string) {
+	if m == nil {
+		return
+	}
+	if pushgateway == "" {
+		if err := m.WriteText(os.Stderr); err != nil {
+			slog.Error("metrics write failed", "err", err)
+		}
+		return
+	}
+	if err := m.Push(pushgateway); err != nil {
+		slog.Error("metrics push failed", "url", pushgateway, "err", err)
+	}
 }
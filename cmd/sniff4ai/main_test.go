@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the command itself when a test re-executes the test
+// binary with SNIFF4AI_RUN_MAIN set, taking the arguments after "--".
+func TestMain(m *testing.M) {
+	if os.Getenv("SNIFF4AI_RUN_MAIN") != "" {
+		for i, a := range os.Args {
+			if a == "--" {
+				os.Args = append([]string{os.Args[0]}, os.Args[i+1:]...)
+				break
+			}
+		}
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command with args in a child process and returns
+// what it wrote to stdout and stderr.
+func runMain(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "SNIFF4AI_RUN_MAIN=1")
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err = cmd.Run()
+	return out.String(), errOut.String(), err
+}
+
+func TestVersionFlag(t *testing.T) {
+	for _, arg := range []string{"-version", "--version"} {
+		out, _, err := runMain(t, arg)
+		require.NoError(t, err, out)
+		assert.Contains(t, out, "synthsniff version ")
+		assert.Contains(t, out, "(commit ")
+		assert.Contains(t, out, ", built ")
+		assert.NotContains(t, out, "at least one file", "exits before paths are checked")
+	}
+
+	_, _, err := runMain(t)
+	assert.Error(t, err, "without -version a path is still required")
+}
+
+func TestExitCodeFlags(t *testing.T) {
+	_, _, err := runMain(t, "-no-config", "-exit-error", "7")
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 7, exitErr.ExitCode(), "a missing path exits with -exit-error")
+
+	_, stderr, err := runMain(t, "-no-config", "-exit-smelly", "0", ".")
+	require.ErrorAs(t, err, &exitErr, stderr)
+	assert.Equal(t, sniff.DefaultExitCodeError, exitErr.ExitCode())
+	assert.Contains(t, stderr, "-exit-clean and -exit-smelly are both 0")
+
+	_, stderr, err = runMain(t, "-no-config", "-exit-warning", "2", ".")
+	require.ErrorAs(t, err, &exitErr, stderr)
+	assert.Contains(t, stderr, "-exit-warning and -exit-error are both 2")
+}
+
+func TestPrint0Flag(t *testing.T) {
+	dir := t.TempDir()
+	smelly := strings.Repeat("Let's delve into this — ", 20)
+	for name, content := range map[string]string{
+		"my notes.md": smelly,
+		"it's.md":     smelly,
+		"clean.md":    "plain text",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	out, stderr, err := runMain(t, "-no-config", "-print0", "-summary", "stdout", dir)
+	require.NoError(t, err, stderr)
+	paths := strings.Split(out, "\x00")
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "my notes.md"), filepath.Join(dir, "it's.md")}, paths)
+
+	for _, args := range [][]string{{"-json"}, {"-format", "csv"}, {"-v"}, {"-vv"}} {
+		_, stderr, err := runMain(t, append([]string{"-no-config", "-print0"}, append(args, dir)...)...)
+		assert.Error(t, err, args)
+		assert.Contains(t, stderr, "-print0 cannot be used with", args)
+	}
+}
+
+func TestTraceIgnoreFlag(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, sniff.SynthsniffIgnoreName), []byte("*.md\n!keep.md\n"), 0o644))
+	draft := filepath.Join(dir, "draft.md")
+	require.NoError(t, os.WriteFile(draft, []byte(strings.Repeat("Let's delve into this — ", 20)), 0o644))
+
+	out, stderr, err := runMain(t, "-no-config", "-trace-ignore", draft, dir)
+	require.NoError(t, err, stderr)
+	assert.Empty(t, out, "the trace goes to stderr")
+	assert.Contains(t, stderr, dir+": *.md: matched, ignored=true")
+	assert.Contains(t, stderr, draft+": ignored by "+dir+": *.md")
+	assert.NotContains(t, stderr, "Scanned", "exits without scanning")
+}
+
+func TestIgnoreStatsFlag(t *testing.T) {
+	dir := t.TempDir()
+	ignoreFile := filepath.Join(dir, sniff.SynthsniffIgnoreName)
+	require.NoError(t, os.WriteFile(ignoreFile, []byte("*.md\nbuild/\n"), 0o644))
+	for _, name := range []string{"a.md", "b.md", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("plain text"), 0o644))
+	}
+
+	out, stderr, err := runMain(t, "-no-config", "-ignore-stats", dir)
+	require.NoError(t, err, stderr)
+	assert.NotContains(t, out, "PATTERN", "the table goes to stderr")
+	assert.Regexp(t, `PATTERN +FILES +SOURCE\n\*\.md +2 +`+regexp.QuoteMeta(ignoreFile)+`\nbuild/ +0 +`, stderr)
+
+	_, stderr, err = runMain(t, "-no-config", dir)
+	require.NoError(t, err, stderr)
+	assert.NotContains(t, stderr, "PATTERN", "off by default")
+}
+
+func TestSetMaxProcs(t *testing.T) {
+	old := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() { runtime.GOMAXPROCS(old) })
+
+	setMaxProcs(0)
+	assert.Equal(t, old, runtime.GOMAXPROCS(0), "0 keeps the runtime default")
+
+	setMaxProcs(old + 3)
+	assert.Equal(t, old+3, runtime.GOMAXPROCS(0), "no cap at 4")
+}
+
+func TestInGitHubActions(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	assert.True(t, inGitHubActions(env(map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"})))
+	assert.False(t, inGitHubActions(env(map[string]string{"CI": "true"})), "other CI systems")
+	assert.False(t, inGitHubActions(env(map[string]string{"GITHUB_ACTIONS": "true"})))
+}
+
+func TestCountFlag(t *testing.T) {
+	dir := t.TempDir()
+	smelly := strings.Repeat("Let's delve into this — ", 20)
+	for name, content := range map[string]string{
+		"a.md":     smelly,
+		"b.md":     smelly,
+		"clean.md": "plain text",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	out, stderr, err := runMain(t, "-no-config", "-count", "-summary", "stdout", dir)
+	require.NoError(t, err, stderr)
+	n, err := strconv.Atoi(out)
+	require.NoError(t, err, "bare integer, got %q", out)
+	assert.Equal(t, 2, n)
+
+	out, _, err = runMain(t, "-no-config", "-count", "-invert", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "1", out)
+
+	out, _, err = runMain(t, "-no-config", "-count", "-ci", dir)
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, sniff.DefaultExitCodeSmelly, exitErr.ExitCode())
+	assert.Equal(t, "2", out)
+
+	_, stderr, err = runMain(t, "-no-config", "-count", "-vv", dir)
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "-count cannot be used with")
+}
+
+func TestJSONV2RuleCountLoadsRulesOnce(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("- {name: remote, pattern: ACME, weight: 1}\n"))
+	}))
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("ACME"), 0644))
+
+	out, _, err := runMain(t, "-no-config", "-json", "-json-version", "2", "-dict", srv.URL+"/rules.yaml", dir)
+	require.NoError(t, err)
+	var report struct {
+		Config struct {
+			Rules int `json:"rules"`
+		} `json:"config"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	base, err := sniff.EffectiveRules(sniff.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, len(base)+1, report.Config.Rules)
+	assert.Equal(t, int32(1), hits.Load(), "the count comes from the rules the scan loaded")
+}
+
+func TestDepthFlagMatchesConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "top.md"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "deep.md"), []byte("x"), 0644))
+
+	out, _, err := runMain(t, "-no-config", "-dry-run", "-depth", "0", dir)
+	require.NoError(t, err)
+	assert.Contains(t, out, "top.md")
+	assert.NotContains(t, out, "deep.md")
+
+	// maxDepth in a config file means the same as -depth
+	zero := 0
+	var cfg sniff.Config
+	applyConfigFile(&cfg, sniff.Config{MaxDepth: &zero}, map[string]bool{})
+	results, err := sniff.Scan(t.Context(), []string{dir}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, filepath.Join(dir, "top.md"), results[0].Path)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+)
+
+// preCommitConfig is the file install-hook edits, in the working directory.
+const preCommitConfig = ".pre-commit-config.yaml"
+
+// runGenerate handles "sniff4ai generate pre-commit" and exits.
+func runGenerate(args []string) {
+	if len(args) != 1 || args[0] != "pre-commit" {
+		fatal("usage: sniff4ai generate pre-commit")
+	}
+	if err := sniff.WritePreCommitHooks(os.Stdout, sniff.DefaultPreCommitHook); err != nil {
+		fatal(err)
+	}
+	os.Exit(0)
+}
+
+// runInstallHook handles "sniff4ai install-hook" and exits.
+func runInstallHook(args []string) {
+	if len(args) != 0 {
+		fatal("usage: sniff4ai install-hook")
+	}
+	added, err := sniff.InstallPreCommitHook(preCommitConfig, sniff.DefaultPreCommitHook)
+	if err != nil {
+		fatal(err)
+	}
+	if added {
+		fmt.Printf("Added the %s hook to %s.\n", sniff.DefaultPreCommitHook.ID, preCommitConfig)
+	} else {
+		fmt.Printf("%s already has the %s hook.\n", preCommitConfig, sniff.DefaultPreCommitHook.ID)
+	}
+	fmt.Println("Run `pre-commit install` to enable it, and `pre-commit run synthsniff --all-files` to check every file now.")
+	os.Exit(0)
+}
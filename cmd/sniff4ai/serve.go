@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+)
+
+// shutdownTimeout is how long in-flight requests get after Ctrl-C.
+const shutdownTimeout = 5 * time.Second
+
+// listenAddr binds to localhost when addr names only a port (":8080").
+// Other interfaces must be asked for explicitly, e.g. "0.0.0.0:8080".
+func listenAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+// serve runs the HTTP API until ctx is cancelled. SIGHUP reloads the
+// rule files.
+func serve(ctx context.Context, addr string, cfg sniff.Config) error {
+	h, err := sniff.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: listenAddr(addr), Handler: h, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("serving", "url", "http://"+srv.Addr)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for ctx.Err() == nil {
+		select {
+		case err := <-errc:
+			return err
+		case <-hup:
+			_ = h.Reload() // logged; the old rules stay on error
+		case <-ctx.Done():
+		}
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JoobyPM/synthsniff/internal/sniff"
+	"golang.org/x/term"
+)
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\x1b[2K"
+
+// runWatch scans paths, then renders each file that changes and keeps
+// the summary current until ctx is cancelled. On a terminal the summary
+// line on stderr is rewritten in place instead of repeated.
+func runWatch(ctx context.Context, out io.Writer, paths []string, cfg sniff.Config, summary string) error {
+	s, err := sniff.NewScanner(sniff.WithConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	inPlace := summary == "stderr" && term.IsTerminal(int(os.Stderr.Fd()))
+	err = s.Watch(ctx, paths, sniff.DefaultWatchDebounce, func(u sniff.WatchUpdate) {
+		if inPlace {
+			fmt.Fprint(os.Stderr, clearLine)
+		}
+		if len(u.Changed) > 0 {
+			sniff.Render(out, u.Changed, cfg)
+		}
+		if cfg.Format == sniff.FormatText {
+			for _, p := range u.Removed {
+				fmt.Fprintf(out, "removed %s\n", p)
+			}
+		}
+		if !inPlace {
+			printSummary(out, u.Results, cfg, summary)
+			return
+		}
+		var line bytes.Buffer
+		sniff.RenderSummary(&line, sniff.ComputeSummary(u.Results, 0), cfg)
+		fmt.Fprint(os.Stderr, bytes.TrimSuffix(line.Bytes(), []byte("\n")))
+	})
+	if inPlace {
+		fmt.Fprintln(os.Stderr)
+	}
+	return err
+}
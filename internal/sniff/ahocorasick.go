@@ -0,0 +1,272 @@
+package sniff
+
+import (
+	"strings"
+	"sync"
+)
+
+// acMatcher is an Aho-Corasick automaton over a fixed set of literal
+// patterns. It is immutable once built and safe for concurrent use.
+type acMatcher struct {
+	next  [][256]int32 // full DFA transition table
+	out   [][]int32    // pattern indices that end in each state
+	lens  []int        // byte length of each pattern
+	start [256]bool    // bytes that leave the root state
+	first string       // every distinct start byte, for fast skipping
+}
+
+// newACMatcher builds an automaton for patterns. Empty patterns never match.
+func newACMatcher(patterns []string) *acMatcher {
+	m := &acMatcher{
+		next: make([][256]int32, 1, 1+len(patterns)*4),
+		out:  make([][]int32, 1, 1+len(patterns)*4),
+		lens: make([]int, len(patterns)),
+	}
+
+	// Build the trie; -1 marks a missing edge until the BFS pass below
+	for i := range m.next[0] {
+		m.next[0][i] = -1
+	}
+	for i, p := range patterns {
+		m.lens[i] = len(p)
+		if p == "" {
+			continue
+		}
+		state := int32(0)
+		for j := 0; j < len(p); j++ {
+			c := p[j]
+			if m.next[state][c] == -1 {
+				var row [256]int32
+				for k := range row {
+					row[k] = -1
+				}
+				m.next = append(m.next, row)
+				m.out = append(m.out, nil)
+				m.next[state][c] = int32(len(m.next) - 1)
+			}
+			state = m.next[state][c]
+		}
+		m.out[state] = append(m.out[state], int32(i))
+	}
+
+	// Root edges: missing ones loop back to the root
+	fail := make([]int32, len(m.next))
+	queue := make([]int32, 0, len(m.next))
+	var first []byte
+	for c := 0; c < 256; c++ {
+		s := m.next[0][c]
+		if s == -1 {
+			m.next[0][c] = 0
+			continue
+		}
+		m.start[c] = true
+		first = append(first, byte(c))
+		queue = append(queue, s)
+	}
+	m.first = string(first)
+
+	// BFS turns the trie into a DFA and merges outputs along failure links
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		m.out[s] = append(m.out[s], m.out[fail[s]]...)
+		for c := 0; c < 256; c++ {
+			t := m.next[s][c]
+			if t == -1 {
+				m.next[s][c] = m.next[fail[s]][c]
+				continue
+			}
+			fail[t] = m.next[fail[s]][c]
+			queue = append(queue, t)
+		}
+	}
+
+	return m
+}
+
+// count adds the number of non-overlapping occurrences of each pattern in
+// content to counts, matching strings.Count semantics. lastEnd is scratch
+// space of the same length as counts.
+func (m *acMatcher) count(content string, counts, lastEnd []int) {
+	if len(m.next) == 1 {
+		return
+	}
+	for i := range lastEnd {
+		lastEnd[i] = 0
+	}
+
+	state := int32(0)
+	for i := 0; i < len(content); i++ {
+		// In the root state, jump straight to the next possible start byte
+		if state == 0 {
+			skip := m.skip(content[i:])
+			if skip < 0 {
+				return
+			}
+			i += skip
+		}
+
+		state = m.next[state][content[i]]
+		for _, p := range m.out[state] {
+			end := i + 1
+			if end-m.lens[p] < lastEnd[p] {
+				continue // overlaps the previous occurrence
+			}
+			lastEnd[p] = end
+			counts[p]++
+		}
+	}
+}
+
+// skip returns the offset of the first byte in s that can start a pattern,
+// or -1 when there is none.
+func (m *acMatcher) skip(s string) int {
+	if len(m.first) == 1 {
+		return strings.IndexByte(s, m.first[0])
+	}
+	for i := 0; i < len(s); i++ {
+		if m.start[s[i]] {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleMatcher maps a rule set onto shared automata, one pass per content
+// view: plain, case-folded, or normalized to a Unicode form (and folded).
+type ruleMatcher struct {
+	passes   []matchPass
+	pass     []int          // rule index -> pass index
+	index    []int          // rule index -> pattern index in its pass
+	prox     map[int][2]int // proximity rule index -> PatternA, PatternB indices
+	words    map[int][]int  // word-list rule index -> word indices
+	alts     map[int][]int  // rule index -> distinct indices of its Patterns
+	excludes map[int][]int  // rule index -> exclude pattern indices
+	prepared map[int]Rule   // rule index -> rule with normalized or folded patterns
+	hasMIME  bool           // some rule filters on MIME type
+	hasLang  bool           // some rule filters on language
+	form     string         // global normalization form it was built for
+	numPats  int            // patterns across all passes
+	scratch  sync.Pool      // *[]int count buffers, reused across files
+}
+
+// matchPass is one automaton and the view of the content it runs over.
+type matchPass struct {
+	view
+	ac      *acMatcher
+	numPats int
+}
+
+// view identifies a transformed copy of a file's content.
+type view struct {
+	form  string // Unicode normalization form, "" for none
+	fold  bool   // case-folded after normalization
+	strip bool   // Markdown code blocks blanked before folding
+	front bool   // everything but the front matter blanked
+}
+
+// patternSet deduplicates the patterns of one automaton.
+type patternSet struct {
+	seen     map[string]int
+	patterns []string
+}
+
+func (ps *patternSet) add(pattern string) int {
+	if p, ok := ps.seen[pattern]; ok {
+		return p
+	}
+	if ps.seen == nil {
+		ps.seen = make(map[string]int)
+	}
+	p := len(ps.patterns)
+	ps.seen[pattern] = p
+	ps.patterns = append(ps.patterns, pattern)
+	return p
+}
+
+// newRuleMatcher builds a matcher for rules, deduplicating equal patterns.
+// Proximity patterns and list words join the automaton, so one pass
+// counts them all. Rules without their own normalization form use form.
+func newRuleMatcher(rules []Rule, form string) *ruleMatcher {
+	var sets []patternSet
+	passOf := make(map[view]int)
+	rm := &ruleMatcher{pass: make([]int, len(rules)), index: make([]int, len(rules)), form: form}
+	for i, r := range rules {
+		v := view{r.normForm(form), r.CaseInsensitive, r.ExcludeCodeBlocks, r.FrontMatterOnly}
+		p, ok := passOf[v]
+		if !ok {
+			p = len(rm.passes)
+			passOf[v] = p
+			rm.passes = append(rm.passes, matchPass{view: v})
+			sets = append(sets, patternSet{})
+		}
+		rm.pass[i] = p
+		rm.hasMIME = rm.hasMIME || r.MIME != ""
+		rm.hasLang = rm.hasLang || r.Language != ""
+		if v.form != "" || v.fold {
+			r = r.matchForm(v.form)
+			if rm.prepared == nil {
+				rm.prepared = make(map[int]Rule)
+			}
+			rm.prepared[i] = r
+		}
+
+		set := &sets[p]
+		rm.index[i] = set.add(r.Pattern)
+		if r.Proximity != nil {
+			if rm.prox == nil {
+				rm.prox = make(map[int][2]int)
+			}
+			rm.prox[i] = [2]int{set.add(r.Proximity.PatternA), set.add(r.Proximity.PatternB)}
+		}
+		if r.WordList != "" {
+			if rm.words == nil {
+				rm.words = make(map[int][]int)
+			}
+			for _, w := range r.words {
+				rm.words[i] = append(rm.words[i], set.add(w))
+			}
+		}
+		if len(r.Patterns) > 0 && r.WordList == "" {
+			if rm.alts == nil {
+				rm.alts = make(map[int][]int)
+			}
+			seen := make(map[int]bool, len(r.Patterns))
+			for _, alt := range r.Patterns {
+				// Equal alternatives share a slot and must count once
+				if p := set.add(alt); !seen[p] {
+					seen[p] = true
+					rm.alts[i] = append(rm.alts[i], p)
+				}
+			}
+		}
+		for _, x := range r.excludePatterns() {
+			if rm.excludes == nil {
+				rm.excludes = make(map[int][]int)
+			}
+			rm.excludes[i] = append(rm.excludes[i], set.add(x))
+		}
+	}
+
+	for p := range rm.passes {
+		rm.passes[p].ac = newACMatcher(sets[p].patterns)
+		rm.passes[p].numPats = len(sets[p].patterns)
+		rm.numPats += len(sets[p].patterns)
+	}
+	return rm
+}
+
+// getScratch returns a zeroed buffer with room for every pass's counts
+// and the automaton's scratch space. Hand it back with putScratch.
+func (rm *ruleMatcher) getScratch() *[]int {
+	if b, ok := rm.scratch.Get().(*[]int); ok {
+		clear(*b)
+		return b
+	}
+	b := make([]int, 2*rm.numPats)
+	return &b
+}
+
+func (rm *ruleMatcher) putScratch(b *[]int) {
+	rm.scratch.Put(b)
+}
@@ -0,0 +1,83 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestACMatcherMatchesStringsCount verifies the automaton reproduces
+// strings.Count for every pattern, including overlapping and shared prefixes.
+func TestACMatcherMatchesStringsCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		patterns []string
+	}{
+		{
+			name:     "no patterns match",
+			content:  "plain ascii text",
+			patterns: []string{"xyz", "—"},
+		},
+		{
+			name:     "overlapping occurrences",
+			content:  "aaaaa",
+			patterns: []string{"aa", "a", "aaa"},
+		},
+		{
+			name:     "markdown rule overlaps itself",
+			content:  "title\n---\n---\nbody\n---\n",
+			patterns: []string{"\n---\n", "---"},
+		},
+		{
+			name:     "multibyte runes",
+			content:  "“quoted” – and — with nbsp",
+			patterns: []string{"–", "—", "“", "”", " "},
+		},
+		{
+			name:     "shared prefixes and suffixes",
+			content:  "she sells seashells by the seashore",
+			patterns: []string{"he", "she", "hers", "sea", "shells", "s"},
+		},
+		{
+			name:     "empty pattern never matches",
+			content:  "anything",
+			patterns: []string{"", "any"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newACMatcher(tt.patterns)
+			counts := make([]int, len(tt.patterns))
+			m.count(tt.content, counts, make([]int, len(tt.patterns)))
+
+			for i, p := range tt.patterns {
+				want := 0
+				if p != "" {
+					want = strings.Count(tt.content, p)
+				}
+				assert.Equal(t, want, counts[i], "pattern %q", p)
+			}
+		})
+	}
+}
+
+// TestRuleMatcherSharedPattern verifies rules with equal patterns share a slot.
+func TestRuleMatcherSharedPattern(t *testing.T) {
+	rules := []Rule{
+		{Name: "a", Pattern: "x"},
+		{Name: "b", Pattern: "y"},
+		{Name: "c", Pattern: "x"},
+	}
+
+	rm := newRuleMatcher(rules, "")
+	require.Len(t, rm.passes, 1)
+	assert.Equal(t, 2, rm.passes[0].numPats)
+	assert.Equal(t, rm.index[0], rm.index[2])
+	compiled := CompileRules(rules, "")
+	assert.Same(t, compiled[0].matcher, compiled[2].matcher, "rules compiled together share one matcher")
+}
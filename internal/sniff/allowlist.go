@@ -0,0 +1,55 @@
+package sniff
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allowlist holds path globs of files that are accepted as AI-generated:
+// they are still scored, but never count as smelly or as warnings.
+type allowlist []string
+
+// loadAllowlist reads one glob per line from path; blank lines and lines
+// starting with # are skipped. An empty path yields an empty list.
+func loadAllowlist(path string) (allowlist, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("allowlist: %w", err)
+	}
+	defer f.Close()
+
+	var globs allowlist
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		globs = append(globs, filepath.FromSlash(line))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("allowlist %s: %w", path, err)
+	}
+	if err := checkGlobs(globs); err != nil {
+		return nil, fmt.Errorf("allowlist %s: %w", path, err)
+	}
+	return globs, nil
+}
+
+// apply marks r as allowlisted when name, the file it was read from,
+// matches a glob, clearing its smelly and warning flags. Its score is
+// kept.
+func (a allowlist) apply(r Result, name string) Result {
+	if len(a) == 0 || !matchesAny(filepath.Clean(name), a) {
+		return r
+	}
+	r.Allowlisted = true
+	r.Smelly, r.Warning = false, false
+	return r
+}
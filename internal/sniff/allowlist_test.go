@@ -0,0 +1,76 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllowlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow")
+	require.NoError(t, os.WriteFile(path, []byte("# generated\n\ndocs/api/*.md\n  CHANGELOG.md  \n"), 0644))
+
+	globs, err := loadAllowlist(path)
+	require.NoError(t, err)
+	assert.Equal(t, allowlist{filepath.FromSlash("docs/api/*.md"), "CHANGELOG.md"}, globs)
+
+	globs, err = loadAllowlist("")
+	require.NoError(t, err)
+	assert.Empty(t, globs)
+
+	require.NoError(t, os.WriteFile(path, []byte("[\n"), 0644))
+	_, err = loadAllowlist(path)
+	assert.ErrorContains(t, err, "invalid pattern")
+
+	_, err = loadAllowlist(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestScanAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	gen := filepath.Join(dir, "generated.md")
+	own := filepath.Join(dir, "own.md")
+	require.NoError(t, os.WriteFile(gen, []byte("MARK MARK MARK"), 0644))
+	require.NoError(t, os.WriteFile(own, []byte("MARK MARK MARK"), 0644))
+	allow := filepath.Join(t.TempDir(), "allow")
+	require.NoError(t, os.WriteFile(allow, []byte("generated.*\n"), 0644))
+	cfg := Config{
+		Threshold:     20,
+		WarnThreshold: 10,
+		Allowlist:     allow,
+		ExtraRules:    []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	results, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, gen, results[0].Path)
+	assert.True(t, results[0].Allowlisted)
+	assert.False(t, results[0].Smelly, "an allowlisted file is never smelly")
+	assert.Equal(t, 30.0, results[0].Score, "but keeps its score")
+	assert.True(t, results[1].Smelly, "files off the list are flagged as usual")
+	assert.False(t, results[1].Allowlisted)
+
+	var out bytes.Buffer
+	cfg.Format = FormatJSON
+	Render(&out, results[:1], cfg)
+	var decoded struct{ Results []map[string]any }
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, false, decoded.Results[0]["smelly"])
+	assert.Equal(t, true, decoded.Results[0]["allowlisted"])
+	assert.Equal(t, RenderResult{}, Render(&out, results[:1], cfg), "no exit status")
+
+	// A warning-band file on the list is cleared as well
+	require.NoError(t, os.WriteFile(gen, []byte("MARK"), 0644))
+	results, err = Scan(context.Background(), []string{gen}, cfg)
+	require.NoError(t, err)
+	assert.False(t, results[0].Warning)
+	assert.True(t, results[0].Allowlisted)
+}
@@ -0,0 +1,429 @@
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// analyse reads path and scores its content. With cfg.TimingMode the
+// result carries how long both took. It takes compiled rules rather than
+// a RuleSet so they are compiled once per scan, not once per file; a
+// RuleSet only yields them through Compile, which drops disabled rules.
+func analyse(path string, rules []CompiledRule, cfg Config) (r Result) {
+	if cfg.TimingMode {
+		start := time.Now()
+		defer func() { r.Duration = time.Since(start) }()
+	}
+	// Small files are skipped unread; oversize files are streamed in
+	// chunks rather than read whole
+	if cfg.MinSize > 0 || (cfg.ChunkedScan && cfg.MaxSize > 0) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			switch {
+			case cfg.tooSmall(info.Size()):
+				return skippedResult(path, SkipTooSmall)
+			case cfg.ChunkedScan && cfg.MaxSize > 0 && info.Size() > cfg.MaxSize:
+				return analyseFileChunks(path, info.Size(), rules, cfg)
+			}
+		}
+	}
+	// Use memory mapping to read file content instead of ReadFile
+	// This reduces syscall overhead by avoiding extra copies
+	mmapGate <- struct{}{} // acquire
+	data, isMapped, err := mmapFile(path, cfg.mmapThreshold())
+	<-mmapGate // release ASAP
+	if errors.Is(err, errNotRegular) {
+		return Result{Path: path}
+	}
+	if err != nil {
+		return cfg.failedResult(path, err)
+	}
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		slog.Debug("read file", "path", path, "bytes", len(data), "mmap", isMapped)
+	}
+
+	// Only unmap memory-mapped files
+	if isMapped {
+		defer func() {
+			mmapGate <- struct{}{} // acquire
+			if err := unmapFile(data); err != nil {
+				slog.Warn("failed to unmap file", "path", path, "err", err)
+			}
+			<-mmapGate // release ASAP
+		}()
+	}
+
+	return AnalyseCompiled(data, path, rules, cfg)
+}
+
+// analyseFileChunks scores an oversize file of size bytes with
+// analyseChunks.
+func analyseFileChunks(path string, size int64, rules []CompiledRule, cfg Config) Result {
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg.failedResult(path, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.Warn("failed to close file", "path", path, "err", err)
+		}
+	}()
+	r, err := analyseChunks(f, path, size, rules, cfg)
+	if err != nil {
+		slog.Warn("chunked read failed", "path", path, "err", err)
+		return cfg.failedResult(path, err)
+	}
+	return r
+}
+
+// AnalyseBytes scores in-memory content as though it were read from a file
+// called name. The name's extension drives per-rule extension filters.
+// Binary content and content over cfg.MaxSize yield an empty Result, and
+// content under cfg.MinSize a skipped one; cfg.ForceBinary and
+// cfg.ForcedExts let content with NUL bytes through. It compiles rules
+// on every call; use AnalyseCompiled or a Scanner for many files.
+func AnalyseBytes(data []byte, name string, rules []Rule, cfg Config) Result {
+	return AnalyseCompiled(data, name, CompileRules(rules, cfg.UnicodeNorm), cfg)
+}
+
+// AnalyseCompiled is AnalyseBytes for rules compiled once up front with
+// CompileRules, as when scoring many files with the same rule set.
+func AnalyseCompiled(data []byte, name string, rules []CompiledRule, cfg Config) Result {
+	// Skip binary files unless forced
+	if !cfg.forcesBinary(name) && bytes.IndexByte(data, 0) != -1 {
+		return Result{Path: name}
+	}
+
+	// Check size limits after reading
+	if cfg.MaxSize > 0 && int64(len(data)) > cfg.MaxSize {
+		return Result{Path: name}
+	}
+	if cfg.tooSmall(int64(len(data))) {
+		return skippedResult(name, SkipTooSmall)
+	}
+
+	// Convert to string once to avoid repeated conversions for each rule
+	return scoreContent(string(data), name, rules, cfg)
+}
+
+// AnalyseString is AnalyseBytes for content that is already a string,
+// and likewise compiles rules on every call.
+func AnalyseString(s, name string, rules []Rule, cfg Config) Result {
+	return analyseString(s, name, CompileRules(rules, cfg.UnicodeNorm), cfg)
+}
+
+// analyseString is AnalyseString for compiled rules.
+func analyseString(s, name string, rules []CompiledRule, cfg Config) Result {
+	if !cfg.forcesBinary(name) && strings.IndexByte(s, 0) != -1 {
+		return Result{Path: name}
+	}
+	if cfg.MaxSize > 0 && int64(len(s)) > cfg.MaxSize {
+		return Result{Path: name}
+	}
+	if cfg.tooSmall(int64(len(s))) {
+		return skippedResult(name, SkipTooSmall)
+	}
+	return scoreContent(s, name, rules, cfg)
+}
+
+// SkipTooSmall is Result.SkipReason for files under Config.MinSize.
+const SkipTooSmall = "too small"
+
+// tooSmall reports whether n bytes are under MinSize.
+func (c Config) tooSmall(n int64) bool {
+	return c.MinSize > 0 && n < c.MinSize
+}
+
+// skippedResult is the result for a file left unscored, and why.
+func skippedResult(path, reason string) Result {
+	return Result{Path: path, Skipped: true, SkipReason: reason}
+}
+
+// failedResult is the result for a file that could not be read, passed
+// to Config.OnError as well.
+func (c Config) failedResult(path string, err error) Result {
+	c.fileError(path, err)
+	return Result{Path: path, Err: err.Error()}
+}
+
+// scoreContent runs every applicable rule over content.
+func scoreContent(content, name string, rules []CompiledRule, cfg Config) Result {
+	fileExt := filepath.Ext(name)
+	score := 0
+	detail := make(map[string]RuleHit)
+	fileLen := len(content)
+
+	// Count every pattern with one Aho-Corasick pass per content view.
+	// Normalized views are built once per file and shared by every pass
+	// in that form; folding keeps byte offsets, so a folded view lines up
+	// with the normalized text it came from.
+	rm := matcherOf(rules, cfg.UnicodeNorm)
+	buf := rm.getScratch()
+	defer rm.putScratch(buf)
+	normalized := map[string]string{"": content}
+	views := make(map[view]string) // preprocessed, per form
+	texts := make([]string, len(rm.passes))
+	counts := make([][]int, len(rm.passes))
+	free := *buf
+	for p, ps := range rm.passes {
+		base, ok := normalized[ps.form]
+		if !ok {
+			base = normalizeText(content, ps.form)
+			normalized[ps.form] = base
+		}
+		if ps.strip || ps.front || cfg.ExcludeCodeBlocks || cfg.StripFrontMatter {
+			// Blanking keeps offsets, so lines and snippets still come
+			// from the unblanked text
+			v := view{form: ps.form, strip: ps.strip, front: ps.front}
+			s, ok := views[v]
+			if !ok {
+				s = preprocess(base, fileExt, v, cfg)
+				views[v] = s
+			}
+			base = s
+		}
+		texts[p] = base
+		if ps.fold {
+			texts[p] = foldCase(base)
+		}
+		counts[p] = free[:ps.numPats]
+		ps.ac.count(texts[p], counts[p], free[ps.numPats:2*ps.numPats])
+		free = free[2*ps.numPats:]
+	}
+
+	// The content type and language are detected once per file, and only
+	// when a rule filters on them
+	var mime, lang string
+	if cfg.DetectMIME && rm.hasMIME {
+		mime = detectMIME(content)
+	}
+	if cfg.DetectLanguage && rm.hasLang {
+		lang = detectLanguage(name, content)
+	}
+
+	// Newline offsets are indexed once per form, on the first hit that
+	// needs them
+	lines := make(map[string]lineIndex)
+	wantLines := cfg.wantsLines()
+	var sections map[string][]int // heatmap section of each tracked match
+
+	// Check each rule against the file content
+	for i := range rules {
+		r := rules[i].Rule
+		// Skip rules that don't apply to this file extension, language
+		// or size
+		if !r.appliesTo(fileExt, mime) || !r.appliesToLanguage(lang) || !r.appliesToSize(int64(fileLen)) {
+			continue
+		}
+
+		// mr and text are what the patterns run against; shown is that
+		// text before folding, which lines and snippets are taken from
+		p := rm.pass[i]
+		mr, text, cnt := r, texts[p], counts[p]
+		if pr, ok := rm.prepared[i]; ok {
+			mr = pr
+		}
+		form := rm.passes[p].form
+		shown := normalized[form]
+
+		count := cnt[rm.index[i]]
+		units := -1      // what MaxMatches caps and Weight multiplies, if not count
+		var spans []span // matches, found lazily for lines and snippets
+		if cfg.Profiler != nil {
+			// The automaton matches every rule at once; the profile times
+			// each rule's patterns on their own
+			start := time.Now()
+			if mr.Proximity != nil {
+				mr.Proximity.find(text)
+			} else {
+				spans = mr.matchSpans(text)
+			}
+			cfg.Profiler.add(r.Name, time.Since(start))
+		}
+		switch {
+		case mr.Proximity != nil:
+			count = 0
+			ab := rm.prox[i]
+			if cnt[ab[0]] > 0 && cnt[ab[1]] > 0 {
+				if off, ok := mr.Proximity.find(text); ok {
+					count, spans = 1, []span{{off, len(mr.Proximity.PatternA)}}
+				}
+			}
+		case mr.WordList != "":
+			count = 0
+			for _, p := range rm.words[i] {
+				count += cnt[p]
+			}
+		case len(mr.Patterns) > 0:
+			// Alternatives score once per distinct pattern found
+			count, units = 0, 0
+			for _, p := range rm.alts[i] {
+				if cnt[p] > 0 {
+					count += cnt[p]
+					units++
+				}
+			}
+		}
+
+		// Skip patterns that don't match or don't pass thresholds
+		if count == 0 || !r.passesThresholds(count, fileLen) {
+			continue
+		}
+
+		// An exclude pattern anywhere in the file zeroes the rule's score,
+		// but the hit is still reported
+		excluded := false
+		for _, p := range rm.excludes[i] {
+			if cnt[p] > 0 {
+				excluded = true
+				break
+			}
+		}
+
+		// Calculate score and record hit
+		if units < 0 {
+			units = count
+		}
+		scored := r.scoredCount(units)
+		if excluded {
+			scored = 0
+		}
+		score += scored * r.Weight
+		hit := RuleHit{
+			Rule:     r,
+			Count:    count,
+			Scored:   scored,
+			Excluded: excluded,
+		}
+		track := cfg.tracksPositions(r) && !excluded
+		if wantLines || cfg.Snippets || track {
+			if spans == nil {
+				spans = mr.matchSpans(text)
+			}
+			if track {
+				if sections == nil {
+					sections = make(map[string][]int)
+				}
+				sections[r.Name] = sectionsOf(spans, len(shown), cfg.heatmapSections())
+			}
+			if wantLines {
+				idx, ok := lines[form]
+				if !ok {
+					idx = newLineIndex(shown)
+					lines[form] = idx
+				}
+				hit.Lines = idx.linesAt(spans)
+			}
+			if cfg.Snippets {
+				hit.Snippets = snippetsAt(shown, spans, cfg.snippetWidth())
+			}
+		}
+		detail[r.Name] = hit
+	}
+
+	// Grouped rules only count once their group reaches its minimum
+	score = gateGroups(detail, score)
+	heat := heatmapOf(sections, detail, cfg.heatmapSections())
+
+	// Return the analysis result
+	final := float64(score)
+	if cfg.Normalize {
+		final = normalizeScore(score, fileLen)
+	}
+	smelly, warning := cfg.classify(final)
+	return Result{
+		Path:       name,
+		Score:      final,
+		RawScore:   score,
+		Confidence: cfg.confidence(final),
+		Detail:     detail,
+		Smelly:     smelly,
+		Warning:    warning,
+		Grade:      cfg.grade(final),
+		Heatmap:    heat,
+	}
+}
+
+// normalizeScore turns a raw score into score per kilobyte of content.
+func normalizeScore(raw, size int) float64 {
+	if size == 0 {
+		return 0
+	}
+	return float64(raw) * 1000 / float64(size)
+}
+
+// lineIndex holds the byte offset of every newline in a file.
+type lineIndex []int
+
+// newLineIndex scans content once for newlines.
+func newLineIndex(content string) lineIndex {
+	idx := make(lineIndex, 0, strings.Count(content, "\n"))
+	for off := 0; ; {
+		i := strings.IndexByte(content[off:], '\n')
+		if i < 0 {
+			return idx
+		}
+		idx = append(idx, off+i)
+		off += i + 1
+	}
+}
+
+// lineAt returns the 1-based line number of byte offset off.
+func (idx lineIndex) lineAt(off int) int {
+	return 1 + sort.SearchInts(idx, off)
+}
+
+// linesAt maps match offsets to line numbers.
+func (idx lineIndex) linesAt(spans []span) []int {
+	out := make([]int, len(spans))
+	for i, sp := range spans {
+		out[i] = idx.lineAt(sp.off)
+	}
+	return out
+}
+
+// span is one match: its byte offset and length.
+type span struct {
+	off, len int
+}
+
+// matchSpans returns every match of the rule's patterns, ordered by offset.
+func (r Rule) matchSpans(content string) []span {
+	patterns := r.patterns()
+	var spans []span
+	seen := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		if seen[p] {
+			continue // a repeated alternative matches the same places
+		}
+		seen[p] = true
+		for _, off := range matchOffsets(content, p) {
+			spans = append(spans, span{off, len(p)})
+		}
+	}
+	if len(patterns) > 1 {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].off < spans[j].off })
+	}
+	return spans
+}
+
+// matchOffsets returns the start of every non-overlapping occurrence of
+// pattern, one entry per match as counted by the automaton.
+func matchOffsets(content, pattern string) []int {
+	var out []int
+	for off := 0; pattern != ""; {
+		i := strings.Index(content[off:], pattern)
+		if i < 0 {
+			break
+		}
+		out = append(out, off+i)
+		off += i + len(pattern)
+	}
+	return out
+}
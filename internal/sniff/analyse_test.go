@@ -2,8 +2,11 @@
 package sniff
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,21 +34,21 @@ func TestSimpleAnalyse(t *testing.T) {
 
 	// Test with a threshold lower than the rule weight (should be smelly)
 	lowThresholdCfg := Config{Threshold: 30}
-	result := analyse(testFile, rules, lowThresholdCfg)
+	result := analyse(testFile, CompileRules(rules, ""), lowThresholdCfg)
 
 	// Verify the file is detected as smelly
 	assert.True(t, result.Smelly, "File should be detected as smelly with low threshold")
-	assert.Equal(t, 50, result.Score, "Score should match the rule weight")
+	assert.Equal(t, 50.0, result.Score, "Score should match the rule weight")
 	assert.Equal(t, 1, len(result.Detail), "Should have one rule match")
 	assert.Contains(t, result.Detail, "test-rule", "Should contain our test rule")
 
 	// Test with a threshold higher than the rule weight (should not be smelly)
 	highThresholdCfg := Config{Threshold: 60}
-	result = analyse(testFile, rules, highThresholdCfg)
+	result = analyse(testFile, CompileRules(rules, ""), highThresholdCfg)
 
 	// Verify the file is not detected as smelly due to high threshold
 	assert.False(t, result.Smelly, "File should not be detected as smelly with high threshold")
-	assert.Equal(t, 50, result.Score, "Score should still match the rule weight")
+	assert.Equal(t, 50.0, result.Score, "Score should still match the rule weight")
 	assert.Equal(t, 1, len(result.Detail), "Should still have one rule match")
 }
 
@@ -72,9 +75,9 @@ func TestSimpleScan(t *testing.T) {
 	require.NoError(t, os.WriteFile(dictFile, []byte(dictContent), 0644))
 
 	// Run a scan with our test dictionary
-	results, err := Scan([]string{tempDir}, Config{
+	results, err := Scan(context.Background(), []string{tempDir}, Config{
 		Threshold: 30,
-		DictPath:  dictFile,
+		DictPaths: []string{dictFile},
 		Workers:   1,
 	})
 
@@ -112,10 +115,231 @@ func TestCustomRuleAnalyse(t *testing.T) {
 	}
 
 	// Test with the custom rule
-	result := analyse(testFile, rules, Config{Threshold: 30})
+	result := analyse(testFile, CompileRules(rules, ""), Config{Threshold: 30})
 
 	// Verify custom rule detection
 	assert.True(t, result.Smelly, "File should be detected as smelly with custom rule")
-	assert.Equal(t, 50, result.Score, "Score should match the custom rule weight")
+	assert.Equal(t, 50.0, result.Score, "Score should match the custom rule weight")
 	assert.Contains(t, result.Detail, "custom-rule", "Should detect the custom rule")
 }
+
+// TestAnalyseBytesMatchesAnalyse verifies the in-memory entry points score
+// content exactly like analyse does for the same file.
+func TestAnalyseBytesMatchesAnalyse(t *testing.T) {
+	tempDir := t.TempDir()
+	rules := setupTestPatterns(t)
+
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		cfg     Config
+	}{
+		{
+			name:    "markdown with several rules",
+			file:    "smelly.md",
+			content: []byte("SMARTQUOTE and EMDASH\n---\nCUSTOM_PATTERN"),
+			cfg:     Config{Threshold: 30},
+		},
+		{
+			name:    "extension filter applies by name",
+			file:    "smelly.txt",
+			content: []byte("SMARTQUOTE and EMDASH\n---\n"),
+			cfg:     Config{Threshold: 30},
+		},
+		{
+			name:    "binary content",
+			file:    "binary.bin",
+			content: []byte{'E', 'M', 'D', 'A', 'S', 'H', 0x00},
+			cfg:     Config{Threshold: 1},
+		},
+		{
+			name:    "over max size",
+			file:    "big.txt",
+			content: []byte("EMDASH EMDASH EMDASH"),
+			cfg:     Config{Threshold: 1, MaxSize: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.file)
+			require.NoError(t, os.WriteFile(path, tt.content, 0644))
+
+			want := analyse(path, CompileRules(rules, ""), tt.cfg)
+			assert.Equal(t, want, AnalyseBytes(tt.content, path, rules, tt.cfg))
+			assert.Equal(t, want, AnalyseString(string(tt.content), path, rules, tt.cfg))
+		})
+	}
+}
+
+// TestCollectLines verifies each match is reported with its 1-based line.
+func TestCollectLines(t *testing.T) {
+	rules := []Rule{
+		{Name: "mark", Pattern: "MARK", Weight: 1},
+		{Name: "pair", Pattern: "aa", Weight: 1},
+	}
+	content := "MARK\nplain\nMARK and MARK\naaa\n\nMARK"
+
+	r := AnalyseString(content, "f.txt", rules, Config{Threshold: 1, CollectLines: true})
+	assert.Equal(t, []int{1, 3, 3, 6}, r.Detail["mark"].Lines)
+	assert.Equal(t, r.Detail["mark"].Count, len(r.Detail["mark"].Lines))
+	// Non-overlapping, like the count: "aaa" holds one "aa"
+	assert.Equal(t, []int{4}, r.Detail["pair"].Lines)
+
+	r = AnalyseString(content, "f.txt", rules, Config{Threshold: 1})
+	assert.Nil(t, r.Detail["mark"].Lines)
+}
+
+func TestLineIndex(t *testing.T) {
+	idx := newLineIndex("a\nbb\n\nc")
+	assert.Equal(t, lineIndex{1, 4, 5}, idx)
+	for off, want := range map[int]int{0: 1, 1: 1, 2: 2, 4: 2, 5: 3, 6: 4} {
+		assert.Equal(t, want, idx.lineAt(off), "offset %d", off)
+	}
+}
+
+// TestMaxMatches verifies only the first MaxMatches occurrences score.
+func TestMaxMatches(t *testing.T) {
+	rules := []Rule{{Name: "dash", Pattern: "—", Weight: 3, MaxMatches: 2}}
+	tests := []struct {
+		name       string
+		content    string
+		wantCount  int
+		wantScored int
+		wantScore  float64
+	}{
+		{"below cap", "—", 1, 1, 3},
+		{"at cap", "— —", 2, 2, 6},
+		{"above cap", "— — — — —", 5, 2, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := AnalyseString(tt.content, "a.txt", rules, Config{Threshold: 100})
+			assert.Equal(t, tt.wantScore, r.Score)
+			assert.Equal(t, tt.wantCount, r.Detail["dash"].Count)
+			assert.Equal(t, tt.wantScored, r.Detail["dash"].Scored)
+		})
+	}
+
+	// Without a cap every occurrence scores
+	r := AnalyseString("— — — — —", "a.txt", []Rule{{Name: "dash", Pattern: "—", Weight: 3}}, Config{Threshold: 100})
+	assert.Equal(t, 15.0, r.Score)
+	assert.Equal(t, 5, r.Detail["dash"].Scored)
+
+	var buf bytes.Buffer
+	Render(&buf, []Result{AnalyseString("— — — — —", "a.txt", rules, Config{Threshold: 1})}, Config{VeryVerbose: true})
+	assert.Contains(t, buf.String(), "dash × 5 (scored 2)")
+}
+
+// TestNormalizeScore verifies scores become per-KB densities.
+func TestNormalizeScore(t *testing.T) {
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}
+	short := "MARK" + strings.Repeat(".", 46)   // 50 bytes
+	long := "MARK" + strings.Repeat(".", 49996) // 50,000 bytes
+
+	cfg := Config{Threshold: 100, Normalize: true}
+	r := AnalyseString(short, "a.txt", rules, cfg)
+	assert.Equal(t, 10, r.RawScore)
+	assert.Equal(t, 200.0, r.Score)
+	assert.True(t, r.Smelly)
+
+	r = AnalyseString(long, "a.txt", rules, cfg)
+	assert.Equal(t, 10, r.RawScore)
+	assert.Equal(t, 0.2, r.Score)
+	assert.False(t, r.Smelly)
+
+	// Without normalization both files score the same
+	r = AnalyseString(long, "a.txt", rules, Config{Threshold: 10})
+	assert.Equal(t, 10.0, r.Score)
+	assert.Equal(t, 10, r.RawScore)
+	assert.True(t, r.Smelly)
+
+	assert.Zero(t, normalizeScore(0, 0))
+}
+
+func TestAnalyseExclude(t *testing.T) {
+	rules := []Rule{
+		{Name: "as-an-ai", Pattern: "As an AI", Weight: 10, Exclude: "<!-- human-written -->"},
+		{Name: "delve", Pattern: "delve", Weight: 1, Excludes: []string{"_test.go", "DISCLAIMER"}, CaseInsensitive: true},
+	}
+	tests := []struct {
+		name         string
+		content      string
+		wantExcluded map[string]bool
+		wantScore    float64
+	}{
+		{"no exclusion", "As an AI, I delve.", map[string]bool{"as-an-ai": false, "delve": false}, 11},
+		{"single exclude", "<!-- human-written -->\nAs an AI, I delve.", map[string]bool{"as-an-ai": true, "delve": false}, 1},
+		{"any of several excludes", "As an AI, I delve. Disclaimer: none.", map[string]bool{"as-an-ai": false, "delve": true}, 10},
+		{"exclude alone records nothing", "<!-- human-written -->", map[string]bool{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := AnalyseString(tt.content, "a.md", rules, Config{Threshold: 100})
+			assert.Equal(t, tt.wantScore, r.Score)
+			require.Len(t, r.Detail, len(tt.wantExcluded))
+			for name, excluded := range tt.wantExcluded {
+				h := r.Detail[name]
+				assert.Equal(t, 1, h.Count, name)
+				assert.Equal(t, excluded, h.Excluded, name)
+				if excluded {
+					assert.Zero(t, h.Scored, name)
+				}
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	r := AnalyseString("<!-- human-written --> As an AI", "a.md", rules, Config{Threshold: 1})
+	Render(&buf, []Result{r}, Config{VeryVerbose: true})
+	assert.Contains(t, buf.String(), "as-an-ai × 1 (excluded)")
+}
+
+func TestAnalysePatterns(t *testing.T) {
+	rules := []Rule{{
+		Name:     "summary",
+		Patterns: []string{"In conclusion", "To summarize", "In summary", "In summary"},
+		Weight:   5,
+	}}
+	content := "In conclusion, yes.\nIn summary, yes.\nIn conclusion, no."
+
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 1, CollectLines: true})
+	h := r.Detail["summary"]
+	assert.Equal(t, 3, h.Count, "count is the sum of every pattern's matches")
+	assert.Equal(t, 2, h.Scored, "score is per distinct pattern matched")
+	assert.Equal(t, 10.0, r.Score)
+	assert.Equal(t, []int{1, 2, 3}, h.Lines)
+
+	// Pattern alone behaves like a single alternative
+	single := AnalyseString(content, "a.txt", []Rule{{Name: "summary", Patterns: []string{"In conclusion"}, Weight: 5}}, Config{})
+	plain := AnalyseString(content, "a.txt", []Rule{{Name: "summary", Pattern: "In conclusion", Weight: 5}}, Config{})
+	assert.Equal(t, 2, single.Detail["summary"].Count)
+	assert.Equal(t, 2, plain.Detail["summary"].Count)
+	assert.Equal(t, 5.0, single.Score)
+	assert.Equal(t, 10.0, plain.Score, "a single Pattern still scores per occurrence")
+
+	ci := AnalyseString("IN SUMMARY", "a.txt", []Rule{{Name: "s", Patterns: []string{"in summary"}, Weight: 5, CaseInsensitive: true}}, Config{})
+	assert.Equal(t, 5.0, ci.Score)
+}
+
+func TestAnalyseForceBinary(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("MARK cell MARK")
+	content[4] = 0 // NUL between the two matches
+	nb := filepath.Join(dir, "plot.ipynb")
+	bin := filepath.Join(dir, "plot.bin")
+	require.NoError(t, os.WriteFile(nb, content, 0644))
+	require.NoError(t, os.WriteFile(bin, content, 0644))
+	rules := CompileRules([]Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, "")
+
+	assert.Zero(t, analyse(nb, rules, Config{Threshold: 1}).Score, "binary files are skipped by default")
+
+	cfg := Config{Threshold: 1, ForcedExts: []string{"ipynb"}}
+	assert.Equal(t, 20.0, analyse(nb, rules, cfg).Score, "a forced extension is scored")
+	assert.Zero(t, analyse(bin, rules, cfg).Score, "other binary files are still skipped")
+
+	cfg = Config{Threshold: 1, ForceBinary: true}
+	assert.Equal(t, 20.0, analyse(bin, rules, cfg).Score)
+	assert.Equal(t, 20.0, AnalyseString(string(content), "x", Rules(rules), cfg).Score)
+}
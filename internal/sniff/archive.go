@@ -0,0 +1,153 @@
+package sniff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// archiveSep separates an archive path from a member path in results,
+// e.g. "bundle.zip::docs/readme.md".
+const archiveSep = "::"
+
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveZip
+	archiveTar
+	archiveTarGz
+	archiveTarBz2
+)
+
+// archiveKindOf classifies a path by its (case-insensitive) suffix.
+func archiveKindOf(path string) archiveKind {
+	p := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(p, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(p, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(p, ".tar.bz2"):
+		return archiveTarBz2
+	}
+	return archiveNone
+}
+
+// isArchive reports whether path names a supported archive.
+func isArchive(path string) bool { return archiveKindOf(path) != archiveNone }
+
+// scanArchive analyses every regular member of the archive at path in
+// memory and passes each result to emit.
+func scanArchive(path string, rules []CompiledRule, cfg Config, emit func(Result)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.Warn("failed to close archive", "err", err)
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return scanArchiveReader(path, f, info.Size(), rules, cfg, emit)
+}
+
+// scanArchiveReader walks the members of an archive held in r.
+func scanArchiveReader(name string, r io.ReaderAt, size int64, rules []CompiledRule, cfg Config, emit func(Result)) error {
+	if archiveKindOf(name) == archiveZip {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = scanMember(name, f.Name, int64(f.UncompressedSize64), rc, rules, cfg, emit)
+			_ = rc.Close() // read-only, nothing to flush
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var src io.Reader = io.NewSectionReader(r, 0, size)
+	switch archiveKindOf(name) {
+	case archiveTarGz:
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		src = gz
+	case archiveTarBz2:
+		src = bzip2.NewReader(src)
+	}
+
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := scanMember(name, hdr.Name, hdr.Size, tr, rules, cfg, emit); err != nil {
+			return err
+		}
+	}
+}
+
+// scanMember reads one archive member and scores it. cfg.MaxSize applies
+// to the member, not to the archive as a whole; with cfg.ChunkedScan
+// larger members other than nested archives are scored in chunks.
+func scanMember(archive, member string, size int64, r io.Reader, rules []CompiledRule, cfg Config, emit func(Result)) error {
+	path := archive + archiveSep + member
+	nested := cfg.ScanArchivesRecursively && isArchive(member)
+	if cfg.MaxSize > 0 && size > cfg.MaxSize && (nested || !cfg.ChunkedScan) {
+		emit(Result{Path: path})
+		return nil
+	}
+
+	if !nested {
+		// Never trust the header size alone: analyseReader caps the read
+		// one byte past MaxSize unless it chunks
+		res, err := analyseReader(r, path, rules, cfg)
+		if err != nil {
+			return err
+		}
+		emit(res)
+		return nil
+	}
+
+	if cfg.MaxSize > 0 {
+		r = io.LimitReader(r, cfg.MaxSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return scanArchiveReader(path, bytes.NewReader(data), int64(len(data)), rules, cfg, emit)
+}
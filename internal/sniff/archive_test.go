@@ -0,0 +1,149 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeZip builds a zip archive from name -> content pairs.
+func makeZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// makeTarGz builds a gzip-compressed tar archive from name -> content pairs.
+func makeTarGz(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// scanByPath runs Scan and indexes the results by path.
+func scanByPath(t *testing.T, root string, cfg Config) map[string]Result {
+	t.Helper()
+	results, err := Scan(context.Background(), []string{root}, cfg)
+	require.NoError(t, err)
+	out := make(map[string]Result, len(results))
+	for _, r := range results {
+		out[r.Path] = r
+	}
+	return out
+}
+
+// TestScanArchives verifies archive members are scanned in memory.
+func TestScanArchives(t *testing.T) {
+	dir := t.TempDir()
+	members := map[string][]byte{
+		"docs/readme.md": []byte("intro\n---\nMARK"),
+		"notes.txt":      []byte("\n---\nMARK MARK"),
+		"image.bin":      {'M', 'A', 'R', 'K', 0x00},
+		"big.txt":        bytes.Repeat([]byte("MARK "), 100),
+	}
+	zipPath := filepath.Join(dir, "bundle.zip")
+	require.NoError(t, os.WriteFile(zipPath, makeZip(t, members), 0644))
+	tgzPath := filepath.Join(dir, "bundle.tgz")
+	require.NoError(t, os.WriteFile(tgzPath, makeTarGz(t, members), 0644))
+
+	cfg := Config{
+		Threshold:  30,
+		MaxSize:    100,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+	got := scanByPath(t, dir, cfg)
+
+	for _, archive := range []string{zipPath, tgzPath} {
+		md := got[archive+"::docs/readme.md"]
+		assert.Equal(t, 40.0, md.Score, "markdown-hrule applies by member extension")
+		assert.True(t, md.Smelly)
+
+		txt := got[archive+"::notes.txt"]
+		assert.Equal(t, 20.0, txt.Score)
+
+		assert.Contains(t, got, archive+"::image.bin")
+		assert.Zero(t, got[archive+"::image.bin"].Score, "binary members are skipped")
+
+		assert.Contains(t, got, archive+"::big.txt")
+		assert.Zero(t, got[archive+"::big.txt"].Score, "MaxSize applies per member")
+	}
+	assert.NotContains(t, got, zipPath, "archive itself is not scored")
+}
+
+// TestScanNestedArchives verifies nested archives need an explicit opt-in.
+func TestScanNestedArchives(t *testing.T) {
+	dir := t.TempDir()
+	inner := makeZip(t, map[string][]byte{"inner.txt": []byte("MARK")})
+	outer := makeZip(t, map[string][]byte{"inner.zip": inner})
+	outerPath := filepath.Join(dir, "outer.zip")
+	require.NoError(t, os.WriteFile(outerPath, outer, 0644))
+
+	cfg := Config{
+		Threshold:  1,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	got := scanByPath(t, dir, cfg)
+	assert.Contains(t, got, outerPath+"::inner.zip")
+	assert.NotContains(t, got, outerPath+"::inner.zip::inner.txt")
+
+	cfg.ScanArchivesRecursively = true
+	got = scanByPath(t, dir, cfg)
+	assert.Equal(t, 10.0, got[outerPath+"::inner.zip::inner.txt"].Score)
+}
+
+// TestArchiveKindOf verifies suffix detection.
+func TestArchiveKindOf(t *testing.T) {
+	assert.Equal(t, archiveZip, archiveKindOf("a/B.ZIP"))
+	assert.Equal(t, archiveTar, archiveKindOf("a.tar"))
+	assert.Equal(t, archiveTarGz, archiveKindOf("a.tar.gz"))
+	assert.Equal(t, archiveTarGz, archiveKindOf("a.tgz"))
+	assert.Equal(t, archiveTarBz2, archiveKindOf("a.tar.bz2"))
+	assert.Equal(t, archiveNone, archiveKindOf("a.gz"))
+	assert.Equal(t, archiveNone, archiveKindOf("a.md"))
+}
+
+// TestScanTarBz2 verifies bzip2-compressed tarballs using a fixture, since
+// the standard library cannot write bzip2.
+func TestScanTarBz2(t *testing.T) {
+	path := filepath.Join("testdata", "archive", "bundle.tar.bz2")
+	var got []Result
+	err := scanArchive(path, CompileRules([]Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, ""), Config{Threshold: 30},
+		func(r Result) { got = append(got, r) })
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, path+"::docs/member.txt", got[0].Path)
+	assert.Equal(t, 30.0, got[0].Score)
+	assert.True(t, got[0].Smelly)
+}
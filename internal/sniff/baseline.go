@@ -0,0 +1,140 @@
+package sniff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// baselineVersion is written to new baselines; LoadBaseline rejects
+// files from a newer release.
+const baselineVersion = 1
+
+// Baseline records each file's score at one point in time, so later
+// scans can fail only on regressions.
+type Baseline struct {
+	Version int                      `json:"version"`
+	Files   map[string]BaselineEntry `json:"files"` // keyed by result path
+}
+
+// BaselineEntry is one file in a Baseline.
+type BaselineEntry struct {
+	Score  float64 `json:"score"`
+	Smelly bool    `json:"smelly"`
+}
+
+// NewBaseline records results.
+func NewBaseline(results []Result) Baseline {
+	b := Baseline{Version: baselineVersion, Files: make(map[string]BaselineEntry, len(results))}
+	for _, r := range results {
+		b.Files[r.Path] = BaselineEntry{Score: r.Score, Smelly: r.Smelly}
+	}
+	return b
+}
+
+// WriteBaseline writes NewBaseline(results) as JSON to path.
+func WriteBaseline(path string, results []Result) error {
+	data, err := json.MarshalIndent(NewBaseline(results), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadBaseline reads a baseline written by WriteBaseline.
+func LoadBaseline(path string) (Baseline, error) {
+	var b Baseline
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("baseline %s: %w", path, err)
+	}
+	if b.Version > baselineVersion {
+		return b, fmt.Errorf("baseline %s: unsupported version %d", path, b.Version)
+	}
+	return b, nil
+}
+
+// Baseline change kinds, also the markers of RenderBaselineDiff.
+const (
+	BaselineNewSmelly  = "+" // smelly now, but new or clean in the baseline
+	BaselineRemediated = "-" // smelly in the baseline, clean now
+	BaselineChanged    = "~" // score changed otherwise
+)
+
+// BaselineChange is a file whose verdict or score differs from the
+// baseline.
+type BaselineChange struct {
+	Path     string  `json:"path"`
+	Kind     string  `json:"kind"` // BaselineNewSmelly, BaselineRemediated or BaselineChanged
+	New      bool    `json:"new"`  // not in the baseline
+	OldScore float64 `json:"oldScore"`
+	Score    float64 `json:"score"`
+	Failed   bool    `json:"failed"` // a regression that fails -ci
+}
+
+// CompareBaseline returns, sorted by path, the results that differ from
+// b. A file regresses, and Failed is set, when it is smelly and either
+// new, clean in the baseline or scoring higher than it did; a smelly
+// file whose score dropped or held is accepted. Files missing from
+// results are not reported.
+func CompareBaseline(b Baseline, results []Result) []BaselineChange {
+	var changes []BaselineChange
+	for _, r := range results {
+		old, known := b.Files[r.Path]
+		c := BaselineChange{Path: r.Path, New: !known, OldScore: old.Score, Score: r.Score}
+		switch {
+		case r.Smelly && (!known || !old.Smelly):
+			c.Kind, c.Failed = BaselineNewSmelly, true
+		case !r.Smelly && known && old.Smelly:
+			c.Kind = BaselineRemediated
+		case known && r.Score != old.Score:
+			c.Kind, c.Failed = BaselineChanged, r.Smelly && r.Score > old.Score
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// BaselineFailed reports whether any change is a regression.
+func BaselineFailed(changes []BaselineChange) bool {
+	for _, c := range changes {
+		if c.Failed {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderBaselineDiff prints one line per change, e.g.
+// "+ docs/new.md (42, new file)", "- docs/old.md (40 → 12)" or
+// "~ docs/a.md (31 → 35)", regressions in red, then a count.
+func RenderBaselineDiff(w io.Writer, changes []BaselineChange, cfg Config) {
+	st := newTextStyle(w, cfg)
+	regressions := 0
+	for _, c := range changes {
+		line := c.Kind + " " + displayPath(c.Path)
+		if c.New {
+			line += fmt.Sprintf(" (%s, new file)", FormatScore(c.Score))
+		} else {
+			line += fmt.Sprintf(" (%s → %s)", FormatScore(c.OldScore), FormatScore(c.Score))
+		}
+		switch {
+		case c.Failed:
+			regressions++
+			fmt.Fprintln(w, st.paint(ansiRed, line))
+		case c.Kind == BaselineRemediated:
+			fmt.Fprintln(w, st.paint(ansiGreen, line))
+		default:
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintln(w, st.meta(fmt.Sprintf("Baseline: %d change(s), %d regression(s)", len(changes), regressions)))
+}
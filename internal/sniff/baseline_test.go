@@ -0,0 +1,77 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	results := []Result{{Path: "a.md", Score: 42, Smelly: true}, {Path: "b.md", Score: 3}}
+	require.NoError(t, WriteBaseline(path, results))
+
+	b, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, NewBaseline(results), b)
+	assert.Equal(t, BaselineEntry{Score: 42, Smelly: true}, b.Files["a.md"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "files": {}}`), 0644))
+	_, err = LoadBaseline(path)
+	assert.ErrorContains(t, err, "unsupported version")
+	_, err = LoadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestCompareBaseline(t *testing.T) {
+	base := NewBaseline([]Result{
+		{Path: "remediated.md", Score: 40, Smelly: true},
+		{Path: "worsened.md", Score: 31, Smelly: true},
+		{Path: "improved.md", Score: 50, Smelly: true},
+		{Path: "unchanged.md", Score: 35, Smelly: true},
+		{Path: "now-smelly.md", Score: 10},
+		{Path: "drifted.md", Score: 2},
+		{Path: "deleted.md", Score: 60, Smelly: true},
+	})
+	results := []Result{
+		{Path: "remediated.md", Score: 12},
+		{Path: "worsened.md", Score: 35, Smelly: true},
+		{Path: "improved.md", Score: 45, Smelly: true},
+		{Path: "unchanged.md", Score: 35, Smelly: true},
+		{Path: "now-smelly.md", Score: 30, Smelly: true},
+		{Path: "drifted.md", Score: 5},
+		{Path: "new.md", Score: 42, Smelly: true},
+		{Path: "new-clean.md", Score: 1},
+	}
+
+	changes := CompareBaseline(base, results)
+	assert.Equal(t, []BaselineChange{
+		{Path: "drifted.md", Kind: BaselineChanged, OldScore: 2, Score: 5},
+		{Path: "improved.md", Kind: BaselineChanged, OldScore: 50, Score: 45},
+		{Path: "new.md", Kind: BaselineNewSmelly, New: true, Score: 42, Failed: true},
+		{Path: "now-smelly.md", Kind: BaselineNewSmelly, OldScore: 10, Score: 30, Failed: true},
+		{Path: "remediated.md", Kind: BaselineRemediated, OldScore: 40, Score: 12},
+		{Path: "worsened.md", Kind: BaselineChanged, OldScore: 31, Score: 35, Failed: true},
+	}, changes, "unchanged, deleted and new clean files are left out")
+	assert.True(t, BaselineFailed(changes))
+
+	var buf bytes.Buffer
+	RenderBaselineDiff(&buf, changes, Config{Color: ColorNever})
+	assert.Equal(t, "~ drifted.md (2 → 5)\n"+
+		"~ improved.md (50 → 45)\n"+
+		"+ new.md (42, new file)\n"+
+		"+ now-smelly.md (10 → 30)\n"+
+		"- remediated.md (40 → 12)\n"+
+		"~ worsened.md (31 → 35)\n"+
+		"Baseline: 6 change(s), 3 regression(s)\n", buf.String())
+
+	// Only accepted changes
+	changes = CompareBaseline(base, []Result{{Path: "improved.md", Score: 45, Smelly: true}, {Path: "remediated.md", Score: 1}})
+	assert.Len(t, changes, 2)
+	assert.False(t, BaselineFailed(changes))
+}
@@ -165,7 +165,7 @@ func benchPrintUltra(r Result) {
 	os.Stdout = null
 
 	// Print to the redirected stdout
-	printUltra(r)
+	printUltra(os.Stdout, plainStyle, r)
 
 	// Restore stdout
 	os.Stdout = old
@@ -191,7 +191,7 @@ func makeResult(n int, smelly bool) Result {
 
 	return Result{
 		Path:   fmt.Sprintf("/path/to/file-%d.txt", n),
-		Score:  n * 10, // 10 points per rule
+		Score:  float64(n * 10), // 10 points per rule
 		Detail: details,
 		Smelly: smelly,
 	}
@@ -0,0 +1,193 @@
+package sniff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheFileName is the file written inside Config.CacheDir.
+const CacheFileName = "synthsniff.cache.json"
+
+// cacheVersion changes whenever cached results change shape, so older
+// caches are discarded through the fingerprint.
+const cacheVersion = 3
+
+// cacheEntry is one file's cached outcome plus the stat data that keys it.
+type cacheEntry struct {
+	Path     string             `json:"path"`
+	MTime    int64              `json:"mtime"`
+	Size     int64              `json:"size"`
+	Score    float64            `json:"score"`
+	RawScore int                `json:"rawScore"`
+	Smelly   bool               `json:"smelly"`
+	Detail   map[string]RuleHit `json:"detail,omitempty"`
+	Chunks   int                `json:"chunks,omitempty"` // Result.ChunkCount
+	Heatmap  []int              `json:"heatmap,omitempty"`
+	Skipped  string             `json:"skipped,omitempty"` // Result.SkipReason
+}
+
+// cacheFile is the on-disk layout of the cache.
+type cacheFile struct {
+	Fingerprint string       `json:"fingerprint"`
+	Files       []cacheEntry `json:"files"`
+}
+
+// scanCache reuses results for files whose mtime and size are unchanged.
+// It is safe for concurrent use by workers.
+type scanCache struct {
+	mu          sync.Mutex
+	path        string
+	fingerprint string
+	entries     map[string]cacheEntry // key is the absolute path
+	dirty       bool
+}
+
+// loadCache opens the cache in dir. A missing, unreadable or stale cache
+// (different fingerprint) starts empty rather than failing the scan.
+func loadCache(dir, fingerprint string) *scanCache {
+	c := &scanCache{
+		path:        filepath.Join(dir, CacheFileName),
+		fingerprint: fingerprint,
+		entries:     make(map[string]cacheEntry),
+	}
+
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("cache read failed", "err", err)
+		}
+		return c
+	}
+	var f cacheFile
+	if err := json.Unmarshal(b, &f); err != nil || f.Fingerprint != fingerprint {
+		c.dirty = true // rewrite with the current fingerprint
+		return c
+	}
+	for _, e := range f.Files {
+		c.entries[e.Path] = e
+	}
+	return c
+}
+
+// lookup returns the cached result for path if its stat data still matches.
+func (c *scanCache) lookup(path string, info os.FileInfo, cfg Config) (Result, bool) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return Result{}, false
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || e.MTime != info.ModTime().UnixNano() || e.Size != info.Size() {
+		return Result{}, false
+	}
+
+	smelly, warning := cfg.classify(e.Score)
+	return Result{
+		Path:       path,
+		Score:      e.Score,
+		RawScore:   e.RawScore,
+		Confidence: cfg.confidence(e.Score),
+		Detail:     e.Detail,
+		Smelly:     smelly,
+		Warning:    warning,
+		Grade:      cfg.grade(e.Score),
+		Chunked:    e.Chunks > 0,
+		ChunkCount: e.Chunks,
+		Heatmap:    e.Heatmap,
+		Skipped:    e.Skipped != "",
+		SkipReason: e.Skipped,
+	}, true
+}
+
+// store records r for path under the given stat data.
+func (c *scanCache) store(path string, info os.FileInfo, r Result) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{
+		Path:     key,
+		MTime:    info.ModTime().UnixNano(),
+		Size:     info.Size(),
+		Score:    r.Score,
+		RawScore: r.RawScore,
+		Smelly:   r.Smelly,
+		Detail:   r.Detail,
+		Chunks:   r.ChunkCount,
+		Heatmap:  r.Heatmap,
+		Skipped:  r.SkipReason,
+	}
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// save writes the cache back if anything changed.
+func (c *scanCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	f := cacheFile{Fingerprint: c.fingerprint, Files: make([]cacheEntry, 0, len(c.entries))}
+	for _, e := range c.entries {
+		f.Files = append(f.Files, e)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, b, 0644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// analyseCached serves path from the cache when possible.
+func analyseCached(path string, rules []CompiledRule, cfg Config, cache *scanCache) Result {
+	info, err := os.Stat(path)
+	if err != nil {
+		return analyse(path, rules, cfg)
+	}
+	if r, ok := cache.lookup(path, info, cfg); ok {
+		return r
+	}
+	r := analyse(path, rules, cfg)
+	if r.Err == "" {
+		cache.store(path, info, r)
+	}
+	return r
+}
+
+// cacheFingerprint hashes everything that changes a file's score: the full
+// rule definitions plus the analysis options in cfg.
+func cacheFingerprint(rules []CompiledRule, cfg Config) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\n", cacheVersion)
+	enc := json.NewEncoder(h)
+	_ = enc.Encode(rules) // hashing writer never fails
+	for _, r := range rules {
+		// Word lists and group gates are not part of the JSON form
+		_ = enc.Encode(r.words)
+		fmt.Fprintf(h, "group-min=%d\n", r.minGroupScore)
+	}
+	fmt.Fprintf(h, "min=%d max=%d format=%s lines=%t snippets=%t/%d normalize=%t unicode=%s mime=%t lang=%t code=%t front=%t chunked=%t/%d heatmap=%d binary=%t/%q",
+		cfg.MinSize, cfg.MaxSize, cfg.Format, cfg.wantsLines(), cfg.Snippets, cfg.snippetWidth(), cfg.Normalize, cfg.UnicodeNorm, cfg.DetectMIME,
+		cfg.DetectLanguage, cfg.ExcludeCodeBlocks, cfg.StripFrontMatter, cfg.ChunkedScan, cfg.chunkSize(), cfg.Heatmap, cfg.ForceBinary, cfg.ForcedExts)
+	return hex.EncodeToString(h.Sum(nil))
+}
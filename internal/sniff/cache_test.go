@@ -0,0 +1,72 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poisonCache rewrites every cached score so cache hits are recognisable.
+func poisonCache(t *testing.T, dir string, score int) {
+	t.Helper()
+	path := filepath.Join(dir, CacheFileName)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var f cacheFile
+	require.NoError(t, json.Unmarshal(b, &f))
+	require.NotEmpty(t, f.Files)
+	for i := range f.Files {
+		f.Files[i].Score = float64(score)
+	}
+	b, err = json.Marshal(f)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, b, 0644))
+}
+
+// TestScanCache verifies unchanged files come from the cache while
+// modified files and rule changes force a re-scan.
+func TestScanCache(t *testing.T) {
+	srcDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	file := filepath.Join(srcDir, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("MARK"), 0644))
+
+	cfg := Config{
+		Threshold:  30,
+		CacheDir:   cacheDir,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+	scan := func(cfg Config) Result {
+		t.Helper()
+		results, err := Scan(context.Background(), []string{srcDir}, cfg)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	// First run populates the cache
+	assert.Equal(t, 10.0, scan(cfg).Score)
+	require.FileExists(t, filepath.Join(cacheDir, CacheFileName))
+
+	// Unchanged file: the (poisoned) cached score is returned
+	poisonCache(t, cacheDir, 999)
+	r := scan(cfg)
+	assert.Equal(t, 999.0, r.Score, "unchanged file should be served from cache")
+	assert.True(t, r.Smelly, "smelly is recomputed against the current threshold")
+
+	// Modified file: re-scanned
+	require.NoError(t, os.WriteFile(file, []byte("MARK MARK"), 0644))
+	assert.Equal(t, 20.0, scan(cfg).Score, "modified file should be re-scanned")
+
+	// Changed rules invalidate the whole cache
+	poisonCache(t, cacheDir, 999)
+	cfg.ExtraRules[0].Weight = 5
+	assert.Equal(t, 10.0, scan(cfg).Score, "rule change should invalidate the cache")
+}
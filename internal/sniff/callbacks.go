@@ -0,0 +1,33 @@
+package sniff
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// onResult passes r to Config.OnResult, if set. A panic in the callback
+// is logged and does not stop the scan.
+func (c Config) onResult(r Result) {
+	if c.OnResult == nil {
+		return
+	}
+	defer recoverCallback("OnResult", r.Path)
+	c.OnResult(r)
+}
+
+// fileError passes a file that could not be scored to Config.OnError, if
+// set. A panic in the callback is logged and does not stop the scan.
+func (c Config) fileError(path string, err error) {
+	if c.OnError == nil {
+		return
+	}
+	defer recoverCallback("OnError", path)
+	c.OnError(path, err)
+}
+
+// recoverCallback logs a panic in the named callback; call it deferred.
+func recoverCallback(name, path string) {
+	if v := recover(); v != nil {
+		slog.Error("callback panicked", "callback", name, "path", path, "panic", fmt.Sprint(v))
+	}
+}
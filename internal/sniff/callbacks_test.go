@@ -0,0 +1,72 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanCallbacks(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("MARK MARK"), 0644))
+	}
+	broken := filepath.Join(dir, "broken.zip")
+	require.NoError(t, os.WriteFile(broken, []byte("not a zip"), 0644))
+
+	var mu sync.Mutex
+	var seen []string
+	errs := map[string]error{}
+	cfg := Config{
+		Threshold:  1,
+		Workers:    3,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+		OnResult: func(r Result) {
+			mu.Lock()
+			seen = append(seen, r.Path)
+			mu.Unlock()
+			if filepath.Base(r.Path) == "b.md" {
+				panic("boom")
+			}
+		},
+		OnError: func(path string, err error) {
+			mu.Lock()
+			errs[path] = err
+			mu.Unlock()
+		},
+	}
+
+	results, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 4, "a panicking callback does not stop the scan")
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.Path)
+		if r.Path == broken {
+			assert.NotEmpty(t, r.Err)
+			continue
+		}
+		assert.Equal(t, 20.0, r.Score)
+	}
+	sort.Strings(seen)
+	assert.Equal(t, paths, seen, "every result goes to OnResult")
+	require.Contains(t, errs, broken)
+	assert.Error(t, errs[broken])
+}
+
+func TestCallbacksUnset(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Config{}.onResult(Result{Path: "a.md"})
+		Config{}.fileError("a.md", os.ErrNotExist)
+	})
+	assert.NotPanics(t, func() {
+		Config{OnError: func(string, error) { panic("boom") }}.fileError("a.md", os.ErrNotExist)
+	})
+}
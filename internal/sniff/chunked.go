@@ -0,0 +1,181 @@
+package sniff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"unicode/utf8"
+)
+
+// DefaultChunkSize is the window Config.ChunkedScan reads oversize files
+// in when Config.ChunkSize is unset.
+const DefaultChunkSize int64 = 1 << 20
+
+// chunkSize returns the configured chunk size in bytes.
+func (c Config) chunkSize() int64 {
+	if c.ChunkSize > 0 {
+		return c.ChunkSize
+	}
+	return DefaultChunkSize
+}
+
+// analyseReader scores the content of r as a file called name. Content
+// over cfg.MaxSize yields an empty Result, unless cfg.ChunkedScan scores
+// it chunk by chunk; otherwise at most one byte past MaxSize is read.
+func analyseReader(r io.Reader, name string, rules []CompiledRule, cfg Config) (Result, error) {
+	if cfg.MaxSize <= 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return Result{Path: name}, err
+		}
+		return AnalyseCompiled(data, name, rules, cfg), nil
+	}
+	// Read one byte past MaxSize so AnalyseCompiled still rejects oversize input
+	data, err := io.ReadAll(io.LimitReader(r, cfg.MaxSize+1))
+	if err != nil {
+		return Result{Path: name}, err
+	}
+	if int64(len(data)) <= cfg.MaxSize || !cfg.ChunkedScan {
+		return AnalyseCompiled(data, name, rules, cfg), nil
+	}
+	// The length is unknown until the end, but more than MaxSize
+	return analyseChunks(io.MultiReader(bytes.NewReader(data), r), name, cfg.MaxSize+1, rules, cfg)
+}
+
+// analyseChunks scores r in windows of cfg.ChunkSize bytes. Each window
+// starts with the end of the one before, enough to hold the longest
+// pattern, so no match is lost at a boundary; what the rules find in
+// that overlap alone is taken back out, so no match counts twice.
+// Chunks are scored independently, so rule thresholds, exclude patterns
+// and group minimums apply per chunk; their scores are summed.
+//
+// size is r's length, or a lower bound on it for a stream. Rules whose
+// MaxFileSize it exceeds are left out up front; those a stream only
+// outgrows later have their hits taken back out at the end.
+func analyseChunks(r io.Reader, name string, size int64, rules []CompiledRule, cfg Config) (Result, error) {
+	rules = rulesForSize(rules, size)
+	overlap := chunkOverlap(rules)
+	chunk := int(cfg.chunkSize())
+	buf := make([]byte, overlap+chunk)
+
+	inner := cfg
+	inner.Normalize = false // the total is normalized once, below
+	raw, total, lineBase, kept := 0, 0, 0, 0
+	detail := make(map[string]RuleHit)
+	chunks := 0
+	for {
+		n, err := io.ReadFull(r, buf[kept:kept+chunk])
+		if n > 0 {
+			window := buf[:kept+n]
+			if !cfg.forcesBinary(name) && bytes.IndexByte(window[kept:], 0) != -1 {
+				return Result{Path: name}, nil
+			}
+			chunks++
+			total += n
+
+			whole := scoreContent(string(window), name, rules, inner)
+			var seen Result
+			if kept > 0 {
+				seen = scoreContent(string(window[:kept]), name, rules, inner)
+			}
+			raw += max(whole.RawScore-seen.RawScore, 0)
+			mergeChunkHits(detail, whole.Detail, seen.Detail, lineBase)
+
+			// Carry the tail over as the next window's overlap
+			next := min(overlap, len(window))
+			lineBase += bytes.Count(window[:len(window)-next], []byte{'\n'})
+			kept = copy(buf, window[len(window)-next:])
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return Result{Path: name}, err
+		}
+	}
+
+	for _, cr := range rules {
+		if h, ok := detail[cr.Name]; ok && !cr.appliesToSize(int64(total)) {
+			raw -= h.Scored * cr.Weight
+			delete(detail, cr.Name)
+		}
+	}
+
+	final := float64(raw)
+	if cfg.Normalize {
+		final = normalizeScore(raw, total)
+	}
+	smelly, warning := cfg.classify(final)
+	return Result{
+		Path:       name,
+		Score:      final,
+		RawScore:   raw,
+		Confidence: cfg.confidence(final),
+		Detail:     detail,
+		Smelly:     smelly,
+		Warning:    warning,
+		Grade:      cfg.grade(final),
+		Chunked:    true,
+		ChunkCount: chunks,
+	}, nil
+}
+
+// rulesForSize returns the rules that run on a file of n bytes; rules
+// itself, and so its shared matcher, when all of them do.
+func rulesForSize(rules []CompiledRule, n int64) []CompiledRule {
+	for i, r := range rules {
+		if r.appliesToSize(n) {
+			continue
+		}
+		out := slices.Clone(rules[:i])
+		for _, r := range rules[i+1:] {
+			if r.appliesToSize(n) {
+				out = append(out, r)
+			}
+		}
+		return out
+	}
+	return rules
+}
+
+// mergeChunkHits adds a window's hits to detail, less those already
+// found in the window's overlap (seen), with lines shifted by lineBase.
+func mergeChunkHits(detail, whole, seen map[string]RuleHit, lineBase int) {
+	for name, h := range whole {
+		prev := seen[name]
+		count := h.Count - prev.Count
+		if count <= 0 {
+			continue
+		}
+		acc := detail[name]
+		acc.Rule = h.Rule
+		acc.Count += count
+		acc.Scored += max(h.Scored-prev.Scored, 0)
+		acc.Excluded = acc.Excluded || h.Excluded
+		// Overlap matches come first, so the hits new to this window are
+		// the trailing ones
+		for _, l := range h.Lines[min(len(prev.Lines), len(h.Lines)):] {
+			acc.Lines = append(acc.Lines, lineBase+l)
+		}
+		acc.Snippets = append(acc.Snippets, h.Snippets[min(len(prev.Snippets), len(h.Snippets)):]...)
+		detail[name] = acc
+	}
+}
+
+// chunkOverlap returns how many bytes each chunk repeats from the one
+// before: one less than the longest pattern, and at least a full UTF-8
+// character so no character is split for normalization.
+func chunkOverlap(rules []CompiledRule) int {
+	longest := 0
+	for _, cr := range rules {
+		r := cr.Rule
+		for _, p := range append(r.patterns(), r.excludePatterns()...) {
+			longest = max(longest, len(p))
+		}
+		if r.Proximity != nil {
+			longest = max(longest, len(r.Proximity.PatternA)+r.Proximity.MaxDistance+len(r.Proximity.PatternB))
+		}
+	}
+	return max(longest-1, utf8.UTFMax)
+}
@@ -0,0 +1,108 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChunkedScan verifies an oversize file is scored in chunks with the
+// same counts and lines as a whole-file scan, matches across a chunk
+// boundary included and none counted twice.
+func TestChunkedScan(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("plain line with — a dash and a long phrase: delve deeper\n")
+	}
+	content := b.String()
+	path := filepath.Join(t.TempDir(), "big.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	rules := CompileRules([]Rule{
+		{Name: "em-dash", Pattern: "—", Weight: 1},
+		{Name: "delve", Pattern: "delve deeper", Weight: 2},
+	}, "")
+
+	whole := analyse(path, rules, Config{Threshold: 1, CollectLines: true})
+	require.Equal(t, 200, whole.Detail["em-dash"].Count)
+
+	cfg := Config{Threshold: 1, CollectLines: true, MaxSize: 1000}
+	assert.Empty(t, analyse(path, rules, cfg).Detail, "oversize files are skipped by default")
+
+	// A chunk size coprime to the line length puts matches on boundaries
+	cfg.ChunkedScan, cfg.ChunkSize = true, 97
+	r := analyse(path, rules, cfg)
+	assert.True(t, r.Chunked)
+	assert.Equal(t, (len(content)+96)/97, r.ChunkCount)
+	assert.Equal(t, whole.RawScore, r.RawScore)
+	assert.Equal(t, whole.Detail["em-dash"].Count, r.Detail["em-dash"].Count)
+	assert.Equal(t, whole.Detail["delve"].Count, r.Detail["delve"].Count)
+	assert.Equal(t, whole.Detail["delve"].Lines, r.Detail["delve"].Lines)
+	assert.True(t, r.Smelly)
+
+	cfg.Normalize = true
+	r = analyse(path, rules, cfg)
+	assert.InDelta(t, normalizeScore(whole.RawScore, len(content)), r.Score, 0.001, "normalized over the whole file")
+}
+
+func TestChunkedScanSmallFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "small.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a — b"), 0644))
+	r := analyse(path, CompileRules(baseRules, ""), Config{Threshold: 1, MaxSize: 100, ChunkedScan: true, ChunkSize: 2})
+	assert.False(t, r.Chunked, "files within MaxSize are read whole")
+	assert.Equal(t, 1, r.Detail["em-dash"].Count)
+}
+
+func TestChunkedScanBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bin.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("—", 50)+"\x00"), 0644))
+	r := analyse(path, CompileRules(baseRules, ""), Config{Threshold: 1, MaxSize: 10, ChunkedScan: true, ChunkSize: 16})
+	assert.Empty(t, r.Detail, "a NUL byte in any chunk marks the file binary")
+}
+
+func TestChunkedStdin(t *testing.T) {
+	withStdin(t, strings.Repeat("— ", 100))
+	r := analyseStdin(CompileRules(baseRules, ""), Config{Threshold: 1, MaxSize: 50, ChunkedScan: true, ChunkSize: 40})
+	assert.Equal(t, StdinPath, r.Path)
+	assert.True(t, r.Chunked)
+	assert.Equal(t, 100, r.Detail["em-dash"].Count)
+}
+
+func TestChunkOverlap(t *testing.T) {
+	assert.Equal(t, 4, chunkOverlap(CompileRules([]Rule{{Name: "a", Pattern: "ab"}}, "")), "at least one UTF-8 character")
+	rules := CompileRules([]Rule{
+		{Name: "a", Pattern: "short"},
+		{Name: "b", Pattern: "x", Excludes: []string{"a much longer exclude"}},
+		{Name: "c", Proximity: &Proximity{PatternA: "ab", PatternB: "cd", MaxDistance: 30}},
+	}, "")
+	assert.Equal(t, 33, chunkOverlap(rules))
+}
+
+// TestChunkedScanRuleMaxFileSize verifies MaxFileSize is checked against
+// the whole file, not each chunk, for files and streams alike.
+func TestChunkedScanRuleMaxFileSize(t *testing.T) {
+	content := strings.Repeat("— ", 100) // 400 bytes
+	rules := CompileRules([]Rule{
+		{Name: "em-dash", Pattern: "—", Weight: 1, MaxFileSize: 300},
+		{Name: "space", Pattern: " ", Weight: 1, MaxFileSize: 400},
+	}, "")
+	cfg := Config{Threshold: 1, MaxSize: 50, ChunkedScan: true, ChunkSize: 40}
+
+	path := filepath.Join(t.TempDir(), "big.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	r := analyse(path, rules, cfg)
+	assert.True(t, r.Chunked)
+	assert.NotContains(t, r.Detail, "em-dash")
+	assert.Equal(t, 100, r.Detail["space"].Count)
+	assert.Equal(t, 100, r.RawScore)
+
+	// A stream's length is only known at the end
+	withStdin(t, content)
+	r = analyseStdin(rules, cfg)
+	assert.NotContains(t, r.Detail, "em-dash")
+	assert.Equal(t, 100, r.RawScore)
+}
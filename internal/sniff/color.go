@@ -0,0 +1,95 @@
+package sniff
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ANSI SGR sequences used by the text output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiGrey   = "\x1b[90m"
+)
+
+// textStyle decides how text output is decorated. JSON, SARIF and HTML
+// never use it.
+type textStyle struct {
+	color bool
+	emoji bool
+}
+
+// plainStyle keeps the emoji but adds no escape codes.
+var plainStyle = textStyle{emoji: true}
+
+// newTextStyle resolves cfg.Color against w: "auto" colors only when w is
+// a terminal and NO_COLOR is unset.
+func newTextStyle(w io.Writer, cfg Config) textStyle {
+	st := textStyle{emoji: !cfg.NoEmoji}
+	switch cfg.Color {
+	case ColorAlways:
+		st.color = true
+	case ColorNever:
+	default:
+		st.color = os.Getenv("NO_COLOR") == "" && isTerminal(w)
+	}
+	return st
+}
+
+// isTerminal reports whether w is a file attached to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+func (st textStyle) paint(code, s string) string {
+	if !st.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// path colors a file path red when smelly, yellow on a warning and green
+// otherwise.
+func (st textStyle) path(r Result) string {
+	switch {
+	case r.Smelly:
+		return st.paint(ansiRed, displayPath(r.Path))
+	case r.Warning:
+		return st.paint(ansiYellow, displayPath(r.Path))
+	}
+	return st.paint(ansiGreen, displayPath(r.Path))
+}
+
+func (st textStyle) rule(name string) string { return st.paint(ansiYellow, name) }
+
+func (st textStyle) meta(s string) string { return st.paint(ansiGrey, s) }
+
+// status returns the marker for r: 🚨 smelly, ⚠️ warning, ❌ unread,
+// ✅ clean.
+func (st textStyle) status(r Result) string {
+	if r.Warning && st.emoji {
+		return "⚠️ "
+	}
+	if r.Err != "" && st.emoji {
+		return "❌ "
+	}
+	return st.icon(r.Smelly)
+}
+
+// icon returns the status marker followed by a space, or "" when emoji
+// are off.
+func (st textStyle) icon(smelly bool) string {
+	switch {
+	case !st.emoji:
+		return ""
+	case smelly:
+		return "🚨 "
+	default:
+		return "✅ "
+	}
+}
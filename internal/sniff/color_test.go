@@ -0,0 +1,67 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func colorResults() []Result {
+	return []Result{
+		{Path: "clean.md", Score: 1},
+		{
+			Path:   "smelly.md",
+			Score:  40,
+			Smelly: true,
+			Detail: map[string]RuleHit{"rule1": {Rule: Rule{Name: "rule1", Pattern: "x", Weight: 40}, Count: 1}},
+		},
+	}
+}
+
+func TestNewTextStyle(t *testing.T) {
+	var buf bytes.Buffer
+	assert.False(t, newTextStyle(&buf, Config{}).color, "auto is off for a non-terminal")
+	assert.True(t, newTextStyle(&buf, Config{Color: ColorAlways}).color)
+	assert.False(t, newTextStyle(&buf, Config{Color: ColorNever}).color)
+	assert.False(t, newTextStyle(&buf, Config{NoEmoji: true}).emoji)
+}
+
+func TestRenderColorAlways(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, colorResults(), Config{Color: ColorAlways, UltraVerbose: true})
+	out := buf.String()
+
+	assert.Contains(t, out, ansiRed+"smelly.md"+ansiReset)
+	assert.Contains(t, out, ansiGreen+"clean.md"+ansiReset)
+	assert.Contains(t, out, ansiYellow+"rule1"+ansiReset)
+	assert.Contains(t, out, ansiGrey+"(score 40, confidence 0%)"+ansiReset)
+}
+
+func TestRenderNoColorInPipes(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, colorResults(), Config{VeryVerbose: true})
+	assert.NotContains(t, buf.String(), "\x1b[")
+}
+
+func TestRenderMachineFormatsIgnoreColor(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatSARIF} {
+		var buf bytes.Buffer
+		Render(&buf, colorResults(), Config{Format: format, Color: ColorAlways})
+		assert.NotContains(t, buf.String(), "\x1b[", format)
+	}
+}
+
+func TestRenderNoEmoji(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, colorResults(), Config{VeryVerbose: true, NoEmoji: true})
+	out := buf.String()
+	assert.NotContains(t, out, "🚨")
+	assert.NotContains(t, out, "✅")
+	assert.Contains(t, out, "smelly.md (score 40, confidence 0%)")
+
+	buf.Reset()
+	Render(&buf, colorResults()[:1], Config{NoEmoji: true})
+	assert.Equal(t, "No AI smell detected in 1 file(s)\n", buf.String())
+}
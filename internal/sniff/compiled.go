@@ -0,0 +1,42 @@
+package sniff
+
+// CompiledRule is a Rule bound to the automaton built for the rule set it
+// was compiled with. Rules compiled together share one matcher, so files
+// scored with them skip the per-file rule-set lookup entirely.
+type CompiledRule struct {
+	Rule
+	matcher *ruleMatcher
+}
+
+// CompileRules builds the automaton for rules matched in the global
+// normalization form once and binds every rule to it. Building costs
+// far more than scoring a typical file, so compile a rule set once and
+// keep the result, as Scanner does.
+func CompileRules(rules []Rule, form string) []CompiledRule {
+	rm := newRuleMatcher(rules, form)
+	out := make([]CompiledRule, len(rules))
+	for i, r := range rules {
+		out[i] = CompiledRule{Rule: r, matcher: rm}
+	}
+	return out
+}
+
+// Rules returns the plain rules behind compiled.
+func Rules(compiled []CompiledRule) []Rule {
+	out := make([]Rule, len(compiled))
+	for i, c := range compiled {
+		out[i] = c.Rule
+	}
+	return out
+}
+
+// matcherOf returns the automaton rules were compiled with, or builds a
+// new one when they were compiled for another form.
+func matcherOf(rules []CompiledRule, form string) *ruleMatcher {
+	if len(rules) > 0 {
+		if rm := rules[0].matcher; rm != nil && rm.form == form && len(rm.pass) == len(rules) {
+			return rm
+		}
+	}
+	return newRuleMatcher(Rules(rules), form)
+}
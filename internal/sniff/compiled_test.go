@@ -0,0 +1,56 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRules(t *testing.T) {
+	rules := []Rule{
+		{Name: "mark", Pattern: "MARK", Weight: 10},
+		{Name: "dash", Pattern: "--", Weight: 1},
+	}
+	compiled := CompileRules(rules, "")
+	require.Len(t, compiled, 2)
+	assert.Equal(t, rules, Rules(compiled))
+	assert.Same(t, compiled[0].matcher, compiled[1].matcher, "one automaton for the whole set")
+	assert.Same(t, compiled[0].matcher, matcherOf(compiled, ""))
+
+	nfc := matcherOf(compiled, "NFC")
+	assert.NotSame(t, compiled[0].matcher, nfc, "another form gets its own automaton")
+	assert.Equal(t, "NFC", nfc.form)
+	assert.NotSame(t, nfc, matcherOf(compiled, "NFC"), "built again each time, nothing is cached")
+}
+
+func TestAnalyseCompiled(t *testing.T) {
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}
+	cfg := Config{Threshold: 15}
+	data := []byte("MARK MARK")
+
+	got := AnalyseCompiled(data, "a.txt", CompileRules(rules, ""), cfg)
+	assert.Equal(t, AnalyseBytes(data, "a.txt", rules, cfg), got)
+	assert.Equal(t, 20.0, got.Score)
+	assert.True(t, got.Smelly)
+}
+
+func TestLoadRulesCompiled(t *testing.T) {
+	set, err := LoadRules(nil)
+	require.NoError(t, err)
+	rules := set.Compile("")
+	require.Len(t, rules, len(baseRules))
+	assert.Equal(t, baseRules, Rules(rules))
+	assert.Same(t, rules[0].matcher, rules[len(rules)-1].matcher)
+	assert.Same(t, rules[0].matcher, matcherOf(rules, ""), "no rebuild for the form compiled in")
+}
+
+func TestScoreContentReusesScratch(t *testing.T) {
+	compiled := CompileRules([]Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, "")
+	cfg := Config{Threshold: 1}
+	// Counts from one file must not leak into the next through the pool
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 10.0, scoreContent("MARK", "a.txt", compiled, cfg).Score)
+	}
+}
@@ -2,30 +2,273 @@ package sniff
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by -format.
+const (
+	FormatText      = "text"
+	FormatJSON      = "json"
+	FormatJSONArray = "json-array" // the results alone, as a bare JSON array
+	FormatSARIF     = "sarif"
+	FormatHTML      = "html"
+	FormatCSV       = "csv"
+	FormatJUnit     = "junit"
+	FormatGHA       = "gha"    // GitHub Actions workflow commands
+	FormatNDJSON    = "ndjson" // one JSON object per line, then a summary line
+)
+
+// Package defaults, as used by the CLI and Config.WithDefaults.
+const (
+	DefaultThreshold       = 30       // score at which a file is smelly
+	DefaultMaxSize   int64 = 10 << 20 // larger files are skipped
+	DefaultGitBase         = "HEAD"   // ref diffed against in git diff mode
+)
+
+// DefaultConfidenceScale is the multiple of the threshold at which
+// Result.Confidence reaches 1.
+const DefaultConfidenceScale = 3.0
+
+// Default CLI exit codes, as set by -exit-clean, -exit-smelly,
+// -exit-error and -exit-timeout.
+const (
+	DefaultExitCodeClean   = 0
+	DefaultExitCodeSmelly  = 1
+	DefaultExitCodeError   = 2
+	DefaultExitCodeTimeout = 3
+	DefaultExitCodeWarning = 4
+)
+
+// Color modes accepted by -color.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
 )
 
 // Config groups runtime options.
+//
+// The tags define the keys accepted in a .synthsniff.yaml/.json/.toml file.
 type Config struct {
-	DictPath          string   // -dict
-	Threshold         int      // -t
-	MaxSize           int64    // -max
-	Workers           int      // -j
-	Verbose           bool     // -v
-	VeryVerbose       bool     // -vv
-	UltraVerbose      bool     // -vvv
-	CIMode            bool     // -ci
-	JSON              bool     // -json
-	UseGitignore      bool     // -use-gitignore
-	IgnoreFile        string   // -ignore-file <path>
-	LoadedIgnoreFiles []string // For -vvv reporting
-}
-
-// ParseThreshold validates env threshold.
-func ParseThreshold(s string) (int, error) {
-	n, err := strconv.Atoi(s)
-	if err != nil || n <= 0 {
+	DictPaths               PathList       `json:"dict,omitempty" yaml:"dict,omitempty"`                                       // -dict, repeatable
+	StrictDict              bool           `json:"strictDict,omitempty" yaml:"strictDict,omitempty"`                           // -strict-dict
+	DictTimeout             time.Duration  `json:"-" yaml:"-"`                                                                 // -dict-timeout for http(s) dicts, 0 = DefaultDictTimeout
+	InsecureDict            bool           `json:"-" yaml:"-"`                                                                 // -insecure-dict: skip TLS verification for http(s) dicts
+	DisabledRules           []string       `json:"disabledRules,omitempty" yaml:"disabledRules,omitempty"`                     // -disable-rule, repeatable
+	WeightOverrides         map[string]int `json:"weightOverrides,omitempty" yaml:"weightOverrides,omitempty"`                 // -set-weight name=N, repeatable: new weights by rule name
+	OnlyTags                []string       `json:"onlyTags,omitempty" yaml:"onlyTags,omitempty"`                               // -only-tags a,b: load only rules with one of these Rule.Tags
+	ExcludeTags             []string       `json:"excludeTags,omitempty" yaml:"excludeTags,omitempty"`                         // -exclude-tags a,b: skip rules with one of these Rule.Tags
+	MinSeverity             string         `json:"minSeverity,omitempty" yaml:"minSeverity,omitempty"`                         // -min-severity: load only rules at or above it (info, warn, error)
+	Threshold               float64        `json:"threshold,omitempty" yaml:"threshold,omitempty"`                             // -t or -error-threshold, per KB with Normalize
+	WarnThreshold           float64        `json:"warnThreshold,omitempty" yaml:"warnThreshold,omitempty"`                     // -warn-threshold, 0 = no warning level
+	ConfidenceScale         float64        `json:"confidenceScale,omitempty" yaml:"confidenceScale,omitempty"`                 // -confidence-scale: Result.Confidence reaches 1 at this many times the threshold, 0 = DefaultConfidenceScale
+	Normalize               bool           `json:"normalize,omitempty" yaml:"normalize,omitempty"`                             // -normalize
+	UnicodeNorm             string         `json:"unicodeNorm,omitempty" yaml:"unicodeNorm,omitempty"`                         // -unicode-norm (NFC, NFD, NFKC, NFKD)
+	MinSize                 int64          `json:"minSize,omitempty" yaml:"minSize,omitempty"`                                 // -min-size: smaller files are skipped unread
+	MaxSize                 int64          `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`                                 // -max
+	ChunkedScan             bool           `json:"chunkedScan,omitempty" yaml:"chunkedScan,omitempty"`                         // -chunked: score files over MaxSize in chunks
+	ChunkSize               int64          `json:"chunkSize,omitempty" yaml:"chunkSize,omitempty"`                             // -chunk-size, 0 = DefaultChunkSize
+	ForceBinary             bool           `json:"forceBinary,omitempty" yaml:"forceBinary,omitempty"`                         // -force-binary: score files even when they contain NUL bytes
+	ForcedExts              []string       `json:"forcedExts,omitempty" yaml:"forcedExts,omitempty"`                           // -force-ext, repeatable: extensions scored despite NUL bytes
+	MmapThreshold           int64          `json:"mmapThreshold,omitempty" yaml:"mmapThreshold,omitempty"`                     // -mmap-threshold: larger files are memory mapped, 0 = package default
+	Timeout                 time.Duration  `json:"-" yaml:"-"`                                                                 // -timeout: stop the scan and keep partial results, 0 = none
+	MaxErrors               int            `json:"maxErrors,omitempty" yaml:"maxErrors,omitempty"`                             // -max-errors: stop with ErrTooManyErrors once more files fail to read, 0 = unlimited
+	Workers                 int            `json:"workers,omitempty" yaml:"workers,omitempty"`                                 // -j
+	Verbose                 bool           `json:"verbose,omitempty" yaml:"verbose,omitempty"`                                 // -v
+	VeryVerbose             bool           `json:"veryVerbose,omitempty" yaml:"veryVerbose,omitempty"`                         // -vv
+	UltraVerbose            bool           `json:"ultraVerbose,omitempty" yaml:"ultraVerbose,omitempty"`                       // -vvv
+	CIMode                  bool           `json:"ci,omitempty" yaml:"ci,omitempty"`                                           // -ci
+	ExitCodeClean           int            `json:"exitCodeClean,omitempty" yaml:"exitCodeClean,omitempty"`                     // -exit-clean: exit code of a scan that finds nothing, DefaultExitCodeClean
+	ExitCodeSmelly          int            `json:"exitCodeSmelly,omitempty" yaml:"exitCodeSmelly,omitempty"`                   // -exit-smelly: exit code with -ci on a smelly file, 0 = DefaultExitCodeSmelly
+	ExitCodeWarning         int            `json:"exitCodeWarning,omitempty" yaml:"exitCodeWarning,omitempty"`                 // -exit-warning: exit code with -ci on warnings alone, 0 = DefaultExitCodeWarning
+	ExitCodeError           int            `json:"exitCodeError,omitempty" yaml:"exitCodeError,omitempty"`                     // -exit-error: exit code on a fatal error, 0 = DefaultExitCodeError
+	ExitCodeTimeout         int            `json:"exitCodeTimeout,omitempty" yaml:"exitCodeTimeout,omitempty"`                 // -exit-timeout: exit code when Timeout stops the scan, 0 = DefaultExitCodeTimeout
+	Format                  string         `json:"format,omitempty" yaml:"format,omitempty"`                                   // -format (text, json, json-array, ndjson, sarif, html, csv, junit, gha); -json is shorthand
+	JSONVersion             int            `json:"jsonVersion,omitempty" yaml:"jsonVersion,omitempty"`                         // -json-version: JSON report layout, JSONReportV1 (default) or JSONReportV2
+	Color                   string         `json:"color,omitempty" yaml:"color,omitempty"`                                     // -color (auto, always, never); -no-color is never
+	NoEmoji                 bool           `json:"noEmoji,omitempty" yaml:"noEmoji,omitempty"`                                 // -no-emoji
+	UseGitignore            bool           `json:"useGitignore,omitempty" yaml:"useGitignore,omitempty"`                       // -use-gitignore
+	FollowSymlinks          bool           `json:"followSymlinks,omitempty" yaml:"followSymlinks,omitempty"`                   // -follow-symlinks
+	NamePatterns            []string       `json:"name,omitempty" yaml:"name,omitempty"`                                       // -name: only scan files whose base name matches one of these globs
+	IncludePatterns         []string       `json:"include,omitempty" yaml:"include,omitempty"`                                 // -include <glob>, repeatable
+	ExcludePatterns         []string       `json:"exclude,omitempty" yaml:"exclude,omitempty"`                                 // -exclude <glob>, repeatable; applies to named files too
+	MaxDepth                *int           `json:"maxDepth,omitempty" yaml:"maxDepth,omitempty"`                               // -depth: subdirectory levels to descend, 0 = only the named directories, nil = unlimited
+	IgnoreFile              string         `json:"ignoreFile,omitempty" yaml:"ignoreFile,omitempty"`                           // -ignore-file <path>
+	Allowlist               string         `json:"allowlist,omitempty" yaml:"allowlist,omitempty"`                             // -allowlist <path>: globs of accepted files, one per line
+	CacheDir                string         `json:"cacheDir,omitempty" yaml:"cacheDir,omitempty"`                               // -cache-dir
+	ScanArchivesRecursively bool           `json:"scanArchivesRecursively,omitempty" yaml:"scanArchivesRecursively,omitempty"` // -scan-archives-recursively
+	DeduplicateContent      bool           `json:"deduplicateContent,omitempty" yaml:"deduplicateContent,omitempty"`           // -dedup: score identical files with one extension once, the rest become Result.Aliases
+	GitDiff                 bool           `json:"gitDiff,omitempty" yaml:"gitDiff,omitempty"`                                 // -git-diff
+	GitBase                 string         `json:"gitBase,omitempty" yaml:"gitBase,omitempty"`                                 // -git-base <ref>
+	StdinExt                string         `json:"stdinExt,omitempty" yaml:"stdinExt,omitempty"`                               // -stdin-ext (.md)
+	DetectMIME              bool           `json:"detectMime,omitempty" yaml:"detectMime,omitempty"`                           // -detect-mime
+	DetectLanguage          bool           `json:"detectLanguage,omitempty" yaml:"detectLanguage,omitempty"`                   // -detect-language
+	ExcludeCodeBlocks       bool           `json:"excludeCodeBlocks,omitempty" yaml:"excludeCodeBlocks,omitempty"`             // -exclude-code-blocks
+	StripFrontMatter        bool           `json:"stripFrontMatter,omitempty" yaml:"stripFrontMatter,omitempty"`               // -strip-front-matter
+	CollectLines            bool           `json:"collectLines,omitempty" yaml:"collectLines,omitempty"`                       // set by -vv, -vvv and -json
+	Snippets                bool           `json:"snippets,omitempty" yaml:"snippets,omitempty"`                               // -snippets
+	SnippetWidth            int            `json:"snippetWidth,omitempty" yaml:"snippetWidth,omitempty"`                       // -snippet-width (80)
+	Quiet                   bool           `json:"quiet,omitempty" yaml:"quiet,omitempty"`                                     // -quiet
+	Print0                  bool           `json:"print0,omitempty" yaml:"print0,omitempty"`                                   // -print0: text output is only the smelly (with Invert, clean) paths, NUL-separated
+	Invert                  bool           `json:"invert,omitempty" yaml:"invert,omitempty"`                                   // -invert: write only the files that are not smelly
+	CountOnly               bool           `json:"count,omitempty" yaml:"count,omitempty"`                                     // -count: write only how many files are smelly (with Invert, clean)
+	Top                     int            `json:"top,omitempty" yaml:"top,omitempty"`                                         // -top N: only write the N highest-scoring flagged files, 0 = all
+	TopAll                  bool           `json:"topAll,omitempty" yaml:"topAll,omitempty"`                                   // -top-all: rank clean files for -top too
+	TimingMode              bool           `json:"timing,omitempty" yaml:"timing,omitempty"`                                   // -timing: record Result.Duration per file
+	Grades                  bool           `json:"grades,omitempty" yaml:"grades,omitempty"`                                   // -grades: set Result.Grade
+	GradeBoundaries         map[string]int `json:"gradeThresholds,omitempty" yaml:"gradeThresholds,omitempty"`                 // -grade-thresholds: lowest score per grade, nil = quarters of Threshold
+	Heatmap                 int            `json:"heatmap,omitempty" yaml:"heatmap,omitempty"`                                 // -heatmap: sections per file for Result.Heatmap, 0 = off
+	RuleStats               bool           `json:"ruleStats,omitempty" yaml:"ruleStats,omitempty"`                             // -rule-stats: hits per rule across the scan, "rule_stats" in JSON
+	ExtraRules              []Rule         `json:"rules,omitempty" yaml:"rules,omitempty"`                                     // config file only
+	AutoDiscoverRules       bool           `json:"autoDiscoverRules,omitempty" yaml:"autoDiscoverRules,omitempty"`             // -auto-discover-rules: add each walked directory's RulesFileName for its subtree
+	ConfigFile              string         `json:"-" yaml:"-"`                                                                 // file the settings came from, not re-applied per directory
+	NoDirConfigs            bool           `json:"-" yaml:"-"`                                                                 // -no-config: skip per-directory config files too
+	LoadedIgnoreFiles       []string       `json:"-" yaml:"-"`                                                                 // For -vvv reporting
+	Output                  string         `json:"-" yaml:"-"`                                                                 // -output: report file, created or truncated; "" = stdout
+	Started                 time.Time      `json:"-" yaml:"-"`                                                                 // scan start for the JSON v2 scan_time, set before Render
+	Elapsed                 time.Duration  `json:"-" yaml:"-"`                                                                 // scan wall-clock time for the JSON summary, set before Render
+	RuleCount               int            `json:"-" yaml:"-"`                                                                 // rules the scan loaded, for the JSON v2 config block, set before Render
+	Progress                *Progress      `json:"-" yaml:"-"`                                                                 // live counters, nil when unused
+	Metrics                 *Metrics       `json:"-" yaml:"-"`                                                                 // Prometheus counters, nil when unused
+	Profiler                *RuleProfiler  `json:"-" yaml:"-"`                                                                 // -profile-rules: match time per rule, nil when unused
+	IgnoreStats             *IgnoreStats   `json:"-" yaml:"-"`                                                                 // -ignore-stats and the loaded ignore files report, nil when unused
+	ClearBase               bool           `json:"-" yaml:"-"`                                                                 // MergeConfigs: replace the base's lists instead of appending to them
+
+	// OnResult, when set, receives each result as soon as it is scored,
+	// and OnError each file that could not be read or scored; the scan
+	// still returns every result. Both are called from several worker
+	// goroutines at once, so they must be safe for concurrent use. A
+	// panic in either is logged and the scan goes on.
+	OnResult func(Result)                 `json:"-" yaml:"-"`
+	OnError  func(path string, err error) `json:"-" yaml:"-"`
+}
+
+// wantsLines reports whether rule hits should carry line numbers. SARIF,
+// HTML, JUnit and GitHub Actions output always use them.
+func (c Config) wantsLines() bool {
+	switch c.Format {
+	case FormatSARIF, FormatHTML, FormatJUnit, FormatGHA:
+		return true
+	}
+	return c.CollectLines
+}
+
+// forcesBinary reports whether name is scored even if it holds NUL bytes:
+// always with ForceBinary, else when its extension is in ForcedExts.
+func (c Config) forcesBinary(name string) bool {
+	if c.ForceBinary {
+		return true
+	}
+	if len(c.ForcedExts) == 0 {
+		return false
+	}
+	ext := filepath.Ext(name)
+	for _, e := range c.ForcedExts {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// classify places a score against the thresholds: smelly at or above
+// Threshold, a warning from WarnThreshold up to it.
+func (c Config) classify(score float64) (smelly, warning bool) {
+	smelly = score >= c.Threshold
+	warning = !smelly && c.WarnThreshold > 0 && score >= c.WarnThreshold
+	return smelly, warning
+}
+
+// confidence maps score to 0..1: the share of ConfidenceScale times the
+// threshold it reaches, so by default a score of three times the
+// threshold is fully confident.
+func (c Config) confidence(score float64) float64 {
+	scale := c.ConfidenceScale
+	if scale <= 0 {
+		scale = DefaultConfidenceScale
+	}
+	full := c.Threshold * scale
+	if full <= 0 || score <= 0 {
+		return 0
+	}
+	return math.Min(1, score/full)
+}
+
+// ParseThreshold validates a -t or env threshold. Raw scores are whole
+// numbers; with normalized (per KB) scores decimals are accepted too.
+func ParseThreshold(s string, normalized bool) (float64, error) {
+	var n float64
+	var err error
+	if normalized {
+		n, err = strconv.ParseFloat(s, 64)
+	} else {
+		var i int
+		i, err = strconv.Atoi(s)
+		n = float64(i)
+	}
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
 		return 0, fmt.Errorf("invalid threshold %q", s)
 	}
 	return n, nil
 }
+
+// ParseFormat validates an output format name.
+func ParseFormat(s string) (string, error) {
+	switch s {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON, FormatJSONArray, FormatSARIF, FormatHTML, FormatCSV, FormatJUnit, FormatGHA, FormatNDJSON:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid format %q", s)
+}
+
+// ParseJSONVersion validates a JSON report layout; 0 is JSONReportV1.
+func ParseJSONVersion(n int) (int, error) {
+	switch n {
+	case 0, JSONReportV1:
+		return JSONReportV1, nil
+	case JSONReportV2:
+		return n, nil
+	}
+	return 0, fmt.Errorf("invalid JSON version %d (want 1 or 2)", n)
+}
+
+// ParseColor validates a color mode.
+func ParseColor(s string) (string, error) {
+	switch s {
+	case "":
+		return ColorAuto, nil
+	case ColorAuto, ColorAlways, ColorNever:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q", s)
+}
+
+// OpenOutput opens the report destination: c.Output, created or
+// truncated, or stdout when it is empty. Closing stdout's writer is a
+// no-op.
+func (c Config) OpenOutput() (io.WriteCloser, error) {
+	if c.Output == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	f, err := os.Create(c.Output)
+	if err != nil {
+		return nil, fmt.Errorf("open output: %w", err)
+	}
+	return f, nil
+}
+
+// nopCloser is an io.WriteCloser whose Close does nothing.
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -2,19 +2,24 @@
 package sniff
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestParseThreshold verifies that the threshold parsing
 // correctly validates and converts input strings.
 func TestParseThreshold(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   string
-		want    int
-		wantErr bool
+		name       string
+		input      string
+		normalized bool
+		want       float64
+		wantErr    bool
 	}{
 		{
 			name:    "valid positive number",
@@ -52,11 +57,31 @@ func TestParseThreshold(t *testing.T) {
 			want:    0,
 			wantErr: true,
 		},
+		{
+			name:    "decimal needs normalization",
+			input:   "2.5",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:       "decimal when normalized",
+			input:      "2.5",
+			normalized: true,
+			want:       2.5,
+			wantErr:    false,
+		},
+		{
+			name:       "normalized zero",
+			input:      "0.0",
+			normalized: true,
+			want:       0,
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParseThreshold(tt.input)
+			got, err := ParseThreshold(tt.input, tt.normalized)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -66,3 +91,102 @@ func TestParseThreshold(t *testing.T) {
 		})
 	}
 }
+
+// TestParseFormat verifies output format validation.
+// TestConfidence verifies a score's confidence grows linearly to 1 at
+// ConfidenceScale times the threshold and stops there.
+func TestConfidence(t *testing.T) {
+	cfg := Config{Threshold: 20}
+	tests := []struct {
+		score float64
+		want  float64
+	}{
+		{0, 0},
+		{20, 1.0 / 3},
+		{60, 1},
+		{200, 1},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, cfg.confidence(tt.score), 1e-9, "score %v", tt.score)
+	}
+
+	cfg.ConfidenceScale = 2
+	assert.Equal(t, 0.5, cfg.confidence(20))
+	assert.Zero(t, Config{}.confidence(10), "no threshold, no confidence")
+
+	r := AnalyseString("MARK MARK", "a.md", []Rule{{Name: "mark", Pattern: "MARK", Weight: 11}}, Config{Threshold: 10})
+	assert.InDelta(t, 22.0/30, r.Confidence, 1e-9)
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"confidence":0.73`)
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           FormatText,
+		"text":       FormatText,
+		"json":       FormatJSON,
+		"sarif":      FormatSARIF,
+		"ndjson":     FormatNDJSON,
+		"json-array": FormatJSONArray,
+	} {
+		got, err := ParseFormat(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestParseJSONVersion(t *testing.T) {
+	for in, want := range map[int]int{0: JSONReportV1, 1: JSONReportV1, 2: JSONReportV2} {
+		got, err := ParseJSONVersion(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseJSONVersion(3)
+	assert.Error(t, err)
+}
+
+func TestParseColor(t *testing.T) {
+	for in, want := range map[string]string{
+		"":       ColorAuto,
+		"auto":   ColorAuto,
+		"always": ColorAlways,
+		"never":  ColorNever,
+	} {
+		got, err := ParseColor(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseColor("sometimes")
+	assert.Error(t, err)
+}
+
+// TestOpenOutput verifies -output creates or truncates the report file
+// and fails on a path that cannot be opened.
+func TestOpenOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, os.WriteFile(path, []byte("an older and much longer report"), 0644))
+
+	cfg := Config{Output: path, Format: FormatJSON}
+	out, err := cfg.OpenOutput()
+	require.NoError(t, err)
+	Render(out, []Result{{Path: "a.md", Score: 42, Smelly: true}}, cfg)
+	require.NoError(t, out.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded jsonReport
+	require.NoError(t, json.Unmarshal(b, &decoded), "truncated, not appended to")
+	assert.Equal(t, "a.md", decoded.Results[0].Path)
+
+	out, err = Config{}.OpenOutput()
+	require.NoError(t, err)
+	assert.NoError(t, out.Close(), "stdout is left open")
+
+	_, err = Config{Output: filepath.Join(t.TempDir(), "missing", "report.json")}.OpenOutput()
+	assert.ErrorContains(t, err, "open output")
+}
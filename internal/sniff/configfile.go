@@ -0,0 +1,120 @@
+package sniff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames lists the project config files, in lookup order.
+var ConfigFileNames = []string{".synthsniff.yaml", ".synthsniff.yml", ".synthsniff.json", ".synthsniff.toml"}
+
+// FindConfigFile walks from dir up to the filesystem root and returns the
+// nearest config file, or "" when there is none.
+func FindConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range ConfigFileNames {
+			p := filepath.Join(dir, name)
+			info, err := os.Stat(p)
+			if err == nil && info.Mode().IsRegular() {
+				return p, nil
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfigFile parses a JSON, YAML or TOML config file. Relative dict,
+// ignore-file, allowlist and cache-dir paths are resolved against the config file's
+// directory.
+func LoadConfigFile(path string) (Config, error) {
+	var cfg Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".json"):
+		err = json.Unmarshal(b, &cfg)
+	case isTOML(path):
+		err = decodeTOML(b, &cfg)
+	default:
+		err = yaml.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i, p := range cfg.DictPaths {
+		if !isRemoteDict(p) {
+			cfg.DictPaths[i] = resolveRelative(dir, p)
+		}
+	}
+	cfg.IgnoreFile = resolveRelative(dir, cfg.IgnoreFile)
+	cfg.Allowlist = resolveRelative(dir, cfg.Allowlist)
+	cfg.CacheDir = resolveRelative(dir, cfg.CacheDir)
+	if err := loadWordLists(cfg.ExtraRules, dir); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := checkNormForms(cfg.ExtraRules); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := checkSeverities(cfg.ExtraRules); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	if cfg.MinSeverity, err = ParseSeverity(cfg.MinSeverity); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	cfg.ConfigFile = path
+	return cfg, nil
+}
+
+// resolveRelative joins a non-empty relative path onto dir.
+func resolveRelative(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// PathList is a list of paths that a config file may also give as a
+// single string, so "dict: rules.yaml" keeps working next to
+// "dict: [a.yaml, b.yaml]".
+type PathList []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (l *PathList) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*l = PathList{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
+
+// UnmarshalYAML accepts a scalar or a sequence of strings.
+func (l *PathList) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*l = PathList{n.Value}
+		return nil
+	}
+	return n.Decode((*[]string)(l))
+}
@@ -0,0 +1,88 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindConfigFile verifies the nearest config file wins.
+func TestFindConfigFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	rootCfg := filepath.Join(root, ".synthsniff.yaml")
+	require.NoError(t, os.WriteFile(rootCfg, []byte("threshold: 10\n"), 0644))
+
+	got, err := FindConfigFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, rootCfg, got, "should find config in an ancestor")
+
+	midCfg := filepath.Join(root, "a", ".synthsniff.json")
+	require.NoError(t, os.WriteFile(midCfg, []byte(`{"threshold": 20}`), 0644))
+
+	got, err = FindConfigFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, midCfg, got, "nearest config should win")
+}
+
+// TestLoadConfigFile verifies YAML and JSON parsing and path resolution.
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, ".synthsniff.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+threshold: 12
+dict: rules/extra.yaml
+useGitignore: true
+format: json
+rules:
+  - name: config-rule
+    pattern: CONFIG_MARKER
+    weight: 40
+`), 0644))
+
+	cfg, err := LoadConfigFile(yamlPath)
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, cfg.Threshold)
+	assert.Equal(t, PathList{filepath.Join(dir, "rules", "extra.yaml")}, cfg.DictPaths)
+	assert.True(t, cfg.UseGitignore)
+	assert.Equal(t, FormatJSON, cfg.Format)
+	require.Len(t, cfg.ExtraRules, 1)
+	assert.Equal(t, "CONFIG_MARKER", cfg.ExtraRules[0].Pattern)
+
+	jsonPath := filepath.Join(dir, ".synthsniff.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"workers": 3, "ignoreFile": "/abs/ignore", "dict": ["a.yaml", "/abs/b.yaml"]}`), 0644))
+
+	cfg, err = LoadConfigFile(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.Workers)
+	assert.Equal(t, "/abs/ignore", cfg.IgnoreFile)
+	assert.Equal(t, PathList{filepath.Join(dir, "a.yaml"), "/abs/b.yaml"}, cfg.DictPaths, "dict also takes a list")
+
+	badPath := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(badPath, []byte(`{"threshold": "high"}`), 0644))
+	_, err = LoadConfigFile(badPath)
+	assert.Error(t, err)
+}
+
+// TestScanExtraRules verifies rules from a config file are applied.
+func TestScanExtraRules(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("CONFIG_MARKER"), 0644))
+
+	results, err := Scan(context.Background(), []string{dir}, Config{
+		Threshold:  30,
+		ExtraRules: []Rule{{Name: "config-rule", Pattern: "CONFIG_MARKER", Weight: 40}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Smelly)
+	assert.Contains(t, results[0].Detail, "config-rule")
+}
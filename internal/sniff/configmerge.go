@@ -0,0 +1,272 @@
+package sniff
+
+import "sync/atomic"
+
+// MergeConfigs layers override on top of base, e.g. a config file over
+// defaults set in code. A field override leaves at its zero value keeps
+// base's value, so a false bool never switches base's true off; use
+// ConfigOverrides for that. List fields are appended to base's, or
+// replace them when override.ClearBase is set; a non-empty
+// GradeBoundaries or WeightOverrides replaces base's as a whole.
+func MergeConfigs(base, override Config) Config {
+	out := base
+	out.ClearBase = false
+
+	out.DictPaths = mergeList(base.DictPaths, override.DictPaths, override.ClearBase)
+	out.DisabledRules = mergeList(base.DisabledRules, override.DisabledRules, override.ClearBase)
+	out.OnlyTags = mergeList(base.OnlyTags, override.OnlyTags, override.ClearBase)
+	out.ExcludeTags = mergeList(base.ExcludeTags, override.ExcludeTags, override.ClearBase)
+	out.ForcedExts = mergeList(base.ForcedExts, override.ForcedExts, override.ClearBase)
+	out.NamePatterns = mergeList(base.NamePatterns, override.NamePatterns, override.ClearBase)
+	out.IncludePatterns = mergeList(base.IncludePatterns, override.IncludePatterns, override.ClearBase)
+	out.ExcludePatterns = mergeList(base.ExcludePatterns, override.ExcludePatterns, override.ClearBase)
+	out.ExtraRules = mergeList(base.ExtraRules, override.ExtraRules, override.ClearBase)
+	out.LoadedIgnoreFiles = mergeList(base.LoadedIgnoreFiles, override.LoadedIgnoreFiles, override.ClearBase)
+
+	out.StrictDict = base.StrictDict || override.StrictDict
+	out.InsecureDict = base.InsecureDict || override.InsecureDict
+	out.Normalize = base.Normalize || override.Normalize
+	out.ChunkedScan = base.ChunkedScan || override.ChunkedScan
+	out.ForceBinary = base.ForceBinary || override.ForceBinary
+	out.Verbose = base.Verbose || override.Verbose
+	out.VeryVerbose = base.VeryVerbose || override.VeryVerbose
+	out.UltraVerbose = base.UltraVerbose || override.UltraVerbose
+	out.CIMode = base.CIMode || override.CIMode
+	out.NoEmoji = base.NoEmoji || override.NoEmoji
+	out.UseGitignore = base.UseGitignore || override.UseGitignore
+	out.FollowSymlinks = base.FollowSymlinks || override.FollowSymlinks
+	out.ScanArchivesRecursively = base.ScanArchivesRecursively || override.ScanArchivesRecursively
+	out.DeduplicateContent = base.DeduplicateContent || override.DeduplicateContent
+	out.GitDiff = base.GitDiff || override.GitDiff
+	out.DetectMIME = base.DetectMIME || override.DetectMIME
+	out.DetectLanguage = base.DetectLanguage || override.DetectLanguage
+	out.ExcludeCodeBlocks = base.ExcludeCodeBlocks || override.ExcludeCodeBlocks
+	out.StripFrontMatter = base.StripFrontMatter || override.StripFrontMatter
+	out.CollectLines = base.CollectLines || override.CollectLines
+	out.Snippets = base.Snippets || override.Snippets
+	out.Quiet = base.Quiet || override.Quiet
+	out.Print0 = base.Print0 || override.Print0
+	out.Invert = base.Invert || override.Invert
+	out.CountOnly = base.CountOnly || override.CountOnly
+	out.TopAll = base.TopAll || override.TopAll
+	out.TimingMode = base.TimingMode || override.TimingMode
+	out.Grades = base.Grades || override.Grades
+	out.RuleStats = base.RuleStats || override.RuleStats
+	out.AutoDiscoverRules = base.AutoDiscoverRules || override.AutoDiscoverRules
+	out.NoDirConfigs = base.NoDirConfigs || override.NoDirConfigs
+
+	mergeValue(&out.DictTimeout, override.DictTimeout)
+	mergeValue(&out.MinSeverity, override.MinSeverity)
+	mergeValue(&out.Threshold, override.Threshold)
+	mergeValue(&out.WarnThreshold, override.WarnThreshold)
+	mergeValue(&out.ConfidenceScale, override.ConfidenceScale)
+	mergeValue(&out.UnicodeNorm, override.UnicodeNorm)
+	mergeValue(&out.MinSize, override.MinSize)
+	mergeValue(&out.MaxSize, override.MaxSize)
+	mergeValue(&out.ChunkSize, override.ChunkSize)
+	mergeValue(&out.MmapThreshold, override.MmapThreshold)
+	mergeValue(&out.Timeout, override.Timeout)
+	mergeValue(&out.MaxErrors, override.MaxErrors)
+	mergeValue(&out.Workers, override.Workers)
+	mergeValue(&out.ExitCodeClean, override.ExitCodeClean)
+	mergeValue(&out.ExitCodeSmelly, override.ExitCodeSmelly)
+	mergeValue(&out.ExitCodeWarning, override.ExitCodeWarning)
+	mergeValue(&out.ExitCodeError, override.ExitCodeError)
+	mergeValue(&out.ExitCodeTimeout, override.ExitCodeTimeout)
+	mergeValue(&out.Format, override.Format)
+	mergeValue(&out.JSONVersion, override.JSONVersion)
+	mergeValue(&out.Color, override.Color)
+	mergeValue(&out.MaxDepth, override.MaxDepth)
+	mergeValue(&out.IgnoreFile, override.IgnoreFile)
+	mergeValue(&out.Allowlist, override.Allowlist)
+	mergeValue(&out.CacheDir, override.CacheDir)
+	mergeValue(&out.GitBase, override.GitBase)
+	mergeValue(&out.StdinExt, override.StdinExt)
+	mergeValue(&out.SnippetWidth, override.SnippetWidth)
+	mergeValue(&out.Top, override.Top)
+	mergeValue(&out.ConfigFile, override.ConfigFile)
+	mergeValue(&out.Output, override.Output)
+	mergeValue(&out.Started, override.Started)
+	mergeValue(&out.Elapsed, override.Elapsed)
+	mergeValue(&out.RuleCount, override.RuleCount)
+	mergeValue(&out.Progress, override.Progress)
+	mergeValue(&out.Metrics, override.Metrics)
+	mergeValue(&out.Profiler, override.Profiler)
+	mergeValue(&out.IgnoreStats, override.IgnoreStats)
+	if override.OnResult != nil {
+		out.OnResult = override.OnResult
+	}
+	if override.OnError != nil {
+		out.OnError = override.OnError
+	}
+	mergeValue(&out.Heatmap, override.Heatmap)
+	if len(override.GradeBoundaries) > 0 {
+		out.GradeBoundaries = override.GradeBoundaries
+	}
+	if len(override.WeightOverrides) > 0 {
+		out.WeightOverrides = override.WeightOverrides
+	}
+	return out
+}
+
+// mergeList appends override to base in a new slice, or returns override
+// alone when replace is set.
+func mergeList[T any](base, override []T, replace bool) []T {
+	if replace {
+		return override
+	}
+	if len(override) == 0 {
+		return base
+	}
+	return append(base[:len(base):len(base)], override...)
+}
+
+// mergeValue sets *dst to v unless v is the zero value.
+func mergeValue[T comparable](dst *T, v T) {
+	var zero T
+	if v != zero {
+		*dst = v
+	}
+}
+
+// ConfigOverrides sets Config's bool fields explicitly: a nil field
+// leaves the value alone, a non-nil one replaces it, false included.
+// Wrappers map command-line flags the user gave onto it.
+type ConfigOverrides struct {
+	StrictDict              *bool
+	InsecureDict            *bool
+	Normalize               *bool
+	ChunkedScan             *bool
+	ForceBinary             *bool
+	Verbose                 *bool
+	VeryVerbose             *bool
+	UltraVerbose            *bool
+	CIMode                  *bool
+	NoEmoji                 *bool
+	UseGitignore            *bool
+	FollowSymlinks          *bool
+	ScanArchivesRecursively *bool
+	DeduplicateContent      *bool
+	GitDiff                 *bool
+	DetectMIME              *bool
+	DetectLanguage          *bool
+	ExcludeCodeBlocks       *bool
+	StripFrontMatter        *bool
+	CollectLines            *bool
+	Snippets                *bool
+	Quiet                   *bool
+	Print0                  *bool
+	Invert                  *bool
+	CountOnly               *bool
+	TopAll                  *bool
+	TimingMode              *bool
+	Grades                  *bool
+	RuleStats               *bool
+	AutoDiscoverRules       *bool
+	NoDirConfigs            *bool
+	ClearBase               *bool
+}
+
+// Apply returns cfg with every non-nil override set.
+func (o ConfigOverrides) Apply(cfg Config) Config {
+	overrideBool(&cfg.StrictDict, o.StrictDict)
+	overrideBool(&cfg.InsecureDict, o.InsecureDict)
+	overrideBool(&cfg.Normalize, o.Normalize)
+	overrideBool(&cfg.ChunkedScan, o.ChunkedScan)
+	overrideBool(&cfg.ForceBinary, o.ForceBinary)
+	overrideBool(&cfg.Verbose, o.Verbose)
+	overrideBool(&cfg.VeryVerbose, o.VeryVerbose)
+	overrideBool(&cfg.UltraVerbose, o.UltraVerbose)
+	overrideBool(&cfg.CIMode, o.CIMode)
+	overrideBool(&cfg.NoEmoji, o.NoEmoji)
+	overrideBool(&cfg.UseGitignore, o.UseGitignore)
+	overrideBool(&cfg.FollowSymlinks, o.FollowSymlinks)
+	overrideBool(&cfg.ScanArchivesRecursively, o.ScanArchivesRecursively)
+	overrideBool(&cfg.DeduplicateContent, o.DeduplicateContent)
+	overrideBool(&cfg.GitDiff, o.GitDiff)
+	overrideBool(&cfg.DetectMIME, o.DetectMIME)
+	overrideBool(&cfg.DetectLanguage, o.DetectLanguage)
+	overrideBool(&cfg.ExcludeCodeBlocks, o.ExcludeCodeBlocks)
+	overrideBool(&cfg.StripFrontMatter, o.StripFrontMatter)
+	overrideBool(&cfg.CollectLines, o.CollectLines)
+	overrideBool(&cfg.Snippets, o.Snippets)
+	overrideBool(&cfg.Quiet, o.Quiet)
+	overrideBool(&cfg.Print0, o.Print0)
+	overrideBool(&cfg.Invert, o.Invert)
+	overrideBool(&cfg.CountOnly, o.CountOnly)
+	overrideBool(&cfg.TopAll, o.TopAll)
+	overrideBool(&cfg.TimingMode, o.TimingMode)
+	overrideBool(&cfg.Grades, o.Grades)
+	overrideBool(&cfg.RuleStats, o.RuleStats)
+	overrideBool(&cfg.AutoDiscoverRules, o.AutoDiscoverRules)
+	overrideBool(&cfg.NoDirConfigs, o.NoDirConfigs)
+	overrideBool(&cfg.ClearBase, o.ClearBase)
+	return cfg
+}
+
+// overrideBool sets *dst to *v when v is not nil.
+func overrideBool(dst *bool, v *bool) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// overrideValue is overrideBool for any type.
+func overrideValue[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// WithDefaults returns c with its zero fields set to the package
+// defaults the CLI uses. Fields whose zero value is itself the default,
+// such as WarnThreshold (no warning level) or MaxDepth (unlimited), are
+// left alone.
+func (c Config) WithDefaults() Config {
+	if c.Threshold == 0 {
+		c.Threshold = DefaultThreshold
+	}
+	if c.ConfidenceScale == 0 {
+		c.ConfidenceScale = DefaultConfidenceScale
+	}
+	if c.MaxSize == 0 {
+		c.MaxSize = DefaultMaxSize
+	}
+	if c.ChunkSize == 0 {
+		c.ChunkSize = DefaultChunkSize
+	}
+	if c.MmapThreshold == 0 {
+		c.MmapThreshold = atomic.LoadInt64(&mmapThreshold)
+	}
+	if c.DictTimeout == 0 {
+		c.DictTimeout = DefaultDictTimeout
+	}
+	if c.ExitCodeSmelly == 0 {
+		c.ExitCodeSmelly = DefaultExitCodeSmelly
+	}
+	if c.ExitCodeWarning == 0 {
+		c.ExitCodeWarning = DefaultExitCodeWarning
+	}
+	if c.ExitCodeError == 0 {
+		c.ExitCodeError = DefaultExitCodeError
+	}
+	if c.ExitCodeTimeout == 0 {
+		c.ExitCodeTimeout = DefaultExitCodeTimeout
+	}
+	if c.Workers == 0 {
+		c.Workers = getMaxProcs()
+	}
+	if c.Format == "" {
+		c.Format = FormatText
+	}
+	if c.Color == "" {
+		c.Color = ColorAuto
+	}
+	if c.GitBase == "" {
+		c.GitBase = DefaultGitBase
+	}
+	if c.SnippetWidth == 0 {
+		c.SnippetWidth = DefaultSnippetWidth
+	}
+	return c
+}
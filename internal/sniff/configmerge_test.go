@@ -0,0 +1,181 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleValue returns a non-zero value of type typ that differs per seed.
+func sampleValue(t *testing.T, typ reflect.Type, seed int) reflect.Value {
+	t.Helper()
+	v := reflect.New(typ).Elem()
+	if typ == reflect.TypeOf(time.Time{}) {
+		return reflect.ValueOf(time.Unix(int64(seed), 0))
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(int64(seed))
+	case reflect.Float64:
+		v.SetFloat(float64(seed) + 0.5)
+	case reflect.String:
+		v.SetString(string(rune('a' + seed)))
+	case reflect.Slice:
+		v = reflect.Append(v, sampleValue(t, typ.Elem(), seed))
+	case reflect.Struct:
+		v.Field(0).Set(sampleValue(t, typ.Field(0).Type, seed))
+	case reflect.Map:
+		v = reflect.MakeMap(typ)
+		v.SetMapIndex(sampleValue(t, typ.Key(), seed), sampleValue(t, typ.Elem(), seed))
+	case reflect.Ptr:
+		v = reflect.New(typ.Elem())
+	case reflect.Func:
+		v = reflect.MakeFunc(typ, func([]reflect.Value) []reflect.Value { return nil })
+	default:
+		t.Fatalf("no sample value for %s", typ)
+	}
+	return v
+}
+
+// sampleConfig fills every Config field except ClearBase.
+func sampleConfig(t *testing.T, seed int) Config {
+	t.Helper()
+	var cfg Config
+	v := reflect.ValueOf(&cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		// Funcs are never equal, so they stay nil for the comparisons
+		if v.Type().Field(i).Name != "ClearBase" && v.Field(i).Kind() != reflect.Func {
+			v.Field(i).Set(sampleValue(t, v.Field(i).Type(), seed))
+		}
+	}
+	return cfg
+}
+
+func TestMergeConfigsZeroKeepsBase(t *testing.T) {
+	base := sampleConfig(t, 1)
+	assert.Equal(t, base, MergeConfigs(base, Config{}))
+	assert.Equal(t, base, MergeConfigs(Config{}, base))
+}
+
+func TestMergeConfigsEveryField(t *testing.T) {
+	base := sampleConfig(t, 1)
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Name == "ClearBase" {
+			continue
+		}
+		t.Run(f.Name, func(t *testing.T) {
+			var override Config
+			want := sampleValue(t, f.Type, 2)
+			reflect.ValueOf(&override).Elem().Field(i).Set(want)
+
+			merged := MergeConfigs(base, override)
+			got := reflect.ValueOf(merged).Field(i)
+			baseVal := reflect.ValueOf(base).Field(i)
+			switch f.Type.Kind() {
+			case reflect.Slice:
+				want = reflect.AppendSlice(reflect.AppendSlice(reflect.New(f.Type).Elem(), baseVal), want)
+				assert.Equal(t, want.Interface(), got.Interface(), "lists are appended")
+				assert.Equal(t, 1, baseVal.Len(), "base is not modified")
+			case reflect.Bool:
+				assert.True(t, got.Bool())
+			case reflect.Func:
+				assert.False(t, got.IsNil())
+			default:
+				assert.Equal(t, want.Interface(), got.Interface())
+			}
+
+			// every other field keeps base's value
+			for j := 0; j < typ.NumField(); j++ {
+				baseVal, got := reflect.ValueOf(base).Field(j), reflect.ValueOf(merged).Field(j)
+				switch {
+				case j == i:
+				case typ.Field(j).Type.Kind() == reflect.Func:
+					assert.Equal(t, baseVal.IsNil(), got.IsNil(), typ.Field(j).Name)
+				default:
+					assert.Equal(t, baseVal.Interface(), got.Interface(), typ.Field(j).Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeConfigsClearBase(t *testing.T) {
+	base := Config{DictPaths: PathList{"a.yaml"}, ExcludePatterns: []string{"*.txt"}, Threshold: 10}
+	override := Config{DictPaths: PathList{"b.yaml"}, ClearBase: true}
+
+	merged := MergeConfigs(base, override)
+	assert.Equal(t, PathList{"b.yaml"}, merged.DictPaths)
+	assert.Empty(t, merged.ExcludePatterns, "every list is replaced")
+	assert.Equal(t, float64(10), merged.Threshold, "other fields still merge")
+	assert.False(t, merged.ClearBase)
+}
+
+func TestConfigOverrides(t *testing.T) {
+	// every bool of Config has an override
+	var bools []string
+	cfgType := reflect.TypeOf(Config{})
+	for i := 0; i < cfgType.NumField(); i++ {
+		if cfgType.Field(i).Type.Kind() == reflect.Bool {
+			bools = append(bools, cfgType.Field(i).Name)
+		}
+	}
+	var names []string
+	oType := reflect.TypeOf(ConfigOverrides{})
+	for i := 0; i < oType.NumField(); i++ {
+		names = append(names, oType.Field(i).Name)
+	}
+	require.Equal(t, bools, names)
+
+	cfg := sampleConfig(t, 1)
+	off, on := false, true
+	got := ConfigOverrides{Normalize: &off, Quiet: &off, ClearBase: &on}.Apply(cfg)
+	assert.False(t, got.Normalize)
+	assert.False(t, got.Quiet)
+	assert.True(t, got.ClearBase)
+	assert.True(t, got.Verbose, "nil overrides keep the value")
+
+	// every override reaches its field
+	var all ConfigOverrides
+	ov := reflect.ValueOf(&all).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		ov.Field(i).Set(reflect.ValueOf(&off))
+	}
+	got = all.Apply(cfg)
+	for _, name := range bools {
+		assert.False(t, reflect.ValueOf(got).FieldByName(name).Bool(), name)
+	}
+}
+
+func TestConfigWithDefaults(t *testing.T) {
+	cfg := Config{}.WithDefaults()
+	assert.Equal(t, float64(DefaultThreshold), cfg.Threshold)
+	assert.Equal(t, DefaultMaxSize, cfg.MaxSize)
+	assert.Equal(t, DefaultMmapThreshold, cfg.MmapThreshold)
+	assert.Equal(t, DefaultDictTimeout, cfg.DictTimeout)
+	assert.Equal(t, getMaxProcs(), cfg.Workers)
+	assert.Equal(t, FormatText, cfg.Format)
+	assert.Equal(t, ColorAuto, cfg.Color)
+	assert.Equal(t, DefaultGitBase, cfg.GitBase)
+	assert.Equal(t, DefaultSnippetWidth, cfg.SnippetWidth)
+	assert.Equal(t, DefaultExitCodeSmelly, cfg.ExitCodeSmelly)
+	assert.Equal(t, DefaultExitCodeWarning, cfg.ExitCodeWarning)
+	assert.Equal(t, DefaultExitCodeError, cfg.ExitCodeError)
+	assert.Equal(t, DefaultExitCodeTimeout, cfg.ExitCodeTimeout)
+	assert.Zero(t, cfg.ExitCodeClean)
+	assert.Zero(t, cfg.WarnThreshold)
+	assert.Zero(t, cfg.MaxDepth)
+
+	set := Config{Threshold: 5, Format: FormatJSON, Workers: 2, Timeout: time.Second}.WithDefaults()
+	assert.Equal(t, float64(5), set.Threshold)
+	assert.Equal(t, FormatJSON, set.Format)
+	assert.Equal(t, 2, set.Workers)
+	assert.Equal(t, time.Second, set.Timeout)
+}
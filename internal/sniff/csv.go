@@ -0,0 +1,72 @@
+package sniff
+
+import (
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// csvHeader names the columns of -format csv.
+var csvHeader = []string{"path", "score", "smelly", "rules_fired", "top_rule", "top_rule_count"}
+
+// renderCSV writes one RFC 4180 row per result after a header row.
+func renderCSV(w io.Writer, list []Result) {
+	cw := newCSVWriter(w)
+	for _, r := range list {
+		cw.write(r)
+	}
+	cw.flush()
+}
+
+// csvWriter emits result rows, writing the header before the first one.
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) write(r Result) {
+	if !c.header {
+		c.header = true
+		_ = c.w.Write(csvHeader) // errors surface in flush
+	}
+	top, count := topRule(r)
+	_ = c.w.Write([]string{
+		r.Path,
+		FormatScore(r.Score),
+		strconv.FormatBool(r.Smelly),
+		strconv.Itoa(len(r.Detail)),
+		top,
+		strconv.Itoa(count),
+	})
+}
+
+// flush writes any buffered rows, and the header when there were none.
+func (c *csvWriter) flush() {
+	if !c.header {
+		c.header = true
+		_ = c.w.Write(csvHeader)
+	}
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		slog.Error("csv write failed", "err", err)
+	}
+}
+
+// topRule returns the rule that added the most to r's score and its hit
+// count, preferring the lower name on ties. Rules that matched without
+// scoring still count when nothing scored.
+func topRule(r Result) (string, int) {
+	name, best, count := "", -1, 0
+	for n, h := range r.Detail {
+		contrib := h.Scored * h.Rule.Weight
+		if contrib > best || (contrib == best && n < name) {
+			name, best, count = n, contrib, h.Count
+		}
+	}
+	return name, count
+}
@@ -0,0 +1,59 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCSV(t *testing.T) {
+	list := []Result{
+		{
+			Path:   "docs/a, b.md",
+			Score:  33,
+			Smelly: true,
+			Detail: map[string]RuleHit{
+				"em-dash": {Rule: Rule{Name: "em-dash", Weight: 3}, Count: 11, Scored: 11},
+				"delve":   {Rule: Rule{Name: "delve", Weight: 10}, Count: 4, Scored: 1},
+				"tie":     {Rule: Rule{Name: "tie", Weight: 33}, Count: 1, Scored: 1},
+			},
+		},
+		{Path: "clean.md", Score: 0},
+	}
+
+	var buf bytes.Buffer
+	assert.True(t, Render(&buf, list, Config{Format: FormatCSV}).AnyErrors)
+	assert.Contains(t, buf.String(), `"docs/a, b.md"`, "paths with commas are quoted")
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	for _, row := range rows {
+		assert.Len(t, row, 6)
+	}
+	assert.Equal(t, []string{"path", "score", "smelly", "rules_fired", "top_rule", "top_rule_count"}, rows[0])
+	// em-dash and tie both add 33; the lower name wins
+	assert.Equal(t, []string{"docs/a, b.md", "33", "true", "3", "em-dash", "11"}, rows[1])
+	assert.Equal(t, []string{"clean.md", "0", "false", "0", "", "0"}, rows[2])
+}
+
+func TestRenderStreamCSV(t *testing.T) {
+	ch := make(chan Result, 1)
+	ch <- Result{Path: "a.md", Score: 1.5}
+	close(ch)
+
+	var buf bytes.Buffer
+	assert.Equal(t, RenderResult{}, RenderStream(&buf, ch, Config{Format: FormatCSV}))
+	assert.Equal(t, "path,score,smelly,rules_fired,top_rule,top_rule_count\na.md,1.5,false,0,,0\n", buf.String())
+
+	// No results still get a header
+	empty := make(chan Result)
+	close(empty)
+	buf.Reset()
+	RenderStream(&buf, empty, Config{Format: FormatCSV})
+	assert.Equal(t, "path,score,smelly,rules_fired,top_rule,top_rule_count\n", buf.String())
+}
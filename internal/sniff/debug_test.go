@@ -103,20 +103,20 @@ func TestDebugAnalyse(t *testing.T) {
 	}
 
 	// Step 6: Check if file is smelly
-	foundSmelly = totalScore >= cfg.Threshold
-	t.Logf("Final score: %d, threshold: %d, smelly: %v", totalScore, cfg.Threshold, foundSmelly)
+	foundSmelly = float64(totalScore) >= cfg.Threshold
+	t.Logf("Final score: %d, threshold: %v, smelly: %v", totalScore, cfg.Threshold, foundSmelly)
 
 	// Step 7: Verify result matches expectations
 	assert.True(t, foundSmelly, "File should be marked as smelly")
 	assert.Equal(t, 50, totalScore, "Score should be 50")
 
 	// Step 8: Compare with the actual analyse function
-	actualResult := analyse(testFile, rules, cfg)
-	t.Logf("Actual analyse result: smelly=%v, score=%d, details=%v",
+	actualResult := analyse(testFile, CompileRules(rules, ""), cfg)
+	t.Logf("Actual analyse result: smelly=%v, score=%v, details=%v",
 		actualResult.Smelly, actualResult.Score, actualResult.Detail)
 
 	assert.Equal(t, foundSmelly, actualResult.Smelly, "Manual and actual Smelly should match")
-	assert.Equal(t, totalScore, actualResult.Score, "Manual and actual Score should match")
+	assert.Equal(t, float64(totalScore), actualResult.Score, "Manual and actual Score should match")
 }
 
 // Helper to find minimum of two ints
@@ -0,0 +1,118 @@
+package sniff
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dedup tracks file contents across a scan's workers for
+// Config.DeduplicateContent. The first file with some content and
+// extension is scored; later ones become aliases of its result. Results
+// are held until the scan ends, when every alias is known and the
+// lexically smallest path of each content becomes the reported one,
+// whichever worker got there first.
+type dedup struct {
+	mu      sync.Mutex
+	seen    map[dedupKey]string // content and extension to the first path
+	aliases map[string][]string // first path to the later ones
+	held    []Result
+}
+
+// dedupKey tells copies apart by extension as well as content, since
+// rules scoped with Rule.Ext score the same text differently per
+// extension; copies sharing both get the same score under any of their
+// paths.
+type dedupKey struct {
+	sum [16]byte
+	ext string
+}
+
+// newDedup returns the scan's dedup state, or nil when it is off.
+func newDedup(cfg Config) *dedup {
+	if !cfg.DeduplicateContent {
+		return nil
+	}
+	return &dedup{seen: make(map[dedupKey]string), aliases: make(map[string][]string)}
+}
+
+// duplicate reports whether path holds content already seen in a file
+// with the same extension, recording it as an alias if so. Files that
+// cannot be read are left to analyse.
+func (d *dedup) duplicate(path string) bool {
+	sum, err := hashFile(path)
+	if err != nil {
+		return false
+	}
+	key := dedupKey{sum: sum, ext: filepath.Ext(path)}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	first, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = path
+		return false
+	}
+	d.aliases[first] = append(d.aliases[first], path)
+	return true
+}
+
+// hold keeps r until flush.
+func (d *dedup) hold(r Result) {
+	d.mu.Lock()
+	d.held = append(d.held, r)
+	d.mu.Unlock()
+}
+
+// flush passes each held result to send, under the smallest of its
+// paths with the others sorted as aliases. The copies share content, so
+// the result stands for any of them.
+func (d *dedup) flush(send func(Result)) {
+	d.mu.Lock()
+	held := d.held
+	d.held = nil
+	for i, r := range held {
+		if aliases := d.aliases[r.Path]; len(aliases) > 0 {
+			paths := append([]string{r.Path}, aliases...)
+			sort.Strings(paths)
+			held[i].Path, held[i].Aliases = paths[0], paths[1:]
+		}
+	}
+	d.mu.Unlock()
+	for _, r := range held {
+		send(r)
+	}
+}
+
+// hashFile returns the FNV-128a hash of the file's content.
+func hashFile(path string) ([16]byte, error) {
+	var sum [16]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.Warn("failed to close file", "path", path, "err", err)
+		}
+	}()
+	h := fnv.New128a()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
+// printAliases lists the paths skipped as copies of r, if any.
+func printAliases(w io.Writer, st textStyle, r Result) {
+	if len(r.Aliases) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %s\n", st.meta("same content: "+strings.Join(r.Aliases, ", ")))
+}
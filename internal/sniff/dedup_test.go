@@ -0,0 +1,104 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDeduplicateContent(t *testing.T) {
+	dir := t.TempDir()
+	orig := filepath.Join(dir, "a.md")
+	copied := filepath.Join(dir, "mirror", "a.md")
+	other := filepath.Join(dir, "b.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(copied), 0755))
+	require.NoError(t, os.WriteFile(orig, []byte("MARK MARK"), 0644))
+	require.NoError(t, os.WriteFile(copied, []byte("MARK MARK"), 0644))
+	require.NoError(t, os.WriteFile(other, []byte("MARK"), 0644))
+	cfg := Config{
+		Threshold:  1,
+		Workers:    1, // the walk order makes a.md the first copy
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	results, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	assert.Len(t, results, 3, "every copy is scored by default")
+
+	cfg.DeduplicateContent = true
+	results, err = Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, orig, results[0].Path)
+	assert.Equal(t, []string{copied}, results[0].Aliases)
+	assert.Equal(t, 20.0, results[0].Score)
+	assert.Equal(t, other, results[1].Path)
+	assert.Empty(t, results[1].Aliases)
+
+	var buf bytes.Buffer
+	Render(&buf, results, Config{Threshold: 1, Color: ColorNever})
+	assert.Contains(t, buf.String(), "same content: "+copied)
+}
+
+func TestScanDeduplicateContentWorkers(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md", "d.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("same"), 0644))
+	}
+
+	results, err := Scan(context.Background(), []string{dir}, Config{Workers: 4, DeduplicateContent: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1, "one result for the content")
+	assert.Equal(t, filepath.Join(dir, "a.md"), results[0].Path, "the smallest path, whichever worker saw it first")
+	assert.Equal(t, []string{filepath.Join(dir, "b.md"), filepath.Join(dir, "c.md"), filepath.Join(dir, "d.md")}, results[0].Aliases)
+}
+
+func TestDedupFlushSmallestPath(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "z.md"), filepath.Join(dir, "m.md"), filepath.Join(dir, "a.md")}
+	for _, p := range paths {
+		require.NoError(t, os.WriteFile(p, []byte("same"), 0644))
+	}
+
+	// z.md is hashed first, as a faster worker might
+	d := newDedup(Config{DeduplicateContent: true})
+	assert.False(t, d.duplicate(paths[0]))
+	assert.True(t, d.duplicate(paths[1]))
+	assert.True(t, d.duplicate(paths[2]))
+	d.hold(Result{Path: paths[0], Score: 3})
+
+	var got []Result
+	d.flush(func(r Result) { got = append(got, r) })
+	require.Len(t, got, 1)
+	assert.Equal(t, paths[2], got[0].Path)
+	assert.Equal(t, []string{paths[1], paths[0]}, got[0].Aliases)
+	assert.Equal(t, 3.0, got[0].Score)
+}
+
+func TestScanDeduplicateContentExt(t *testing.T) {
+	dir := t.TempDir()
+	md, py := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.py")
+	require.NoError(t, os.WriteFile(md, []byte("MARK"), 0644))
+	require.NoError(t, os.WriteFile(py, []byte("MARK"), 0644))
+
+	results, err := Scan(context.Background(), []string{dir}, Config{
+		Threshold:          1,
+		DeduplicateContent: true,
+		ExtraRules:         []Rule{{Name: "mark", Pattern: "MARK", Weight: 10, Ext: ".py"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2, "copies with other extensions are scored apart")
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	assert.Equal(t, md, results[0].Path)
+	assert.Empty(t, results[0].Aliases)
+	assert.Equal(t, py, results[1].Path)
+	assert.Empty(t, results[1].Aliases)
+	assert.Greater(t, results[1].Score, results[0].Score, "the .py rule scores b.py alone")
+}
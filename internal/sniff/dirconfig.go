@@ -0,0 +1,191 @@
+package sniff
+
+import (
+	"cmp"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RulesFileName is the rules file Config.AutoDiscoverRules looks for in
+// every directory the scan walks.
+const RulesFileName = ".synthsniff-rules.yaml"
+
+// dirConfig is what a directory's config file and rules file, and those
+// of its parents, change for the files below it. A nil *dirConfig means
+// the scan's own settings apply.
+type dirConfig struct {
+	threshold float64        // 0 = scan threshold
+	dicts     []string       // nil = scan dicts
+	found     []Rule         // from rules files here and above, nearest last
+	rules     []CompiledRule // nil = scan rules
+}
+
+// apply returns the rules and config a file under d is scored with.
+func (d *dirConfig) apply(rules []CompiledRule, cfg Config) ([]CompiledRule, Config) {
+	if d == nil {
+		return rules, cfg
+	}
+	if d.threshold > 0 {
+		cfg.Threshold = d.threshold
+	}
+	if d.rules != nil {
+		rules = d.rules
+	}
+	return rules, cfg
+}
+
+// isConfigFileName reports whether name is one of ConfigFileNames.
+func isConfigFileName(name string) bool {
+	return slices.Contains(ConfigFileNames, name)
+}
+
+// dictCache loads each distinct list of dicts once per scan, so
+// directories sharing the scan's dicts, or a parent's, do not read and
+// fetch them again. Only the walk goroutine uses it.
+type dictCache struct {
+	fetch dictFetcher
+	rules map[string][]Rule // key is the dict paths joined by NUL
+}
+
+// newDictCache returns an empty cache fetching remote dicts like cfg.
+func newDictCache(cfg Config) *dictCache {
+	return &dictCache{fetch: newDictFetcher(cfg), rules: make(map[string][]Rule)}
+}
+
+// load returns the rules of paths, as loadRules does.
+func (c *dictCache) load(paths []string) ([]Rule, error) {
+	key := strings.Join(paths, "\x00")
+	if rules, ok := c.rules[key]; ok {
+		return rules, nil
+	}
+	rules, err := loadRules(paths, c.fetch)
+	if err != nil {
+		return nil, err
+	}
+	c.rules[key] = rules
+	return rules, nil
+}
+
+// loadDirConfig returns the settings for dir: parent's, overridden by the
+// threshold and dict paths of a config file among entries and, with
+// base.AutoDiscoverRules, the rules of a RulesFileName. The scan's own
+// config file (base.ConfigFile) is skipped, as it already applies
+// everywhere. Broken files are reported and ignored. Dicts come from
+// dicts, so only the directory's own files are read each time.
+func loadDirConfig(dir string, entries []os.DirEntry, parent *dirConfig, base Config, dicts *dictCache) *dirConfig {
+	var file Config
+	path := ""
+	if !base.NoDirConfigs {
+		file, path = readDirConfigFile(dir, entries, base)
+	}
+	var found []Rule
+	if base.AutoDiscoverRules && hasFile(entries, RulesFileName) {
+		rulesPath := filepath.Join(dir, RulesFileName)
+		rules, err := loadDict(rulesPath)
+		if err != nil {
+			slog.Warn("skipping rules file", "path", rulesPath, "err", err)
+		}
+		found, path = rules, cmp.Or(path, rulesPath)
+	}
+	if file.Threshold <= 0 && len(file.DictPaths) == 0 && len(found) == 0 {
+		return parent
+	}
+
+	child := &dirConfig{}
+	if parent != nil {
+		*child = *parent
+	}
+	if file.Threshold > 0 {
+		child.threshold = file.Threshold
+	}
+	if len(file.DictPaths) == 0 && len(found) == 0 {
+		return child
+	}
+	if len(file.DictPaths) > 0 {
+		child.dicts = file.DictPaths
+	}
+	// A rule found here replaces one of the same name from above
+	child.found = replaceRules(child.found, found)
+
+	// The dicts replace the scan's own; config-file rules, rules files
+	// and disabled rules still apply on top, the nearest rules file last
+	c := base
+	if child.dicts != nil {
+		c.DictPaths = child.dicts
+	}
+	c.ExtraRules = replaceRules(base.ExtraRules, child.found)
+	loaded, err := dicts.load(c.DictPaths)
+	var rules []CompiledRule
+	if err == nil {
+		rules, err = buildScanRules(loaded, c)
+	}
+	if err != nil {
+		slog.Warn("skipping directory config", "path", path, "err", err)
+		return parent
+	}
+	child.rules = rules
+	return child
+}
+
+// readDirConfigFile loads the config file among dir's entries, and
+// returns its path; a zero Config when there is none, it is the scan's
+// own (base.ConfigFile) or it is broken.
+func readDirConfigFile(dir string, entries []os.DirEntry, base Config) (Config, string) {
+	path := ""
+	for _, name := range ConfigFileNames {
+		if hasFile(entries, name) {
+			path = filepath.Join(dir, name)
+			break
+		}
+	}
+	if path == "" || sameFile(path, base.ConfigFile) {
+		return Config{}, ""
+	}
+	file, err := LoadConfigFile(path)
+	if err != nil {
+		slog.Warn("skipping directory config", "err", err)
+		return Config{}, ""
+	}
+	return file, path
+}
+
+// hasFile reports whether entries hold a regular file called name.
+func hasFile(entries []os.DirEntry, name string) bool {
+	return slices.ContainsFunc(entries, func(e os.DirEntry) bool {
+		return e.Name() == name && e.Type().IsRegular()
+	})
+}
+
+// replaceRules returns rules followed by over, where a rule in over named
+// like one in rules (ignoring case) takes its place instead.
+func replaceRules(rules, over []Rule) []Rule {
+	if len(over) == 0 {
+		return rules
+	}
+	out := slices.Clone(rules)
+	for _, r := range over {
+		i := slices.IndexFunc(out, func(o Rule) bool { return strings.EqualFold(o.Name, r.Name) })
+		if i >= 0 {
+			out[i] = r
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	return err == nil && os.SameFile(ia, ib)
+}
@@ -0,0 +1,130 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirConfigTree builds a tree where every file scores 20:
+//
+//	root/a.txt
+//	root/api/.synthsniff.yaml   threshold: 50
+//	root/api/b.txt
+//	root/api/v2/c.txt           inherits 50
+//	root/api/v2/beta/.synthsniff.json  dict with a heavier rule
+//	root/api/v2/beta/d.txt
+func dirConfigTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	beta := filepath.Join(root, "api", "v2", "beta")
+	require.NoError(t, os.MkdirAll(beta, 0755))
+	for _, f := range []string{"a.txt", "api/b.txt", "api/v2/c.txt", "api/v2/beta/d.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, filepath.FromSlash(f)), []byte("MARK MARK"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api", ".synthsniff.yaml"), []byte("threshold: 50\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(beta, "heavy.yaml"), []byte("- {name: heavy, pattern: MARK, weight: 30}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(beta, ".synthsniff.json"), []byte(`{"dict": "heavy.yaml"}`), 0644))
+	return root
+}
+
+func scanByRel(t *testing.T, root string, cfg Config) map[string]Result {
+	t.Helper()
+	results, err := Scan(context.Background(), []string{root}, cfg)
+	require.NoError(t, err)
+	out := make(map[string]Result, len(results))
+	for _, r := range results {
+		rel, err := filepath.Rel(root, r.Path)
+		require.NoError(t, err)
+		out[filepath.ToSlash(rel)] = r
+	}
+	return out
+}
+
+func TestScanDirConfig(t *testing.T) {
+	root := dirConfigTree(t)
+	cfg := Config{Threshold: 15, ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}}
+
+	got := scanByRel(t, root, cfg)
+	require.Len(t, got, 4, "config files and their dicts are not scanned")
+	assert.True(t, got["a.txt"].Smelly, "the scan threshold applies at the top")
+	assert.False(t, got["api/b.txt"].Smelly, "a directory config raises the threshold")
+	assert.False(t, got["api/v2/c.txt"].Smelly, "subdirectories inherit it")
+
+	d := got["api/v2/beta/d.txt"]
+	assert.Equal(t, 80.0, d.Score, "a nested config adds its dict and keeps config-file rules")
+	assert.Contains(t, d.Detail, "heavy")
+	assert.True(t, d.Smelly, "the inherited threshold still applies")
+
+	cfg.NoDirConfigs = true
+	got = scanByRel(t, root, cfg)
+	assert.True(t, got["api/b.txt"].Smelly)
+	assert.NotContains(t, got["api/v2/beta/d.txt"].Detail, "heavy")
+}
+
+func TestScanDirConfigSkipsScanConfig(t *testing.T) {
+	root := dirConfigTree(t)
+	scanCfg, err := LoadConfigFile(filepath.Join(root, "api", ".synthsniff.yaml"))
+	require.NoError(t, err)
+
+	// Settings loaded from a file (and then overridden, e.g. by -t) are
+	// not re-applied when the walk meets that same file
+	scanCfg.Threshold = 15
+	scanCfg.ExtraRules = []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}
+	got := scanByRel(t, filepath.Join(root, "api"), scanCfg)
+	assert.True(t, got["b.txt"].Smelly)
+}
+
+// TestScanAutoDiscoverRules verifies a rules file applies to its own
+// directory and below only, and that a nested one overrides a rule of
+// the same name from above.
+func TestScanAutoDiscoverRules(t *testing.T) {
+	root := t.TempDir()
+	deeper := filepath.Join(root, "subdir", "deeper")
+	require.NoError(t, os.MkdirAll(deeper, 0755))
+	for _, f := range []string{"a.txt", "subdir/b.txt", "subdir/deeper/c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, filepath.FromSlash(f)), []byte("ACME ACME widget"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "subdir", RulesFileName),
+		[]byte("- {name: acme, pattern: ACME, weight: 5}\n- {name: widget, pattern: widget, weight: 1}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(deeper, RulesFileName),
+		[]byte("- {name: ACME, pattern: ACME, weight: 20}\n"), 0644))
+
+	cfg := Config{Threshold: 30, AutoDiscoverRules: true}
+	got := scanByRel(t, root, cfg)
+	require.Len(t, got, 3, "rules files are not scanned")
+	assert.Empty(t, got["a.txt"].Detail, "the rules stay in subdir")
+	assert.Equal(t, 11.0, got["subdir/b.txt"].Score)
+	assert.Equal(t, 41.0, got["subdir/deeper/c.txt"].Score, "the nearer acme wins, widget is inherited")
+	assert.True(t, got["subdir/deeper/c.txt"].Smelly)
+
+	cfg.AutoDiscoverRules = false
+	got = scanByRel(t, root, cfg)
+	assert.Empty(t, got["subdir/b.txt"].Detail)
+
+	cfg.AutoDiscoverRules, cfg.NoDirConfigs = true, true
+	got = scanByRel(t, root, cfg)
+	assert.Equal(t, 11.0, got["subdir/b.txt"].Score, "independent of directory config files")
+}
+
+func TestScanDirRulesLoadDictsOnce(t *testing.T) {
+	srv, hits := dictServer(t, "- {name: remote, pattern: ACME, weight: 1}\n", "", &atomic.Int32{})
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b", "b/c", "d"} {
+		p := filepath.Join(root, filepath.FromSlash(dir))
+		require.NoError(t, os.MkdirAll(p, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(p, "x.txt"), []byte("ACME widget"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(p, RulesFileName), []byte("- {name: widget, pattern: widget, weight: 2}\n"), 0644))
+	}
+
+	cfg := Config{Threshold: 30, AutoDiscoverRules: true, DictPaths: []string{srv.URL + "/rules.yaml"}}
+	got := scanByRel(t, root, cfg)
+	assert.Equal(t, 3.0, got["b/c/x.txt"].Score, "remote and discovered rules both apply")
+	assert.Equal(t, int32(2), hits.Load(), "fetched for the scan and once for all rules files, not per directory")
+}
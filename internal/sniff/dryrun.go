@@ -0,0 +1,52 @@
+package sniff
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// ListFiles walks roots like Scan, with the same ignore files, globs and
+// depth limit, and returns the files a scan would score, sorted, without
+// reading them. Files over cfg.MaxSize or under cfg.MinSize are left
+// out as well, since scoring skips them.
+func ListFiles(ctx context.Context, roots []string, cfg Config) ([]string, error) {
+	rules, err := loadScanRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ignoreRules, err := prepareWalk(roots, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan []scanJob, 4)
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		walkErr <- walkDirBreadthFirst(ctx, roots, []chan []scanJob{jobs}, scanWalkOptions(cfg, rules, ignoreRules))
+	}()
+
+	var files []string
+	for batch := range jobs {
+		for _, job := range batch {
+			if job.err != nil {
+				slog.Warn("skipping unreadable directory", "path", job.path, "err", job.err)
+				continue
+			}
+			oversize := cfg.MaxSize > 0 && !cfg.ChunkedScan
+			if (oversize || cfg.MinSize > 0) && job.path != StdinPath {
+				if info, err := os.Stat(job.path); err == nil && ((oversize && info.Size() > cfg.MaxSize) || cfg.tooSmall(info.Size())) {
+					continue
+				}
+			}
+			files = append(files, job.path)
+		}
+	}
+	if err := <-walkErr; err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,58 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.md":                   "text",
+		"b.txt":                  "text",
+		"big.md":                 strings.Repeat("x", 100),
+		"rules.yaml":             "- name: x\n  pattern: x\n",
+		"docs/c.md":              "text",
+		"docs/skip.md":           "text",
+		"docs/deep/d.md":         "text",
+		".git/config":            "text",
+		".gitignore":             "b.txt\n",
+		"docs/.synthsniffignore": "skip.md\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	list := func(cfg Config) []string {
+		t.Helper()
+		cfg.DictPaths = []string{filepath.Join(root, "rules.yaml")}
+		got, err := ListFiles(context.Background(), []string{root}, cfg)
+		require.NoError(t, err)
+		for i, p := range got {
+			rel, err := filepath.Rel(root, p)
+			require.NoError(t, err)
+			got[i] = filepath.ToSlash(rel)
+		}
+		return got
+	}
+
+	one := 1
+	assert.Equal(t, []string{".gitignore", "a.md", "b.txt", "big.md", "docs/c.md", "docs/deep/d.md"}, list(Config{}))
+	assert.Equal(t, []string{".gitignore", "a.md", "big.md", "docs/c.md", "docs/deep/d.md"}, list(Config{UseGitignore: true}))
+	assert.Equal(t, []string{"a.md", "big.md", "docs/c.md", "docs/deep/d.md"}, list(Config{IncludePatterns: []string{"*.md"}}))
+	assert.Equal(t, []string{".gitignore", "b.txt"}, list(Config{ExcludePatterns: []string{"*.md"}}))
+	assert.Equal(t, []string{".gitignore", "a.md", "b.txt", "big.md", "docs/c.md"}, list(Config{MaxDepth: &one}))
+	assert.Equal(t, []string{".gitignore", "a.md", "b.txt", "docs/c.md", "docs/deep/d.md"}, list(Config{MaxSize: 50}))
+
+	_, err := ListFiles(context.Background(), []string{filepath.Join(root, "missing")}, Config{})
+	assert.Error(t, err)
+}
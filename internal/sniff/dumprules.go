@@ -0,0 +1,86 @@
+package sniff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EffectiveRules returns the rules a scan with cfg would use: the base
+// rules and cfg.DictPaths, tuned by cfg.ExtraRules and
+// cfg.WeightOverrides, minus cfg.DisabledRules and the rules
+// cfg.OnlyTags and cfg.ExcludeTags leave out.
+func EffectiveRules(cfg Config) ([]Rule, error) {
+	compiled, err := loadScanRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return Rules(compiled), nil
+}
+
+// DumpRules writes rules to w as a "json" or "yaml" dictionary that -dict
+// loads back into the same rules. Word list paths are made absolute, and
+// gated groups are kept by writing the {rules, groups} object form.
+func DumpRules(rules []Rule, format string, w io.Writer) error {
+	out := make([]Rule, len(rules))
+	copy(out, rules)
+	groups := make(map[string]GroupConfig)
+	for i := range out {
+		if out[i].WordList != "" {
+			abs, err := filepath.Abs(out[i].WordList)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", out[i].Name, err)
+			}
+			out[i].WordList = abs
+		}
+		if out[i].minGroupScore > 0 {
+			groups[out[i].Group] = GroupConfig{MinGroupScore: out[i].minGroupScore}
+		}
+	}
+	var v any = out
+	if len(groups) > 0 {
+		v = dictFile{Rules: out, Groups: groups}
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml", "yml":
+		// JSON is YAML, and going through it keeps the key order and
+		// sidesteps yaml.v3 block scalars, which lose a leading newline
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+		blockStyle(&doc)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("invalid rule dump format %q (want json or yaml)", format)
+}
+
+// blockStyle turns a document parsed from JSON into block YAML, keeping
+// double quotes only on strings with line breaks.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.ContainsAny(n.Value, "\r\n") {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,56 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpRulesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	wordDict := writeWordListDict(t, dir, "delve\nrich tapestry\n")
+	groupDict := filepath.Join(dir, "groups.yaml")
+	require.NoError(t, os.WriteFile(groupDict, []byte(`rules:
+  - name: was-done
+    pattern: "was done"
+    weight: 5
+    group: passive-voice
+  - name: em-dash
+    pattern: "—"
+    weight: 7
+groups:
+  passive-voice:
+    minGroupScore: 15
+`), 0644))
+
+	want, err := EffectiveRules(Config{DictPaths: []string{wordDict, groupDict}})
+	require.NoError(t, err)
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, DumpRules(want, format, &buf))
+			dumped := filepath.Join(t.TempDir(), "rules."+format)
+			require.NoError(t, os.WriteFile(dumped, buf.Bytes(), 0644))
+
+			got, err := EffectiveRules(Config{DictPaths: []string{dumped}})
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestDumpRulesMultilinePattern(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, DumpRules(baseRules, "yaml", &buf))
+	assert.Contains(t, buf.String(), `pattern: "\n---\n"`, "leading newline kept")
+}
+
+func TestDumpRulesBadFormat(t *testing.T) {
+	assert.Error(t, DumpRules(baseRules, "toml", &bytes.Buffer{}))
+}
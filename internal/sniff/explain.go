@@ -0,0 +1,182 @@
+package sniff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExplainStep is one rule's part in an Explanation.
+type ExplainStep struct {
+	Rule    string `json:"rule"`
+	Matched bool   `json:"matched"`           // the rule added to the score
+	Count   int    `json:"count"`             // every occurrence, even when nothing scored
+	Score   int    `json:"score"`             // what the rule added to the raw score
+	Reason  string `json:"reason"`            // e.g. "0 matches" or "skipped, wrong extension"
+	Snippet string `json:"snippet,omitempty"` // context around the first match
+}
+
+// Explanation walks through how one file was scored, rule by rule.
+type Explanation struct {
+	Path    string        `json:"path"`
+	Skipped string        `json:"skipped,omitempty"` // why no rule ran, e.g. the file is binary
+	Steps   []ExplainStep `json:"steps"`
+	Result  Result        `json:"result"`
+}
+
+// Explain scores path like a scan would and records, for every rule in
+// order, whether it ran, how often it matched and what it added, with a
+// reason for each rule that added nothing.
+func Explain(path string, rules []Rule, cfg Config) (Explanation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Explanation{}, err
+	}
+	e := Explanation{Path: path, Result: Result{Path: path}}
+	switch {
+	case !cfg.forcesBinary(path) && bytes.IndexByte(data, 0) != -1:
+		e.Skipped = "the file is binary (it has NUL bytes)"
+		return e, nil
+	case cfg.MaxSize > 0 && int64(len(data)) > cfg.MaxSize:
+		e.Skipped = fmt.Sprintf("the file is larger than the %d byte limit", cfg.MaxSize)
+		return e, nil
+	case cfg.tooSmall(int64(len(data))):
+		e.Skipped = fmt.Sprintf("the file is smaller than the %d byte minimum", cfg.MinSize)
+		return e, nil
+	}
+
+	content := string(data)
+	cfg.Snippets = true
+	e.Result = scoreContent(content, path, CompileRules(rules, cfg.UnicodeNorm), cfg)
+
+	ext := filepath.Ext(path)
+	var mime, lang string
+	if cfg.DetectMIME {
+		mime = detectMIME(content)
+	}
+	if cfg.DetectLanguage {
+		lang = detectLanguage(path, content)
+	}
+	for _, r := range rules {
+		e.Steps = append(e.Steps, explainRule(r, content, ext, mime, lang, e.Result, cfg))
+	}
+	return e, nil
+}
+
+// explainRule reruns r alone, without its thresholds and group gate, to
+// tell which of them kept it from scoring.
+func explainRule(r Rule, content, ext, mime, lang string, res Result, cfg Config) ExplainStep {
+	step := ExplainStep{Rule: r.Name}
+	if !r.appliesToLanguage(lang) {
+		if !cfg.DetectLanguage {
+			step.Reason = "skipped, its language filter needs -detect-language"
+		} else {
+			step.Reason = fmt.Sprintf("skipped, content is %s, not %s", languageName(lang), r.Language)
+		}
+		return step
+	}
+	if !r.appliesTo(ext, mime) {
+		switch {
+		case r.MIME != "" && mime == "":
+			step.Reason = "skipped, its mime filter needs -detect-mime"
+		case r.MIME != "":
+			step.Reason = fmt.Sprintf("skipped, content is %s, not %s", mime, r.MIME)
+		default:
+			step.Reason = fmt.Sprintf("skipped, wrong extension (wants %s)", strings.Join(ruleExts(r), ", "))
+		}
+		return step
+	}
+	if !r.appliesToSize(int64(len(content))) {
+		step.Reason = fmt.Sprintf("skipped, the file is larger than its maxFileSize %d", r.MaxFileSize)
+		return step
+	}
+
+	loose := r
+	loose.MinCount, loose.MinPercent, loose.minGroupScore = 0, 0, 0
+	hit, ok := scoreContent(content, res.Path, CompileRules([]Rule{loose}, cfg.UnicodeNorm), cfg).Detail[r.Name]
+	if !ok {
+		step.Reason = "0 matches"
+		return step
+	}
+	step.Count = hit.Count
+	if len(hit.Snippets) > 0 {
+		step.Snippet = hit.Snippets[0]
+	}
+	matches := plural(hit.Count, "match", "matches")
+	_, kept := res.Detail[r.Name]
+
+	switch {
+	case r.MinCount > 0 && hit.Count < r.MinCount:
+		step.Reason = fmt.Sprintf("%s, below minCount %d", matches, r.MinCount)
+	case !r.passesThresholds(hit.Count, len(content)):
+		step.Reason = fmt.Sprintf("%s, below minPercent %v", matches, r.MinPercent)
+	case hit.Excluded:
+		step.Reason = fmt.Sprintf("%s, but the file contains an exclude pattern", matches)
+	case !kept:
+		step.Reason = fmt.Sprintf("%s, but group %s stayed under minGroupScore %d", matches, r.Group, r.minGroupScore)
+	default:
+		step.Matched = true
+		step.Score = hit.Scored * r.Weight
+		if hit.Scored != hit.Count {
+			step.Reason = fmt.Sprintf("%s, %d scored × weight %d = +%d", matches, hit.Scored, r.Weight, step.Score)
+		} else {
+			step.Reason = fmt.Sprintf("%s × weight %d = +%d", matches, r.Weight, step.Score)
+		}
+	}
+	return step
+}
+
+// ruleExts lists the extensions a rule is limited to.
+func ruleExts(r Rule) []string {
+	if r.Ext != "" {
+		return append([]string{r.Ext}, r.Exts...)
+	}
+	return r.Exts
+}
+
+// plural formats n with the singular or plural noun.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// RenderExplanation prints e as prose: one line per rule, the first
+// match under each rule that found one, then the verdict.
+func RenderExplanation(w io.Writer, e Explanation, cfg Config) {
+	fmt.Fprintf(w, "Explaining %s\n", e.Path)
+	if e.Skipped != "" {
+		fmt.Fprintf(w, "No rules ran: %s.\n", e.Skipped)
+		return
+	}
+	fmt.Fprintf(w, "%s evaluated:\n", plural(len(e.Steps), "rule", "rules"))
+	for _, s := range e.Steps {
+		mark := "-"
+		if s.Matched {
+			mark = "+"
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", mark, s.Rule, s.Reason)
+		if s.Snippet != "" {
+			fmt.Fprintf(w, "      first match: %s\n", s.Snippet)
+		}
+	}
+
+	r := e.Result
+	score := FormatScore(r.Score)
+	if cfg.Normalize {
+		score += " per KB"
+	}
+	switch {
+	case r.Smelly:
+		fmt.Fprintf(w, "Verdict: smelly, score %s reaches the threshold %s.\n", score, FormatScore(cfg.Threshold))
+	case r.Warning:
+		fmt.Fprintf(w, "Verdict: warning, score %s reaches the warn threshold %s but not the threshold %s.\n",
+			score, FormatScore(cfg.WarnThreshold), FormatScore(cfg.Threshold))
+	default:
+		fmt.Fprintf(w, "Verdict: clean, score %s is below the threshold %s.\n", score, FormatScore(cfg.Threshold))
+	}
+}
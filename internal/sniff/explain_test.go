@@ -0,0 +1,62 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a — b — c, delve. <!-- human --> was done"), 0644))
+	rules := []Rule{
+		{Name: "em-dash", Pattern: "—", Weight: 3},
+		{Name: "hrule", Pattern: "---", Weight: 30, Ext: ".md"},
+		{Name: "en-dash", Pattern: "–", Weight: 10},
+		{Name: "rare", Pattern: "delve", Weight: 5, MinCount: 2},
+		{Name: "human", Pattern: "b", Weight: 5, Exclude: "<!-- human -->"},
+		{Name: "passive", Pattern: "was done", Weight: 5, Group: "passive", minGroupScore: 10},
+	}
+
+	e, err := Explain(path, rules, Config{Threshold: 5})
+	require.NoError(t, err)
+	require.Len(t, e.Steps, len(rules))
+	assert.Equal(t, ExplainStep{Rule: "em-dash", Matched: true, Count: 2, Score: 6,
+		Reason: "2 matches × weight 3 = +6", Snippet: "a >>>—<<< b — c, delve. <!-- human --> was done"}, e.Steps[0])
+	assert.Equal(t, "skipped, wrong extension (wants .md)", e.Steps[1].Reason)
+	assert.Equal(t, "0 matches", e.Steps[2].Reason)
+	assert.Equal(t, "1 match, below minCount 2", e.Steps[3].Reason)
+	assert.Equal(t, "1 match, but the file contains an exclude pattern", e.Steps[4].Reason)
+	assert.Equal(t, "1 match, but group passive stayed under minGroupScore 10", e.Steps[5].Reason)
+	for _, s := range e.Steps[1:] {
+		assert.False(t, s.Matched, s.Rule)
+	}
+	assert.Equal(t, 6, e.Result.RawScore)
+	assert.True(t, e.Result.Smelly)
+
+	var buf bytes.Buffer
+	RenderExplanation(&buf, e, Config{Threshold: 5})
+	out := buf.String()
+	assert.Contains(t, out, "6 rules evaluated:\n")
+	assert.Contains(t, out, "  + em-dash: 2 matches × weight 3 = +6\n      first match: a >>>—<<< b")
+	assert.Contains(t, out, "  - en-dash: 0 matches\n")
+	assert.Contains(t, out, "Verdict: smelly, score 6 reaches the threshold 5.\n")
+}
+
+func TestExplainSkipped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob.bin")
+	require.NoError(t, os.WriteFile(path, []byte("a\x00—"), 0644))
+
+	e, err := Explain(path, baseRules, Config{Threshold: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "the file is binary (it has NUL bytes)", e.Skipped)
+	assert.Empty(t, e.Steps)
+
+	_, err = Explain(filepath.Join(t.TempDir(), "missing"), baseRules, Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package sniff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldCase lowercases s for case-insensitive matching. Runes whose lower
+// case has a different UTF-8 length are left alone, so every byte offset
+// in the result is valid in s as well.
+func foldCase(s string) string {
+	// ASCII-only content, the common case, needs no rune decoding
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return strings.ToLower(s)
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if l := unicode.ToLower(r); r != utf8.RuneError && utf8.RuneLen(l) == size {
+			b.WriteRune(l)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// mapPatterns returns a copy of r with f applied to every pattern.
+func (r Rule) mapPatterns(f func(string) string) Rule {
+	r.Pattern = f(r.Pattern)
+	if r.Patterns != nil {
+		patterns := make([]string, len(r.Patterns))
+		for i, p := range r.Patterns {
+			patterns[i] = f(p)
+		}
+		r.Patterns = patterns
+	}
+	if r.Exclude != "" {
+		r.Exclude = f(r.Exclude)
+	}
+	if r.Excludes != nil {
+		excludes := make([]string, len(r.Excludes))
+		for i, p := range r.Excludes {
+			excludes[i] = f(p)
+		}
+		r.Excludes = excludes
+	}
+	if r.Proximity != nil {
+		p := *r.Proximity
+		p.PatternA, p.PatternB = f(p.PatternA), f(p.PatternB)
+		r.Proximity = &p
+	}
+	if r.words != nil {
+		// "Delve" and "delve" fold to one word and must count once
+		words := make([]string, 0, len(r.words))
+		seen := make(map[string]bool, len(r.words))
+		for _, w := range r.words {
+			if w = f(w); !seen[w] {
+				seen[w] = true
+				words = append(words, w)
+			}
+		}
+		r.words = words
+	}
+	return r
+}
+
+// matchForm returns r with its patterns in the form content is matched
+// in: normalized to form, then case-folded for case-insensitive rules.
+func (r Rule) matchForm(form string) Rule {
+	return r.mapPatterns(func(p string) string {
+		p = normalizeText(p, form)
+		if r.CaseInsensitive {
+			p = foldCase(p)
+		}
+		return p
+	})
+}
@@ -0,0 +1,54 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldCase(t *testing.T) {
+	assert.Equal(t, "as an ai model", foldCase("As An AI Model"))
+	assert.Equal(t, "éclair über straße", foldCase("Éclair ÜBER Straße"))
+
+	// Runes whose lower case changes length stay as-is to keep offsets
+	for _, s := range []string{"İstanbul", "K", "bad\xffbyte"} {
+		assert.Len(t, foldCase(s), len(s), s)
+	}
+}
+
+func TestAnalyseCaseInsensitive(t *testing.T) {
+	rules := []Rule{
+		{Name: "as-an-ai", Pattern: "as an AI", Weight: 10, CaseInsensitive: true},
+		{Name: "exact", Pattern: "Delve", Weight: 1},
+	}
+	content := "As an AI, I think.\nAS AN AI again; as an ai. Delve, delve, DELVE."
+
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 1, CollectLines: true, Snippets: true, SnippetWidth: 4})
+	assert.Equal(t, 3, r.Detail["as-an-ai"].Count, "mixed-case occurrences all count")
+	assert.Equal(t, []int{1, 2, 2}, r.Detail["as-an-ai"].Lines)
+	assert.Equal(t, ">>>As an AI<<<, ", r.Detail["as-an-ai"].Snippets[0], "snippets show the original case")
+	assert.Equal(t, 1, r.Detail["exact"].Count, "case-sensitive rules are unaffected")
+	assert.Equal(t, 31, r.RawScore)
+}
+
+func TestAnalyseCaseInsensitiveWordListAndProximity(t *testing.T) {
+	rules := []Rule{
+		{Name: "words", Weight: 1, WordList: "w.txt", words: []string{"Delve", "delve", "Tapestry"}, CaseInsensitive: true},
+		{
+			Name: "essay", Weight: 5, CaseInsensitive: true,
+			Proximity: &Proximity{PatternA: "in conclusion", PatternB: "FURTHERMORE", MaxDistance: 20},
+		},
+	}
+	r := AnalyseString("DELVE into the TAPESTRY. Furthermore, In Conclusion.", "a.txt", rules, Config{Threshold: 1})
+	assert.Equal(t, 2, r.Detail["words"].Count, "words that fold together count once")
+	assert.Equal(t, 1, r.Detail["essay"].Count)
+	assert.Equal(t, 7, r.RawScore)
+}
+
+func TestCompileRulesCaseInsensitive(t *testing.T) {
+	a := []Rule{{Name: "x", Pattern: "ai", Weight: 1}}
+	b := []Rule{{Name: "x", Pattern: "ai", Weight: 1, CaseInsensitive: true}}
+	assert.Empty(t, AnalyseString("AI", "a.txt", a, Config{}).Detail)
+	assert.Equal(t, 1, AnalyseString("AI", "a.txt", b, Config{}).Detail["x"].Count)
+}
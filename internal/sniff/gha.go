@@ -0,0 +1,67 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// renderGHA writes GitHub Actions workflow commands, which the runner
+// turns into annotations on the pull request: ::error for smelly files,
+// ::warning for the warning band and, with -vv, ::notice for clean ones.
+func renderGHA(w io.Writer, list []Result, cfg Config) {
+	for _, r := range list {
+		printGHA(w, r, cfg)
+	}
+}
+
+// printGHA writes the workflow command for one result, if any.
+func printGHA(w io.Writer, r Result, cfg Config) {
+	file := ghaProperty(displayPath(r.Path))
+	score := FormatScore(r.Score)
+	msg := "score=" + score
+	if rules := ghaRules(r); rules != "" {
+		msg += " rules: " + rules
+	}
+	switch {
+	case r.Smelly:
+		fmt.Fprintf(w, "::error file=%s,line=%d::%s\n", file, firstLine(r), ghaData(msg))
+	case r.Warning:
+		fmt.Fprintf(w, "::warning file=%s,line=%d::%s\n", file, firstLine(r), ghaData(msg))
+	case cfg.VeryVerbose || cfg.UltraVerbose:
+		fmt.Fprintf(w, "::notice file=%s::%s\n", file, ghaData("clean (score="+score+")"))
+	}
+}
+
+// ghaRules lists the rules that matched as "name×count", sorted by name.
+func ghaRules(r Result) string {
+	names := hitNames(r)
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = fmt.Sprintf("%s×%d", n, r.Detail[n].Count)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// firstLine returns the earliest line any rule matched on, or 1.
+func firstLine(r Result) int {
+	line := 0
+	for _, h := range r.Detail {
+		for _, l := range h.Lines {
+			if line == 0 || l < line {
+				line = l
+			}
+		}
+	}
+	if line == 0 {
+		return 1
+	}
+	return line
+}
+
+// ghaData escapes a workflow command message.
+var ghaData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace
+
+// ghaProperty escapes a workflow command property value, which also
+// must not contain the ":" and "," separators.
+var ghaProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace
@@ -0,0 +1,49 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderGHA(t *testing.T) {
+	list := []Result{
+		{
+			Path:   "docs/a.md",
+			Score:  42,
+			Smelly: true,
+			Detail: map[string]RuleHit{
+				"em-dash": {Count: 4, Lines: []int{7, 9}},
+				"delve":   {Count: 3, Lines: []int{3}},
+			},
+		},
+		{Path: "b,c.md", Score: 20, Warning: true, Detail: map[string]RuleHit{"delve": {Count: 2}}},
+		{Path: "clean.md", Score: 1.5},
+	}
+
+	var buf bytes.Buffer
+	assert.True(t, Render(&buf, list, Config{Format: FormatGHA}).AnyErrors)
+	assert.Equal(t, "::error file=docs/a.md,line=3::score=42 rules: delve×3, em-dash×4\n"+
+		"::warning file=b%2Cc.md,line=1::score=20 rules: delve×2\n", buf.String())
+
+	buf.Reset()
+	Render(&buf, list[2:], Config{Format: FormatGHA, VeryVerbose: true})
+	assert.Equal(t, "::notice file=clean.md::clean (score=1.5)\n", buf.String())
+}
+
+func TestRenderStreamGHA(t *testing.T) {
+	ch := make(chan Result, 1)
+	ch <- Result{Path: StdinPath, Score: 30, Smelly: true}
+	close(ch)
+
+	var buf bytes.Buffer
+	assert.True(t, RenderStream(&buf, ch, Config{Format: FormatGHA}).AnyErrors)
+	assert.Equal(t, "::error file=<stdin>,line=1::score=30\n", buf.String())
+}
+
+func TestGHAEscape(t *testing.T) {
+	assert.Equal(t, "50%25 done%0Anext", ghaData("50% done\nnext"))
+	assert.Equal(t, "C%3A\\a%2Cb", ghaProperty("C:\\a,b"))
+}
@@ -0,0 +1,176 @@
+package sniff
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// diffHunk holds the lines one hunk adds to a file.
+type diffHunk struct {
+	Path  string // new-side path, relative to the repository root
+	Start int    // first added line in the new file (1-based)
+	End   int    // last added line in the new file
+	Added string // added lines, newline-terminated
+}
+
+// ScanGitDiff scores only the lines added by `git diff --unified=0 base`
+// in the current working tree. Each hunk becomes one result whose path
+// carries the new-file line range, e.g. "main.go:42-67". Non-empty paths
+// are passed to git as a pathspec.
+func ScanGitDiff(ctx context.Context, base string, paths []string, cfg Config) ([]Result, error) {
+	s, err := NewScanner(WithConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.ScanGitDiff(ctx, base, paths)
+}
+
+// ScanGitDiff is the package ScanGitDiff with the scanner's rules.
+func (s *Scanner) ScanGitDiff(ctx context.Context, base string, paths []string) ([]Result, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	rules, cfg := s.rules, s.cfg
+	allow, err := loadAllowlist(cfg.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		base = DefaultGitBase
+	}
+
+	args := []string{"diff", "--unified=0", "--no-color", "--no-ext-diff", base}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	out, err := gitOutput(ctx, "", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDiff(string(out), rules, allow, cfg), nil
+}
+
+// scanDiff scores each hunk of a unified diff on its own.
+func scanDiff(diff string, rules []CompiledRule, allow allowlist, cfg Config) []Result {
+	hunks := parseUnifiedDiff(diff)
+	results := make([]Result, 0, len(hunks))
+	for _, h := range hunks {
+		// Analyse under the real name so extension filters still apply
+		r := allow.apply(analyseString(h.Added, h.Path, rules, cfg), h.Path)
+		r.Path = fmt.Sprintf("%s:%d-%d", h.Path, h.Start, h.End)
+		results = append(results, r)
+	}
+	return results
+}
+
+// parseUnifiedDiff extracts added lines per hunk. Deleted files and
+// hunks that only remove lines are dropped.
+func parseUnifiedDiff(diff string) []diffHunk {
+	var (
+		hunks   []diffHunk
+		path    string
+		cur     diffHunk
+		added   strings.Builder
+		oldLeft int // hunk body lines still expected on each side
+		newLeft int
+	)
+
+	sc := bufio.NewScanner(strings.NewReader(diff))
+	sc.Buffer(make([]byte, 64*1024), 16<<20) // long minified lines
+	for sc.Scan() {
+		line := sc.Text()
+
+		// Inside a hunk body the counts decide, so an added line that
+		// itself starts with "++ " is never mistaken for a header
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+				added.WriteString(line[1:])
+				added.WriteByte('\n')
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, " "):
+				oldLeft--
+				newLeft--
+			}
+			if oldLeft <= 0 && newLeft <= 0 && added.Len() > 0 {
+				cur.Added = added.String()
+				hunks = append(hunks, cur)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path = ""
+		case strings.HasPrefix(line, "+++ "):
+			path = diffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			oldCount, start, count, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			oldLeft, newLeft = oldCount, count
+			added.Reset()
+			cur = diffHunk{Path: path, Start: start, End: start + count - 1}
+			if path == "" {
+				// Deleted file: consume the body without recording it
+				newLeft = 0
+			}
+		}
+	}
+	return hunks
+}
+
+// diffPath turns a "+++" header value into a repository path, or "" for
+// /dev/null. Git quotes paths with unusual characters.
+func diffPath(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			s = u
+		}
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, "b/")
+}
+
+// parseHunkHeader reads "@@ -a,b +c,d @@" and returns b, c and d.
+// A missing count means one line.
+func parseHunkHeader(line string) (oldCount, start, count int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, false
+	}
+	_, oldCount, ok = parseRange(fields[1][1:])
+	if !ok {
+		return 0, 0, 0, false
+	}
+	start, count, ok = parseRange(fields[2][1:])
+	return oldCount, start, count, ok
+}
+
+// parseRange parses "start,count" or "start".
+func parseRange(spec string) (start, count int, ok bool) {
+	count = 1
+	if i := strings.IndexByte(spec, ','); i >= 0 {
+		n, err := strconv.Atoi(spec[i+1:])
+		if err != nil {
+			return 0, 0, false
+		}
+		count = n
+		spec = spec[:i]
+	}
+	start, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, count, true
+}
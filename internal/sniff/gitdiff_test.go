@@ -0,0 +1,101 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,0 +11,2 @@ func main() {
++	// MARK one
++	// MARK two
+@@ -20,3 +22 @@ func helper() {
+-	old()
+-	older()
+-	oldest()
++++ looks like a header but is content MARK
+@@ -30,2 +30,0 @@ func tail() {
+-	gone()
+-	gone()
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1,3 @@
++# Title
++MARK
++
+\ No newline at end of file
+diff --git a/removed.md b/removed.md
+deleted file mode 100644
+index 4444444..0000000
+--- a/removed.md
++++ /dev/null
+@@ -1,2 +0,0 @@
+-MARK
+-MARK
+diff --git "a/with space.txt" "b/with space.txt"
+--- "a/with space.txt"
++++ "b/with space.txt"
+@@ -1 +1 @@
+-plain
++MARK
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	hunks := parseUnifiedDiff(fakeDiff)
+	require.Len(t, hunks, 4)
+
+	assert.Equal(t, diffHunk{Path: "main.go", Start: 11, End: 12, Added: "\t// MARK one\n\t// MARK two\n"}, hunks[0])
+	assert.Equal(t, diffHunk{Path: "main.go", Start: 22, End: 22, Added: "++ looks like a header but is content MARK\n"}, hunks[1])
+	assert.Equal(t, diffHunk{Path: "docs/new.md", Start: 1, End: 3, Added: "# Title\nMARK\n\n"}, hunks[2])
+	assert.Equal(t, diffHunk{Path: "with space.txt", Start: 1, End: 1, Added: "MARK\n"}, hunks[3])
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	tests := []struct {
+		line                   string
+		oldCount, start, count int
+		ok                     bool
+	}{
+		{"@@ -10,0 +11,2 @@ func main() {", 0, 11, 2, true},
+		{"@@ -1 +1 @@", 1, 1, 1, true},
+		{"@@ -5,3 +4,0 @@", 3, 4, 0, true},
+		{"@@ -x +1 @@", 0, 0, 0, false},
+		{"@@ garbage", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		oldCount, start, count, ok := parseHunkHeader(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.oldCount, oldCount, tt.line)
+		assert.Equal(t, tt.start, start, tt.line)
+		assert.Equal(t, tt.count, count, tt.line)
+	}
+}
+
+func TestScanDiff(t *testing.T) {
+	rules := []Rule{
+		{Name: "mark", Pattern: "MARK", Weight: 10},
+		{Name: "md-title", Pattern: "# Title", Weight: 7, Ext: ".md"},
+	}
+	results := scanDiff(fakeDiff, CompileRules(rules, ""), nil, Config{Threshold: 15})
+	require.Len(t, results, 4)
+
+	byPath := make(map[string]Result, len(results))
+	for _, r := range results {
+		byPath[r.Path] = r
+	}
+	assert.Equal(t, 20.0, byPath["main.go:11-12"].Score)
+	assert.True(t, byPath["main.go:11-12"].Smelly)
+	assert.Equal(t, 10.0, byPath["main.go:22-22"].Score)
+	// The extension filter sees the real file name, not the hunk range
+	assert.Equal(t, 17.0, byPath["docs/new.md:1-3"].Score)
+	assert.Equal(t, 10.0, byPath["with space.txt:1-1"].Score)
+}
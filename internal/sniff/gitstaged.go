@@ -0,0 +1,129 @@
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScanGitStaged scores the files staged for commit in the current
+// repository (added, copied, modified or renamed), reading each from the
+// index, so the result matches what will be committed whatever the
+// working tree holds. Paths are relative to the repository root.
+func ScanGitStaged(ctx context.Context, cfg Config) ([]Result, error) {
+	s, err := NewScanner(WithConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.ScanGitStaged(ctx)
+}
+
+// ScanGitUnstaged is the complement of ScanGitStaged: it scores, from the
+// working tree, the files with changes not staged yet and the untracked
+// files that are not ignored.
+func ScanGitUnstaged(ctx context.Context, cfg Config) ([]Result, error) {
+	s, err := NewScanner(WithConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.ScanGitUnstaged(ctx)
+}
+
+// ScanGitStaged is the package ScanGitStaged with the scanner's rules.
+func (s *Scanner) ScanGitStaged(ctx context.Context) ([]Result, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	return scanGitFiles(ctx, s.rules, s.cfg, true)
+}
+
+// ScanGitUnstaged is the package ScanGitUnstaged with the scanner's rules.
+func (s *Scanner) ScanGitUnstaged(ctx context.Context) ([]Result, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	return scanGitFiles(ctx, s.rules, s.cfg, false)
+}
+
+// scanGitFiles lists the staged or unstaged files and scores them in
+// path order. The include and exclude globs apply as in a walk.
+func scanGitFiles(ctx context.Context, rules []CompiledRule, cfg Config, staged bool) ([]Result, error) {
+	allow, err := loadAllowlist(cfg.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	top, err := gitOutput(ctx, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(top))
+
+	var lists [][]string
+	if staged {
+		lists = [][]string{{"diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z"}}
+	} else {
+		lists = [][]string{
+			{"diff", "--name-only", "--diff-filter=ACMR", "-z"},
+			{"ls-files", "--others", "--exclude-standard", "-z"},
+		}
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, args := range lists {
+		out, err := gitOutput(ctx, root, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range strings.Split(string(out), "\x00") {
+			if p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	skip := ruleFiles(cfg, rules)
+	var results []Result
+	for _, p := range paths {
+		abs := filepath.Join(root, filepath.FromSlash(p))
+		if isRuleFile(abs, skip) || matchesAny(p, cfg.ExcludePatterns) || !matchesName(p, cfg.NamePatterns) ||
+			(len(cfg.IncludePatterns) > 0 && !matchesAny(p, cfg.IncludePatterns)) {
+			continue
+		}
+		var data []byte
+		if staged {
+			data, err = gitOutput(ctx, root, "show", ":"+p)
+		} else {
+			data, err = os.ReadFile(abs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := allow.apply(AnalyseCompiled(data, p, rules, cfg), p)
+		r.Path = p
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// gitOutput runs git in dir ("" for the working directory) and returns
+// its standard output, or an error quoting its standard error.
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
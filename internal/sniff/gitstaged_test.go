@@ -0,0 +1,83 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initGitRepo creates a repository in a temporary directory, makes it
+// the working directory and returns a helper that runs git in it.
+func initGitRepo(t *testing.T) (dir string, git func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir = t.TempDir()
+	git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	t.Chdir(dir)
+	return dir, git
+}
+
+func TestScanGitStaged(t *testing.T) {
+	dir, git := initGitRepo(t)
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	cfg := Config{Threshold: 10, ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}}
+
+	write("staged.md", "MARK")
+	write("docs/clean.md", "plain")
+	write("skip.txt", "MARK")
+	git("add", "staged.md", "docs/clean.md", "skip.txt")
+	// Working tree edits after staging do not count
+	write("staged.md", "plain now")
+	write("docs/clean.md", "MARK MARK")
+	write("untracked.md", "MARK")
+
+	cfg.ExcludePatterns = []string{"*.txt"}
+	results, err := ScanGitStaged(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs/clean.md", "staged.md"}, resultPaths(results))
+	assert.False(t, results[0].Smelly, "scored from the index")
+	assert.True(t, results[1].Smelly, "scored from the index")
+
+	results, err = ScanGitUnstaged(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs/clean.md", "staged.md", "untracked.md"}, resultPaths(results))
+	assert.Equal(t, 20.0, results[0].Score, "scored from the working tree")
+	assert.False(t, results[1].Smelly)
+	assert.True(t, results[2].Smelly)
+
+	// Nothing staged once the index matches the working tree
+	git("add", "-A")
+	results, err = ScanGitUnstaged(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestScanGitStagedOutsideRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(t.TempDir()))
+	_, err := ScanGitStaged(context.Background(), Config{})
+	assert.ErrorContains(t, err, "git rev-parse")
+}
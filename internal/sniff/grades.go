@@ -0,0 +1,70 @@
+package sniff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gradeBound is the lowest score that earns a grade.
+type gradeBound struct {
+	grade string
+	min   float64
+}
+
+// gradeBounds returns the grade boundaries in ascending order: those of
+// GradeBoundaries, else A to F in quarters of the threshold with F from
+// the threshold up.
+func (c Config) gradeBounds() []gradeBound {
+	if len(c.GradeBoundaries) == 0 {
+		t := c.Threshold
+		return []gradeBound{{"A", 0}, {"B", t * 0.25}, {"C", t * 0.5}, {"D", t * 0.75}, {"F", t}}
+	}
+	bounds := make([]gradeBound, 0, len(c.GradeBoundaries))
+	for g, min := range c.GradeBoundaries {
+		bounds = append(bounds, gradeBound{g, float64(min)})
+	}
+	sort.Slice(bounds, func(i, j int) bool {
+		if bounds[i].min != bounds[j].min {
+			return bounds[i].min < bounds[j].min
+		}
+		return bounds[i].grade < bounds[j].grade
+	})
+	return bounds
+}
+
+// grade returns the letter grade for score with Grades on, else "". A
+// score below every boundary gets the lowest one's grade.
+func (c Config) grade(score float64) string {
+	if !c.Grades {
+		return ""
+	}
+	bounds := c.gradeBounds()
+	g := bounds[0].grade
+	for _, b := range bounds[1:] {
+		if score < b.min {
+			break
+		}
+		g = b.grade
+	}
+	return g
+}
+
+// ParseGradeThresholds reads -grade-thresholds, e.g. "A:0,B:10,C:20",
+// each grade with the lowest score that earns it.
+func ParseGradeThresholds(s string) (map[string]int, error) {
+	bounds := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		g, n, ok := strings.Cut(strings.TrimSpace(part), ":")
+		min, err := strconv.Atoi(n)
+		if !ok || g == "" || err != nil || min < 0 {
+			return nil, fmt.Errorf("invalid grade threshold %q (want GRADE:MIN, e.g. B:10)", part)
+		}
+		if _, dup := bounds[g]; dup {
+			return nil, fmt.Errorf("grade %q given twice", g)
+		}
+		bounds[g] = min
+	}
+	return bounds, nil
+}
@@ -0,0 +1,70 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGradeDefaultBoundaries(t *testing.T) {
+	cfg := Config{Threshold: 40, Grades: true}
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "A"},
+		{9.99, "A"},
+		{10, "B"},
+		{19, "B"},
+		{20, "C"},
+		{29, "C"},
+		{30, "D"},
+		{39.5, "D"},
+		{40, "F"},
+		{400, "F"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, cfg.grade(tt.score), "score %v", tt.score)
+	}
+
+	assert.Empty(t, Config{Threshold: 40}.grade(50), "off without Grades")
+}
+
+func TestGradeCustomBoundaries(t *testing.T) {
+	bounds, err := ParseGradeThresholds("A:0,B:10,C:20,D:30,F:40")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"A": 0, "B": 10, "C": 20, "D": 30, "F": 40}, bounds)
+
+	cfg := Config{Threshold: 100, Grades: true, GradeBoundaries: bounds}
+	for score, want := range map[float64]string{0: "A", 9: "A", 10: "B", 20: "C", 29: "C", 30: "D", 40: "F", 99: "F"} {
+		assert.Equal(t, want, cfg.grade(score), "score %v", score)
+	}
+
+	// A score under the lowest boundary gets its grade
+	cfg.GradeBoundaries = map[string]int{"good": 5, "bad": 50}
+	assert.Equal(t, "good", cfg.grade(1))
+	assert.Equal(t, "bad", cfg.grade(50))
+
+	for _, bad := range []string{"", "A", "A:x", "A:-1", ":3", "A:1,A:2"} {
+		_, err := ParseGradeThresholds(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestGradeOutput(t *testing.T) {
+	cfg := Config{Threshold: 10, Grades: true, Color: ColorNever}
+	r := AnalyseString("MARK MARK", "file.md", []Rule{{Name: "mark", Pattern: "MARK", Weight: 6}}, cfg)
+	assert.Equal(t, "F", r.Grade)
+
+	var buf bytes.Buffer
+	Render(&buf, []Result{r}, cfg)
+	assert.Contains(t, buf.String(), "file.md\t(score 12, grade F)")
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"grade":"F"`)
+}
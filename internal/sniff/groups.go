@@ -0,0 +1,60 @@
+package sniff
+
+import (
+	"fmt"
+)
+
+// GroupConfig gates a rule group: the group's rules only add to a file's
+// score once together they reach MinGroupScore. This models clusters of
+// weak signals, e.g. ten passive-voice rules that only count when at
+// least three of them fire.
+type GroupConfig struct {
+	MinGroupScore int `json:"minGroupScore" yaml:"minGroupScore"`
+}
+
+// dictFile is the object form of a dictionary, which can carry groups.
+type dictFile struct {
+	Rules  []Rule                 `json:"rules" yaml:"rules"`
+	Groups map[string]GroupConfig `json:"groups" yaml:"groups"`
+}
+
+// applyGroups copies each group's gate onto its member rules. Rules may
+// name a group that has no config; they then score as usual.
+func applyGroups(rules []Rule, groups map[string]GroupConfig) error {
+	for name, g := range groups {
+		if g.MinGroupScore < 0 {
+			return fmt.Errorf("group %s: minGroupScore must not be negative", name)
+		}
+	}
+	for i := range rules {
+		if g, ok := groups[rules[i].Group]; ok {
+			rules[i].minGroupScore = g.MinGroupScore
+		}
+	}
+	return nil
+}
+
+// gateGroups is the second scoring pass: it drops the hits of every group
+// whose combined score stays under its minimum and returns the new total.
+func gateGroups(detail map[string]RuleHit, score int) int {
+	var groupScore map[string]int
+	for _, h := range detail {
+		if h.Rule.minGroupScore > 0 {
+			if groupScore == nil {
+				groupScore = make(map[string]int)
+			}
+			groupScore[h.Rule.Group] += h.Scored * h.Rule.Weight
+		}
+	}
+	if groupScore == nil {
+		return score
+	}
+
+	for name, h := range detail {
+		if h.Rule.minGroupScore > 0 && groupScore[h.Rule.Group] < h.Rule.minGroupScore {
+			score -= h.Scored * h.Rule.Weight
+			delete(detail, name)
+		}
+	}
+	return score
+}
@@ -0,0 +1,78 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRulesWithGroups(t *testing.T) {
+	dict := `rules:
+  - name: was-done
+    pattern: "was done"
+    weight: 5
+    group: passive-voice
+  - name: is-seen
+    pattern: "is seen"
+    weight: 5
+    group: passive-voice
+  - name: loner
+    pattern: "loner"
+    weight: 1
+    group: no-config
+groups:
+  passive-voice:
+    minGroupScore: 15
+`
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(dict), 0644))
+
+	set, err := LoadRules([]string{path})
+	require.NoError(t, err)
+	rules := set.Active()
+	require.Len(t, rules, len(baseRules)+3)
+	ext := rules[len(baseRules):]
+	assert.Equal(t, 15, ext[0].minGroupScore)
+	assert.Equal(t, 15, ext[1].minGroupScore)
+	assert.Zero(t, ext[2].minGroupScore, "groups without config are ungated")
+
+	jsonDict := `{"rules":[{"name":"a","pattern":"a","weight":1,"group":"g"}],"groups":{"g":{"minGroupScore":2}}}`
+	require.NoError(t, os.WriteFile(path, []byte(jsonDict), 0644))
+	set, err = LoadRules([]string{path})
+	require.NoError(t, err)
+	rules = set.Active()
+	assert.Equal(t, 2, rules[len(rules)-1].minGroupScore)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"groups":{"g":{"minGroupScore":-1}}}`), 0644))
+	_, err = LoadRules([]string{path})
+	assert.Error(t, err)
+}
+
+func TestAnalyseGroupGate(t *testing.T) {
+	rules := []Rule{
+		{Name: "was-done", Pattern: "was done", Weight: 5, Group: "passive", minGroupScore: 15},
+		{Name: "is-seen", Pattern: "is seen", Weight: 5, Group: "passive", minGroupScore: 15},
+		{Name: "got-made", Pattern: "got made", Weight: 5, Group: "passive", minGroupScore: 15},
+		{Name: "solo", Pattern: "solo", Weight: 2},
+	}
+	cfg := Config{Threshold: 10}
+
+	// Two of three passive rules: 10 < 15, so the group adds nothing
+	r := AnalyseString("it was done and is seen, solo", "a.txt", rules, cfg)
+	assert.Equal(t, 2.0, r.Score)
+	assert.NotContains(t, r.Detail, "was-done")
+	assert.Contains(t, r.Detail, "solo")
+
+	// All three reach the minimum and count in full
+	r = AnalyseString("it was done, is seen and got made, solo", "a.txt", rules, cfg)
+	assert.Equal(t, 17.0, r.Score)
+	assert.Len(t, r.Detail, 4)
+
+	// Repeats of one rule also add up towards the group minimum
+	r = AnalyseString("was done was done was done", "a.txt", rules, cfg)
+	assert.Equal(t, 15.0, r.Score)
+}
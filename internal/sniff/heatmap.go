@@ -0,0 +1,101 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultHeatmapSections is how many sections a heatmap has when only
+// Rule.PositionTracking asks for one.
+const DefaultHeatmapSections = 10
+
+// heatmapSections returns the number of heatmap sections.
+func (c Config) heatmapSections() int {
+	if c.Heatmap > 0 {
+		return c.Heatmap
+	}
+	return DefaultHeatmapSections
+}
+
+// tracksPositions reports whether r's match offsets feed the heatmap:
+// every rule's with Config.Heatmap, else only those that opt in.
+func (c Config) tracksPositions(r Rule) bool {
+	return c.Heatmap > 0 || r.PositionTracking
+}
+
+// heatmapOf counts the matches of the rules still in detail per section.
+// sections maps each tracked rule to the section of every match.
+func heatmapOf(sections map[string][]int, detail map[string]RuleHit, n int) []int {
+	if len(sections) == 0 {
+		return nil
+	}
+	heat := make([]int, n)
+	for name, secs := range sections {
+		if _, ok := detail[name]; !ok {
+			continue // dropped by its group
+		}
+		for _, s := range secs {
+			heat[s]++
+		}
+	}
+	return heat
+}
+
+// sectionsOf maps match offsets in content of length size to one of n
+// equal sections.
+func sectionsOf(spans []span, size, n int) []int {
+	out := make([]int, len(spans))
+	for i, sp := range spans {
+		out[i] = min(sp.off*n/size, n-1)
+	}
+	return out
+}
+
+// HeatmapPeak returns the index of the section with the most matches,
+// the first on a tie, or -1 without a heatmap.
+func (r Result) HeatmapPeak() int {
+	if len(r.Heatmap) == 0 {
+		return -1
+	}
+	peak := 0
+	for i, n := range r.Heatmap {
+		if n > r.Heatmap[peak] {
+			peak = i
+		}
+	}
+	return peak
+}
+
+// heatmapBar draws one character per section, e.g. "[##..:.####]": '.'
+// for none, ':' for up to half the peak's matches and '#' above that.
+func heatmapBar(heat []int) string {
+	top := 0
+	for _, n := range heat {
+		top = max(top, n)
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, n := range heat {
+		switch {
+		case n == 0:
+			b.WriteByte('.')
+		case 2*n <= top:
+			b.WriteByte(':')
+		default:
+			b.WriteByte('#')
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// printHeatmap prints r's heatmap under its file line, if it has one.
+func printHeatmap(w io.Writer, st textStyle, r Result) {
+	if len(r.Heatmap) == 0 {
+		return
+	}
+	peak := r.HeatmapPeak()
+	fmt.Fprintf(w, "  heatmap %s %s\n", heatmapBar(r.Heatmap),
+		st.meta(fmt.Sprintf("peak section %d of %d (%s)", peak, len(r.Heatmap), plural(r.Heatmap[peak], "match", "matches"))))
+}
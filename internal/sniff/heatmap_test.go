@@ -0,0 +1,69 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHeatmap verifies matches land in the section of their offset.
+func TestHeatmap(t *testing.T) {
+	// Ten 10-byte sections; MARKs in sections 0, 3 and 3 again, 9
+	content := "MARK......" + strings.Repeat(".", 20) + "MARK.MARK." + strings.Repeat(".", 50) + ".....MARK."
+	require.Len(t, content, 100)
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}}
+
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 100})
+	assert.Nil(t, r.Heatmap, "off by default")
+	assert.Equal(t, -1, r.HeatmapPeak())
+
+	r = AnalyseString(content, "a.txt", rules, Config{Threshold: 100, Heatmap: 10})
+	assert.Equal(t, []int{1, 0, 0, 2, 0, 0, 0, 0, 0, 1}, r.Heatmap)
+	assert.Equal(t, 3, r.HeatmapPeak())
+
+	r = AnalyseString(content, "a.txt", rules, Config{Threshold: 100, Heatmap: 2})
+	assert.Equal(t, []int{3, 1}, r.Heatmap)
+}
+
+// TestHeatmapPositionTracking verifies rules can opt in on their own.
+func TestHeatmapPositionTracking(t *testing.T) {
+	content := "MARK dash — " + strings.Repeat(".", 100)
+	rules := []Rule{
+		{Name: "mark", Pattern: "MARK", Weight: 1, PositionTracking: true},
+		{Name: "dash", Pattern: "—", Weight: 1},
+	}
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 100})
+	require.Len(t, r.Heatmap, DefaultHeatmapSections)
+	assert.Equal(t, 1, r.Heatmap[0], "only the tracked rule counts")
+}
+
+func TestHeatmapBar(t *testing.T) {
+	assert.Equal(t, "[#.:.#]", heatmapBar([]int{4, 0, 2, 0, 3}))
+	assert.Equal(t, "[...]", heatmapBar([]int{0, 0, 0}))
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	r := Result{Path: "a.md", Score: 40, Smelly: true, Heatmap: []int{0, 3, 12, 4}}
+
+	var buf bytes.Buffer
+	Render(&buf, []Result{r}, Config{Threshold: 30})
+	assert.Contains(t, buf.String(), "  heatmap [.:#:] peak section 2 of 4 (12 matches)\n")
+
+	buf.Reset()
+	Render(&buf, []Result{r}, Config{Threshold: 30, Format: FormatJSON})
+	var report struct {
+		Results []map[string]any `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, []any{0.0, 3.0, 12.0, 4.0}, report.Results[0]["heatmap"])
+	assert.Equal(t, 2.0, report.Results[0]["heatmapPeak"])
+
+	b, err := json.Marshal(Result{Path: "b.md"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "heatmap")
+}
@@ -0,0 +1,125 @@
+package sniff
+
+import (
+	"html/template"
+	"io"
+	"log/slog"
+	"sort"
+)
+
+// htmlReport is the data behind the self-contained HTML report.
+type htmlReport struct {
+	Threshold float64
+	Total     int
+	Smelly    int
+	Warnings  int
+	MaxScore  float64
+	MaxPath   string
+	Files     []htmlFile
+}
+
+type htmlFile struct {
+	Path    string
+	Score   float64
+	Smelly  bool
+	Warning bool
+	Hits    []htmlHit
+}
+
+type htmlHit struct {
+	Name        string
+	Description string
+	Pattern     string
+	Weight      int
+	Count       int
+	Excluded    bool
+	Line        int
+	Snippet     string // first match in context, with -snippets
+}
+
+// htmlTemplate has inline CSS only so the report can be mailed as-is.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"score": FormatScore}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>synthsniff report</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:2rem auto;max-width:60rem;color:#222}
+h1{font-size:1.5rem}
+table{border-collapse:collapse;margin:1rem 0}
+th,td{border:1px solid #ccc;padding:.3rem .6rem;text-align:left}
+th{background:#f4f4f4}
+details{border:1px solid #ddd;border-radius:4px;margin:.4rem 0;padding:.4rem .8rem}
+summary{cursor:pointer;font-family:monospace}
+.smelly summary{color:#b00020;font-weight:bold}
+.warning summary{color:#8a5a00}
+.clean summary{color:#1b5e20}
+code{background:#f6f8fa;padding:0 .2rem}
+</style>
+</head>
+<body>
+<h1>synthsniff report</h1>
+<table>
+<tr><th>Files scanned</th><td>{{.Total}}</td></tr>
+<tr><th>Smelly files</th><td>{{.Smelly}}</td></tr>
+{{if .Warnings}}<tr><th>Warnings</th><td>{{.Warnings}}</td></tr>
+{{end}}<tr><th>Threshold</th><td>{{score .Threshold}}</td></tr>
+<tr><th>Highest score</th><td>{{score .MaxScore}}{{if .MaxPath}} ({{.MaxPath}}){{end}}</td></tr>
+</table>
+{{range .Files}}<details class="{{if .Smelly}}smelly{{else if .Warning}}warning{{else}}clean{{end}}">
+<summary>{{if .Smelly}}🚨{{else if .Warning}}⚠️{{else}}✅{{end}} {{.Path}} (score {{score .Score}})</summary>
+<table>
+<tr><th>Rule</th><th>Hits</th><th>Weight</th><th>First line</th><th>Pattern</th><th>Context</th></tr>
+{{range .Hits}}<tr><td title="{{.Description}}">{{.Name}}</td><td>{{.Count}}{{if .Excluded}} (excluded){{end}}</td><td>{{.Weight}}</td><td>{{if .Line}}{{.Line}}{{end}}</td><td><code>{{.Pattern}}</code></td><td>{{if .Snippet}}<code>{{.Snippet}}</code>{{end}}</td></tr>
+{{end}}</table>
+</details>
+{{else}}<p>✅ No rule matched in any file.</p>
+{{end}}</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, list []Result, cfg Config) {
+	if err := htmlTemplate.Execute(w, buildHTMLReport(list, cfg)); err != nil {
+		slog.Error("html render failed", "err", err)
+	}
+}
+
+// buildHTMLReport summarises the scan and keeps every file with a rule hit.
+func buildHTMLReport(list []Result, cfg Config) htmlReport {
+	rep := htmlReport{Threshold: cfg.Threshold, Total: len(list)}
+	for _, r := range list {
+		if r.Smelly {
+			rep.Smelly++
+		}
+		if r.Warning {
+			rep.Warnings++
+		}
+		if r.Score > rep.MaxScore {
+			rep.MaxScore, rep.MaxPath = r.Score, displayPath(r.Path)
+		}
+		if len(r.Detail) == 0 {
+			continue
+		}
+
+		f := htmlFile{Path: displayPath(r.Path), Score: r.Score, Smelly: r.Smelly, Warning: r.Warning}
+		for _, h := range r.Detail {
+			var snippet string
+			if len(h.Snippets) > 0 {
+				snippet = h.Snippets[0]
+			}
+			f.Hits = append(f.Hits, htmlHit{
+				Name:        h.Rule.Name,
+				Description: h.Rule.Description,
+				Pattern:     escape(h.Rule.displayPattern()),
+				Weight:      h.Rule.Weight,
+				Count:       h.Count,
+				Excluded:    h.Excluded,
+				Line:        h.FirstLine(),
+				Snippet:     snippet,
+			})
+		}
+		sort.Slice(f.Hits, func(i, j int) bool { return f.Hits[i].Name < f.Hits[j].Name })
+		rep.Files = append(rep.Files, f)
+	}
+	return rep
+}
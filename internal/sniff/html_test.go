@@ -0,0 +1,55 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderHTML verifies the report summary and per-file sections.
+func TestRenderHTML(t *testing.T) {
+	results := []Result{
+		{Path: "clean.md", Score: 0},
+		{
+			Path:  "notes.md",
+			Score: 10,
+			Detail: map[string]RuleHit{
+				"rule1": {Rule: Rule{Name: "rule1", Pattern: "x", Weight: 10}, Count: 1, Lines: []int{4}, Snippets: []string{"a >>>x<<< <b>"}},
+			},
+		},
+		{
+			Path:  "smelly<1>.md",
+			Score: 42,
+			Detail: map[string]RuleHit{
+				"rule2": {Rule: Rule{Name: "rule2", Pattern: "\n---\n", Weight: 14}, Count: 3, Lines: []int{2, 5, 9}},
+			},
+			Smelly: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	smelly := Render(&buf, results, Config{Format: FormatHTML, Threshold: 30})
+	out := buf.String()
+
+	assert.True(t, smelly.AnyErrors)
+	assert.Contains(t, out, "<!DOCTYPE html>")
+	assert.Contains(t, out, "<tr><th>Files scanned</th><td>3</td></tr>")
+	assert.Contains(t, out, "<tr><th>Smelly files</th><td>1</td></tr>")
+	assert.Contains(t, out, "42 (smelly&lt;1&gt;.md)", "paths must be HTML-escaped")
+	assert.Contains(t, out, `<details class="smelly">`)
+	assert.Contains(t, out, `<details class="clean">`)
+	assert.Contains(t, out, `<code>\n---\n</code>`)
+	assert.Contains(t, out, `<code>a &gt;&gt;&gt;x&lt;&lt;&lt; &lt;b&gt;</code>`, "snippets must be HTML-escaped")
+	assert.NotContains(t, out, "clean.md", "files without hits get no section")
+	assert.NotContains(t, out, "<link", "report must not depend on external assets")
+	assert.NotContains(t, out, "<script src", "report must not depend on external assets")
+}
+
+// TestRenderHTML_Empty verifies an empty scan renders a placeholder.
+func TestRenderHTML_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, RenderResult{}, Render(&buf, nil, Config{Format: FormatHTML}))
+	assert.Contains(t, buf.String(), "No rule matched")
+}
@@ -3,10 +3,19 @@ package sniff
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"unicode/utf8"
 )
 
 // IgnorePattern represents a single pattern from a gitignore file
@@ -15,18 +24,40 @@ type IgnorePattern struct {
 	Negate    bool // Pattern starts with !
 	Directory bool // Pattern ends with /
 	Root      bool // Pattern starts with /
+
+	Source     string       // ignore file the pattern came from
+	MatchCount atomic.Int64 // paths ShouldIgnore found matching, see Stats
+
+	compiled *regexp.Regexp // Pattern as a regexp, see globToRegexp
+}
+
+// String returns the pattern as written in its ignore file.
+func (p *IgnorePattern) String() string {
+	s := p.Pattern
+	if p.Root {
+		s = "/" + s
+	}
+	if p.Negate {
+		s = "!" + s
+	}
+	if p.Directory {
+		s += "/"
+	}
+	return s
 }
 
 // IgnoreRules stores the patterns from gitignore files
 type IgnoreRules struct {
-	mu       sync.RWMutex
-	patterns map[string][]IgnorePattern // key is directory
+	mu         sync.RWMutex
+	patterns   map[string][]*IgnorePattern // key is directory
+	files      []string                    // -ignore-file and .gitignore files loaded, in order
+	synthFiles []string                    // .synthsniffignore files loaded, in order
 }
 
 // NewIgnoreRules creates a new IgnoreRules instance
 func NewIgnoreRules() *IgnoreRules {
 	return &IgnoreRules{
-		patterns: make(map[string][]IgnorePattern),
+		patterns: make(map[string][]*IgnorePattern),
 	}
 }
 
@@ -38,14 +69,14 @@ func (r *IgnoreRules) LoadGitignoreFile(path string, baseDir string) error {
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to close gitignore file: %v\n", err)
+			slog.Warn("failed to close gitignore file", "err", err)
 		}
 	}()
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	patterns := []IgnorePattern{}
+	patterns := []*IgnorePattern{}
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -54,7 +85,7 @@ func (r *IgnoreRules) LoadGitignoreFile(path string, baseDir string) error {
 			continue
 		}
 
-		pattern := IgnorePattern{}
+		pattern := &IgnorePattern{Source: path}
 		// Handle negation
 		if strings.HasPrefix(line, "!") {
 			pattern.Negate = true
@@ -74,6 +105,13 @@ func (r *IgnoreRules) LoadGitignoreFile(path string, baseDir string) error {
 		}
 
 		pattern.Pattern = line
+		// A line that cannot be compiled is skipped, as git does
+		compiled, err := regexp.Compile(globToRegexp(line))
+		if err != nil {
+			slog.Warn("skipping invalid ignore pattern", "path", path, "pattern", line, "err", err)
+			continue
+		}
+		pattern.compiled = compiled
 		patterns = append(patterns, pattern)
 	}
 
@@ -95,9 +133,30 @@ func (r *IgnoreRules) LoadCustomIgnoreFile(path string) error {
 
 // ShouldIgnore checks if a file should be ignored
 func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
+	return r.match(filePath, nil)
+}
+
+// TraceIgnore is ShouldIgnore writing each pattern it evaluates to w:
+// whether it matched, the ignored state after a match, and which pattern
+// made the final decision.
+func (r *IgnoreRules) TraceIgnore(filePath string, w io.Writer) bool {
+	return r.match(filePath, w)
+}
+
+// match checks filePath against the loaded patterns, tracing to trace
+// when it is not nil.
+func (r *IgnoreRules) match(filePath string, trace io.Writer) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	// Nothing loaded, nothing to stat
+	if len(r.patterns) == 0 {
+		if trace != nil {
+			fmt.Fprintf(trace, "%s: not ignored, no ignore patterns loaded\n", filePath)
+		}
+		return false
+	}
+
 	// Normalize path
 	filePath = filepath.Clean(filePath)
 	fileName := filepath.Base(filePath)
@@ -108,6 +167,13 @@ func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
 	if err == nil && fileInfo.IsDir() {
 		isDir = true
 	}
+	if trace != nil {
+		kind := "file"
+		if isDir {
+			kind = "directory"
+		}
+		fmt.Fprintf(trace, "tracing %s (%s)\n", filePath, kind)
+	}
 
 	// Find all parent directories that might have .gitignore files
 	dir := filepath.Dir(filePath)
@@ -131,8 +197,10 @@ func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
 		relevantDirs[i], relevantDirs[j] = relevantDirs[j], relevantDirs[i]
 	}
 
-	// Track whether the file should be ignored
+	// Track whether the file should be ignored, and by which pattern
 	ignored := false
+	var decidedDir string
+	var decidedBy *IgnorePattern
 
 	// Check patterns in each relevant directory
 	for _, dir := range relevantDirs {
@@ -140,6 +208,9 @@ func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
 			for _, pattern := range patterns {
 				// Skip directory patterns if we're checking a file
 				if pattern.Directory && !isDir {
+					if trace != nil {
+						fmt.Fprintf(trace, "  %s: %s: skipped, matches directories only\n", dir, pattern)
+					}
 					continue
 				}
 
@@ -159,14 +230,14 @@ func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
 					if filepath.IsAbs(pathToMatch) {
 						pathToMatch = pathToMatch[1:] // Remove leading slash if present
 					}
-					match = matchGlob(pattern.Pattern, pathToMatch)
+					match = pattern.compiled.MatchString(pathToMatch)
 				} else {
 					// Non-anchored pattern - can match anywhere in the path
 					// For files, just check the filename
 					if isDir {
-						match = matchGlob(pattern.Pattern, relPath) || matchGlob(pattern.Pattern, fileName)
+						match = pattern.compiled.MatchString(relPath) || pattern.compiled.MatchString(fileName)
 					} else {
-						match = matchGlob(pattern.Pattern, fileName)
+						match = pattern.compiled.MatchString(fileName)
 					}
 				}
 
@@ -177,85 +248,408 @@ func (r *IgnoreRules) ShouldIgnore(filePath string) bool {
 					} else {
 						ignored = true
 					}
+					decidedDir, decidedBy = dir, pattern
+					// Traces are not scans and leave Stats alone
+					if trace == nil {
+						pattern.MatchCount.Add(1)
+					}
+				}
+				if trace != nil {
+					if match {
+						fmt.Fprintf(trace, "  %s: %s: matched, ignored=%t\n", dir, pattern, ignored)
+					} else {
+						fmt.Fprintf(trace, "  %s: %s: no match\n", dir, pattern)
+					}
 				}
 			}
 		}
 	}
 
+	if trace != nil {
+		switch {
+		case decidedBy == nil:
+			fmt.Fprintf(trace, "%s: not ignored, no pattern matched\n", filePath)
+		case ignored:
+			fmt.Fprintf(trace, "%s: ignored by %s: %s\n", filePath, decidedDir, decidedBy)
+		default:
+			fmt.Fprintf(trace, "%s: not ignored, re-included by %s: %s\n", filePath, decidedDir, decidedBy)
+		}
+	}
 	return ignored
 }
 
-// matchGlob provides pattern matching for gitignore patterns
-// This is an improved implementation for handling glob patterns
-func matchGlob(pattern, name string) bool {
-	// Direct match
-	if pattern == name {
-		return true
+// IgnoreStat is how many paths one loaded ignore pattern matched.
+type IgnoreStat struct {
+	Pattern string `json:"pattern"`
+	Count   int    `json:"count"`
+	Source  string `json:"source"`
+}
+
+// Stats returns every loaded pattern with its match count so far, most
+// matches first, then by source file in the order of its lines. Patterns
+// loaded twice, like a .gitignore under two of the scanned roots, are listed twice.
+func (r *IgnoreRules) Stats() []IgnoreStat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dirs := make([]string, 0, len(r.patterns))
+	for dir := range r.patterns {
+		dirs = append(dirs, dir)
 	}
+	sort.Strings(dirs)
+	var out []IgnoreStat
+	for _, dir := range dirs {
+		for _, p := range r.patterns[dir] {
+			out = append(out, IgnoreStat{Pattern: p.String(), Count: int(p.MatchCount.Load()), Source: p.Source})
+		}
+	}
+	sortIgnoreStats(out)
+	return out
+}
+
+// sortIgnoreStats orders stats most matches first, then by source file,
+// keeping each file's lines in order.
+func sortIgnoreStats(stats []IgnoreStat) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Source < stats[j].Source
+	})
+}
+
+// IgnoreStats adds up the ignore pattern match counts of scans and the
+// ignore files they loaded. Set Config.IgnoreStats to one from
+// NewIgnoreStats; each scan counts into its own ignore rules and adds
+// them when its walk ends, so scans running at once, or the rescans of
+// -watch, do not reset each other's counts. Safe for concurrent use and
+// on a nil *IgnoreStats.
+type IgnoreStats struct {
+	mu         sync.Mutex
+	stats      []IgnoreStat
+	index      map[[2]string]int // source and pattern to their place in stats
+	files      []string
+	synthFiles []string
+	seen       map[string]bool // loaded files already in files or synthFiles
+}
+
+// NewIgnoreStats returns an empty collector.
+func NewIgnoreStats() *IgnoreStats {
+	return &IgnoreStats{index: make(map[[2]string]int), seen: make(map[string]bool)}
+}
+
+// add sums one scan's ignore rules into s.
+func (s *IgnoreStats) add(r *IgnoreRules) {
+	if s == nil {
+		return
+	}
+	stats := r.Stats()
+	files, synthFiles := r.LoadedFiles()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range stats {
+		key := [2]string{st.Source, st.Pattern}
+		if i, ok := s.index[key]; ok {
+			s.stats[i].Count += st.Count
+			continue
+		}
+		s.index[key] = len(s.stats)
+		s.stats = append(s.stats, st)
+	}
+	s.files = s.appendUnseen(s.files, files)
+	s.synthFiles = s.appendUnseen(s.synthFiles, synthFiles)
+}
 
-	// Handle simple * glob (matches any string)
-	if strings.Contains(pattern, "*") {
-		// Convert gitignore glob pattern to Go's filepath.Match pattern
-		// but we need to be careful because they have slight differences
+// appendUnseen appends the paths s has not recorded yet to list.
+func (s *IgnoreStats) appendUnseen(list, paths []string) []string {
+	for _, p := range paths {
+		if !s.seen[p] {
+			s.seen[p] = true
+			list = append(list, p)
+		}
+	}
+	return list
+}
 
-		// For now, let's handle the most common patterns
+// LoadedFiles returns the ignore files the scans loaded, each once, in
+// the order first met; see IgnoreRules.LoadedFiles.
+func (s *IgnoreStats) LoadedFiles() (ignoreFiles, synthsniffIgnoreFiles []string) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.files), slices.Clone(s.synthFiles)
+}
 
-		// Pattern with single * (e.g., "*.txt", "log/*", "src/*.go")
+// Stats returns the summed counts so far, ordered like
+// IgnoreRules.Stats; a pattern loaded more than once is listed once.
+func (s *IgnoreStats) Stats() []IgnoreStat {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	out := slices.Clone(s.stats)
+	s.mu.Unlock()
+	sortIgnoreStats(out)
+	return out
+}
+
+// RenderIgnoreStats prints stats as a table, one pattern per line.
+func RenderIgnoreStats(w io.Writer, stats []IgnoreStat) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATTERN\tFILES\tSOURCE")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", s.Pattern, s.Count, s.Source)
+	}
+	return tw.Flush()
+}
+
+// globToRegexp converts a gitignore pattern to an anchored regexp. A
+// pattern always matches its own text. With a * other than "*" alone the
+// name must start with the piece before the first star, hold the pieces
+// between stars in order after it and end with the piece after the last
+// star, which may overlap the others: "ab*ba" matches "aba". Each * spans
+// any text including /. Otherwise the pattern follows filepath.Match,
+// where *, ? and negated classes stop at the separator.
+func globToRegexp(pattern string) string {
+	if pattern != "*" && strings.Contains(pattern, "*") {
 		parts := strings.Split(pattern, "*")
-		if len(parts) == 2 {
-			// Pattern like "*.txt"
-			if parts[0] == "" && parts[1] != "" {
-				return strings.HasSuffix(name, parts[1])
+		pieces := []string{parts[0]}
+		for _, part := range parts[1 : len(parts)-1] {
+			if part != "" {
+				pieces = append(pieces, part)
 			}
+		}
+		sp := &starPieces{pieces: pieces, memo: make(map[starKey][]string)}
+		alts := sp.thenSuffix(len(pieces), parts[len(parts)-1])
+		return `(?s)^(?:` + strings.Join(alts, `|`) + `)$`
+	}
 
-			// Pattern like "log/*"
-			if parts[0] != "" && parts[1] == "" {
-				return strings.HasPrefix(name, parts[0])
-			}
+	literal := regexp.QuoteMeta(pattern)
+	expr, ok := matchToRegexp(pattern)
+	if !ok || expr == literal {
+		return `(?s)^` + literal + `$`
+	}
+	return `(?s)^(?:` + literal + `|` + expr + `)$`
+}
 
-			// Pattern like "src/*.go"
-			if parts[0] != "" && parts[1] != "" {
-				return strings.HasPrefix(name, parts[0]) && strings.HasSuffix(name, parts[1])
-			}
+// starPieces turns the pieces of a star pattern into regexps, each piece
+// but the first following a star. Results are memoized: with repetitive
+// pieces the same overlaps recur, and every end looked up is a prefix of
+// the last piece, so there are few distinct ones.
+type starPieces struct {
+	pieces []string
+	memo   map[starKey][]string
+}
+
+type starKey struct {
+	n   int
+	end string
+}
+
+// thenSuffix returns regexps that together match the names made of the
+// first n pieces in order with any text between them, then anything,
+// that also end with suffix. The suffix may overlap the pieces, so for
+// each way it can, the overlapped part is matched by the pieces.
+func (sp *starPieces) thenSuffix(n int, suffix string) []string {
+	key := starKey{n, suffix}
+	if alts, ok := sp.memo[key]; ok {
+		return alts
+	}
+	alts := []string{joinPieces(sp.pieces[:n]) + `.*` + regexp.QuoteMeta(suffix)}
+	for k := 0; k < len(suffix); k++ {
+		// The last k bytes of suffix follow the pieces, the rest ends them
+		cut := len(suffix) - k
+		if k > 0 && !utf8.RuneStart(suffix[cut]) {
+			continue
 		}
+		for _, alt := range sp.endingWith(n, suffix[:cut]) {
+			alts = append(alts, alt+regexp.QuoteMeta(suffix[cut:]))
+		}
+	}
+	slices.Sort(alts)
+	alts = slices.Compact(alts)
+	sp.memo[key] = alts
+	return alts
+}
 
-		// More complex patterns with multiple *
-		if len(parts) > 2 {
-			// Try to match all parts in order
-			pos := 0
-			for i, part := range parts {
-				if part == "" {
-					continue // Skip empty parts (adjacent *)
-				}
+// endingWith returns regexps that together match the names made of the
+// first n pieces in order with any text between them that end with the
+// non-empty end.
+func (sp *starPieces) endingWith(n int, end string) []string {
+	last := sp.pieces[n-1]
+	switch {
+	case len(end) <= len(last):
+		if strings.HasSuffix(last, end) {
+			return []string{joinPieces(sp.pieces[:n])}
+		}
+		return nil
+	case n == 1 || !strings.HasSuffix(end, last):
+		return nil
+	}
+	var alts []string
+	for _, alt := range sp.thenSuffix(n-1, end[:len(end)-len(last)]) {
+		alts = append(alts, alt+regexp.QuoteMeta(last))
+	}
+	return alts
+}
 
-				// For first part, check prefix
-				if i == 0 {
-					if !strings.HasPrefix(name, part) {
-						return false
-					}
-					pos = len(part)
-					continue
-				}
+// joinPieces quotes pieces and joins them with .*.
+func joinPieces(pieces []string) string {
+	quoted := make([]string, len(pieces))
+	for i, p := range pieces {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	return strings.Join(quoted, `.*`)
+}
 
-				// For last part, check suffix
-				if i == len(parts)-1 {
-					return strings.HasSuffix(name, part)
+// matchToRegexp translates a filepath.Match pattern, reporting false when
+// the pattern is malformed and so matches nothing.
+func matchToRegexp(pattern string) (string, bool) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return "", false
+	}
+	escapes := runtime.GOOS != "windows"
+	notSep := `[^` + regexp.QuoteMeta(string(filepath.Separator)) + `]`
+
+	var b strings.Builder
+	for len(pattern) > 0 {
+		switch c := pattern[0]; {
+		case c == '*':
+			b.WriteString(notSep + `*`)
+			pattern = pattern[1:]
+		case c == '?':
+			b.WriteString(notSep)
+			pattern = pattern[1:]
+		case c == '[':
+			pattern = pattern[1:]
+			negated := strings.HasPrefix(pattern, "^")
+			if negated {
+				pattern = pattern[1:]
+			}
+			var class strings.Builder
+			for pattern[0] != ']' {
+				var lo rune
+				lo, pattern = classRune(pattern, escapes)
+				hi := lo
+				if pattern[0] == '-' {
+					hi, pattern = classRune(pattern[1:], escapes)
 				}
-
-				// For middle parts, find position after current position
-				idx := strings.Index(name[pos:], part)
-				if idx == -1 {
-					return false
+				// filepath.Match accepts a reversed range, which
+				// matches nothing; regexp rejects it
+				if lo <= hi {
+					fmt.Fprintf(&class, `\x{%x}-\x{%x}`, lo, hi)
 				}
-				pos += idx + len(part)
 			}
-			return true
+			pattern = pattern[1:]
+			// Unlike filepath.Match, a negated class stops at the
+			// separator like * and ?, as in git
+			switch {
+			case class.Len() == 0 && negated:
+				b.WriteString(notSep)
+			case class.Len() == 0:
+				b.WriteString(`[^\x00-\x{10ffff}]`)
+			case negated:
+				b.WriteString(notSep[:len(notSep)-1] + class.String() + `]`)
+			default:
+				b.WriteString(`[` + class.String() + `]`)
+			}
+		case c == '\\' && escapes:
+			_, n := utf8.DecodeRuneInString(pattern[1:])
+			b.WriteString(regexp.QuoteMeta(pattern[1 : 1+n]))
+			pattern = pattern[1+n:]
+		default:
+			_, n := utf8.DecodeRuneInString(pattern)
+			b.WriteString(regexp.QuoteMeta(pattern[:n]))
+			pattern = pattern[n:]
+		}
+	}
+	return b.String(), true
+}
+
+// classRune reads one possibly escaped rune of a character class.
+func classRune(pattern string, escapes bool) (rune, string) {
+	if pattern[0] == '\\' && escapes {
+		pattern = pattern[1:]
+	}
+	r, n := utf8.DecodeRuneInString(pattern)
+	return r, pattern[n:]
+}
+
+// SynthsniffIgnoreName is a .gitignore-style file that only affects
+// synthsniff. It is always honoured, with or without -use-gitignore.
+const SynthsniffIgnoreName = ".synthsniffignore"
+
+// loadSynthsniffIgnore loads dir's .synthsniffignore when entries has one.
+// A file that cannot be read is reported and skipped.
+func (r *IgnoreRules) loadSynthsniffIgnore(dir string, entries []os.DirEntry) {
+	for _, e := range entries {
+		if e.Name() != SynthsniffIgnoreName || !e.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if err := r.LoadGitignoreFile(path, filepath.Clean(dir)); err != nil {
+			slog.Warn("failed to load ignore file", "path", path, "err", err)
+			return
+		}
+		r.mu.Lock()
+		r.synthFiles = append(r.synthFiles, path)
+		r.mu.Unlock()
+		return
+	}
+}
+
+// LoadIgnoreRules loads the ignore rules a scan of roots with cfg applies
+// to path: the -ignore-file and .gitignore files with -use-gitignore, and
+// the .synthsniffignore files from the root holding path down to path's
+// directory. path is matched against roots as written, so both should be
+// relative or both absolute.
+func LoadIgnoreRules(roots []string, path string, cfg Config) (*IgnoreRules, error) {
+	r, err := prepareWalk(roots, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.Clean(path)
+	for _, root := range roots {
+		if root == StdinPath {
+			continue
+		}
+		root = filepath.Clean(root)
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "." {
+			break // a root itself is never ignored
+		}
+		// The walk would meet these directories on its way down to path
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			if entries, err := os.ReadDir(dir); err == nil {
+				r.loadSynthsniffIgnore(dir, entries)
+			}
+			if dir == root || dir == filepath.Dir(dir) {
+				break
+			}
 		}
+		break
 	}
+	return r, nil
+}
 
-	// Use filepath.Match for simple wildcard patterns
-	matched, _ := filepath.Match(pattern, name)
-	return matched
+// addFile records path as a loaded -ignore-file or .gitignore file.
+func (r *IgnoreRules) addFile(path string) {
+	r.mu.Lock()
+	r.files = append(r.files, path)
+	r.mu.Unlock()
+}
+
+// LoadedFiles returns the ignore files loaded so far: the -ignore-file
+// and .gitignore files, and apart from them the .synthsniffignore files.
+func (r *IgnoreRules) LoadedFiles() (ignoreFiles, synthsniffIgnoreFiles []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.files), slices.Clone(r.synthFiles)
 }
 
 // FindAndLoadGitignores recursively scans directories and loads .gitignore files
@@ -276,9 +670,7 @@ func (r *IgnoreRules) FindAndLoadGitignores(rootDir string) error {
 			if err := r.LoadGitignoreFile(path, baseDir); err != nil {
 				return err
 			}
-
-			// Record loaded gitignore for reporting using global variable
-			LoadedIgnoreFiles = append(LoadedIgnoreFiles, path)
+			r.addFile(path)
 		}
 
 		return nil
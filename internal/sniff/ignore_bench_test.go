@@ -0,0 +1,54 @@
+package sniff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// BenchmarkShouldIgnore checks 10,000 paths against 100 loaded patterns
+func BenchmarkShouldIgnore(b *testing.B) {
+	dir := b.TempDir()
+
+	// Mix suffix, prefix, multi-star, literal and filepath.Match patterns
+	var lines []string
+	for i := 0; i < 100; i++ {
+		switch i % 5 {
+		case 0:
+			lines = append(lines, fmt.Sprintf("*.ext%02d", i))
+		case 1:
+			lines = append(lines, fmt.Sprintf("/d%02d/*", i))
+		case 2:
+			lines = append(lines, fmt.Sprintf("file%02d*.*.bak", i))
+		case 3:
+			lines = append(lines, fmt.Sprintf("!keep%02d.txt", i))
+		case 4:
+			lines = append(lines, fmt.Sprintf("tmp%02d?.[ch]", i))
+		}
+	}
+	ignoreFile := filepath.Join(dir, ".gitignore")
+	if err := os.WriteFile(ignoreFile, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		b.Fatalf("Failed to write gitignore: %v", err)
+	}
+
+	rules := NewIgnoreRules()
+	if err := rules.LoadGitignoreFile(ignoreFile, dir); err != nil {
+		b.Fatalf("Failed to load gitignore: %v", err)
+	}
+
+	exts := []string{".txt", ".md", ".go", ".c", ".bak", ".ext00", ".ext50"}
+	paths := make([]string, 10000)
+	for i := range paths {
+		name := fmt.Sprintf("file%02d%d%s", i%100, i, exts[i%len(exts)])
+		paths[i] = filepath.Join(dir, fmt.Sprintf("d%02d", i%100), name)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			rules.ShouldIgnore(p)
+		}
+	}
+}
@@ -1,9 +1,19 @@
 package sniff
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIgnoreRules(t *testing.T) {
@@ -59,17 +69,14 @@ func TestIgnoreRules(t *testing.T) {
 	// Initialize ignore rules
 	rules := NewIgnoreRules()
 
-	// Reset the global variable before test
-	LoadedIgnoreFiles = nil
-
 	// Load both gitignore files
 	if err := rules.FindAndLoadGitignores(tempDir); err != nil {
 		t.Fatalf("Failed to load gitignore files: %v", err)
 	}
 
 	// Check if correct files were loaded
-	if len(LoadedIgnoreFiles) != 2 {
-		t.Errorf("Expected 2 loaded ignore files, got %d", len(LoadedIgnoreFiles))
+	if files, _ := rules.LoadedFiles(); len(files) != 2 {
+		t.Errorf("Expected 2 loaded ignore files, got %d", len(files))
 	}
 
 	// Test each file against the rules
@@ -99,3 +106,350 @@ func TestIgnoreRules(t *testing.T) {
 		}
 	}
 }
+
+func TestScanSynthsniffIgnore(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.md", "docs/b.md", "docs/draft.md", "docs/deep/draft.md", "other/draft.md"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+	ignoreFile := filepath.Join(root, "docs", SynthsniffIgnoreName)
+	require.NoError(t, os.WriteFile(ignoreFile, []byte("draft.md\n"), 0644))
+
+	scan := func(useGitignore bool, stats *IgnoreStats) []string {
+		results, err := Scan(context.Background(), []string{root}, Config{
+			UseGitignore: useGitignore,
+			ExtraRules:   []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}},
+			IgnoreStats:  stats,
+		})
+		require.NoError(t, err)
+		var got []string
+		for _, r := range results {
+			rel, err := filepath.Rel(root, r.Path)
+			require.NoError(t, err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	want := []string{"a.md", "docs/b.md", "other/draft.md"}
+	stats := NewIgnoreStats()
+	assert.Equal(t, want, scan(false, stats), "honoured without -use-gitignore, only below its directory")
+	files, synthFiles := stats.LoadedFiles()
+	assert.Empty(t, files)
+	assert.Equal(t, []string{ignoreFile}, synthFiles)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("a.md\n"), 0644))
+	stats = NewIgnoreStats()
+	assert.Equal(t, []string{".gitignore", "docs/b.md", "other/draft.md"}, scan(true, stats), "combined with .gitignore rules")
+	files, synthFiles = stats.LoadedFiles()
+	assert.Equal(t, []string{ignoreFile}, synthFiles, "tracked apart from .gitignore files")
+	assert.Equal(t, []string{filepath.Join(root, ".gitignore")}, files)
+
+	var buf bytes.Buffer
+	printIgnoreFilesReport(&buf, plainStyle, Config{UseGitignore: true, IgnoreStats: stats})
+	assert.Contains(t, buf.String(), "Loaded ignore files:\n  - "+filepath.Join(root, ".gitignore"))
+	assert.Contains(t, buf.String(), "Loaded .synthsniffignore files:\n  - "+ignoreFile)
+
+	// Scans at once keep their own lists
+	other := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(other, "c.md"), []byte("MARK"), 0644))
+	rootStats, otherStats := NewIgnoreStats(), NewIgnoreStats()
+	var wg sync.WaitGroup
+	for dir, stats := range map[string]*IgnoreStats{root: rootStats, other: otherStats} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Scan(context.Background(), []string{dir}, Config{IgnoreStats: stats})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	_, synthFiles = rootStats.LoadedFiles()
+	assert.Equal(t, []string{ignoreFile}, synthFiles)
+	_, synthFiles = otherStats.LoadedFiles()
+	assert.Empty(t, synthFiles)
+}
+
+// legacyMatchGlob is the matcher ShouldIgnore used before patterns were
+// compiled to regexps, kept to check the regexps against it.
+func legacyMatchGlob(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+	if strings.Contains(pattern, "*") {
+		parts := strings.Split(pattern, "*")
+		if len(parts) == 2 {
+			if parts[0] == "" && parts[1] != "" {
+				return strings.HasSuffix(name, parts[1])
+			}
+			if parts[0] != "" && parts[1] == "" {
+				return strings.HasPrefix(name, parts[0])
+			}
+			if parts[0] != "" && parts[1] != "" {
+				return strings.HasPrefix(name, parts[0]) && strings.HasSuffix(name, parts[1])
+			}
+		}
+		if len(parts) > 2 {
+			pos := 0
+			for i, part := range parts {
+				if part == "" {
+					continue
+				}
+				if i == 0 {
+					if !strings.HasPrefix(name, part) {
+						return false
+					}
+					pos = len(part)
+					continue
+				}
+				if i == len(parts)-1 {
+					return strings.HasSuffix(name, part)
+				}
+				idx := strings.Index(name[pos:], part)
+				if idx == -1 {
+					return false
+				}
+				pos += idx + len(part)
+			}
+			return true
+		}
+	}
+	matched, _ := filepath.Match(pattern, name)
+	return matched
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	patterns := []string{
+		"*", "**", "***", "*.log", "log/*", "src/*.go", "a*b*c", "*foo*", "*a*",
+		"docs/**/*.md", "**/draft.md", `\*`, "*.[ch]", "*?",
+		"build", "node_modules", "file?.txt", "[abc].md", "[^a]x", "[a-c]?",
+		"[!x]", `x\?y`, `\[a]`, "é?", "a[", "[]", "a.b", "a+b", "(x)", "$HOME",
+		"", "?", "[/]x", "a?b", "[z-a].md", "[^z-a]", "[z-ab]x", "[a-a]",
+		"a[^x]b", "[^]", "ab*ba", "a*a", "aa*aa", "a*b*ab", "ab*b*ab", "*ab*ba",
+		"a*ba*ab", "abc*bcd", "é*é", "a*é*éa",
+	}
+	names := []string{
+		"", "a", "b", "x", "/", "a.log", "log", "log/x", "log/a/b", "src/main.go",
+		"src/a/b.go", "src/go", "build", "build/x", "node_modules", "fileA.txt",
+		"file/.txt", "file.txt", "a.md", "d.md", "b/x", "bx", "ax", "!", "a/b",
+		"acb", "abc", "axbyc", "abcabc", "foo", "xfooy", "docs/a.md",
+		"docs/a/b.md", "draft.md", "x/draft.md", "*", "*.log", `\x`, "a.c",
+		"a.h", "a.x", "a[", "[]", "[a]", "é1", "é/", "x?y", "xzy", "a.b", "axb",
+		"a+b", "aab", "(x)", "$HOME", "a\nb.log", "a\nb", "/x", "ab",
+		"z.md", "[z-a].md", "m", "z", "aba", "aa", "aaa", "abab", "abba",
+		"aab", "abb", "abcd", "abcbcd", "éé", "é", "aééa", "aéa", "a/a", "ab/ba",
+	}
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(globToRegexp(pattern))
+		negated := strings.Contains(pattern, "[^")
+		for _, name := range names {
+			// The one difference: a negated class no longer matches /
+			want := legacyMatchGlob(pattern, name) && !(negated && strings.Contains(name, "/"))
+			assert.Equal(t, want, re.MatchString(name), "pattern %q, name %q", pattern, name)
+		}
+	}
+	assert.True(t, legacyMatchGlob("a[^x]b", "a/b"))
+	assert.False(t, regexp.MustCompile(globToRegexp("a[^x]b")).MatchString("a/b"))
+	assert.False(t, regexp.MustCompile(globToRegexp("[^]")).MatchString("/"))
+
+	// Every star pattern and name over a small alphabet
+	var patternsAB, namesAB []string
+	var grow func(prefix, alphabet string, n int, out *[]string)
+	grow = func(prefix, alphabet string, n int, out *[]string) {
+		*out = append(*out, prefix)
+		if n == 0 {
+			return
+		}
+		for _, c := range alphabet {
+			grow(prefix+string(c), alphabet, n-1, out)
+		}
+	}
+	grow("", "ab*", 5, &patternsAB)
+	grow("", "ab", 6, &namesAB)
+	for _, pattern := range patternsAB {
+		re := regexp.MustCompile(globToRegexp(pattern))
+		for _, name := range namesAB {
+			if legacyMatchGlob(pattern, name) != re.MatchString(name) {
+				t.Errorf("pattern %q, name %q: legacy %v", pattern, name, legacyMatchGlob(pattern, name))
+			}
+		}
+	}
+}
+
+func TestIgnorePatternCompiled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\n*.log\n!/keep.log\nbuild/\n"), 0644))
+
+	rules := NewIgnoreRules()
+	require.NoError(t, rules.LoadGitignoreFile(path, dir))
+	patterns := rules.patterns[dir]
+	require.Len(t, patterns, 3)
+	for _, p := range patterns {
+		require.NotNil(t, p.compiled, p.Pattern)
+		assert.True(t, p.compiled.MatchString(p.Pattern), "%q matches itself", p.Pattern)
+	}
+	assert.True(t, rules.ShouldIgnore(filepath.Join(dir, "a.log")))
+	assert.False(t, rules.ShouldIgnore(filepath.Join(dir, "keep.log")))
+}
+
+func TestTraceIgnore(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "docs")
+	require.NoError(t, os.MkdirAll(docs, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.md\nbuild/\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, SynthsniffIgnoreName), []byte("!keep.md\n"), 0644))
+	draft := filepath.Join(docs, "draft.md")
+	keep := filepath.Join(docs, "keep.md")
+
+	rules, err := LoadIgnoreRules([]string{root}, draft, Config{UseGitignore: true})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.True(t, rules.TraceIgnore(draft, &buf))
+	out := buf.String()
+	assert.Contains(t, out, "tracing "+draft+" (file)")
+	assert.Contains(t, out, root+": *.md: matched, ignored=true")
+	assert.Contains(t, out, root+": build/: skipped, matches directories only")
+	assert.Contains(t, out, docs+": !keep.md: no match")
+	assert.Contains(t, out, draft+": ignored by "+root+": *.md\n")
+	assert.Equal(t, rules.ShouldIgnore(draft), rules.TraceIgnore(draft, io.Discard))
+
+	buf.Reset()
+	assert.False(t, rules.TraceIgnore(keep, &buf))
+	assert.Contains(t, buf.String(), docs+": !keep.md: matched, ignored=false")
+	assert.Contains(t, buf.String(), keep+": not ignored, re-included by "+docs+": !keep.md\n")
+
+	buf.Reset()
+	assert.False(t, rules.TraceIgnore(filepath.Join(docs, "a.txt"), &buf))
+	assert.Contains(t, buf.String(), "not ignored, no pattern matched")
+
+	// Without -use-gitignore only the .synthsniffignore is loaded
+	rules, err = LoadIgnoreRules([]string{root}, draft, Config{})
+	require.NoError(t, err)
+	buf.Reset()
+	assert.False(t, rules.TraceIgnore(draft, &buf))
+	assert.NotContains(t, buf.String(), "*.md")
+
+	buf.Reset()
+	assert.False(t, NewIgnoreRules().TraceIgnore(draft, &buf))
+	assert.Contains(t, buf.String(), "no ignore patterns loaded")
+}
+
+func TestIgnoreReversedRange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("[z-a].md\n*.log\n"), 0644))
+
+	rules := NewIgnoreRules()
+	require.NoError(t, rules.LoadGitignoreFile(path, dir), "a reversed range must not panic")
+	require.Len(t, rules.patterns[dir], 2)
+	assert.False(t, rules.ShouldIgnore(filepath.Join(dir, "m.md")), "matches no file, like filepath.Match")
+	assert.True(t, rules.ShouldIgnore(filepath.Join(dir, "[z-a].md")), "but still its own text")
+	assert.True(t, rules.ShouldIgnore(filepath.Join(dir, "a.log")), "later lines still load")
+}
+
+func TestIgnorePatternString(t *testing.T) {
+	for _, line := range []string{"*.log", "!keep.log", "/root.txt", "build/", "!/dist/"} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".gitignore")
+		require.NoError(t, os.WriteFile(path, []byte(line+"\n"), 0644))
+		rules := NewIgnoreRules()
+		require.NoError(t, rules.LoadGitignoreFile(path, dir))
+		assert.Equal(t, line, rules.patterns[dir][0].String())
+	}
+}
+
+func TestIgnoreStatsAdd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("x\n*.md\n"), 0644))
+
+	stats := NewIgnoreStats()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rules := NewIgnoreRules()
+			assert.NoError(t, rules.FindAndLoadGitignores(dir))
+			for _, name := range []string{"a.md", "b.md", "x"} {
+				rules.ShouldIgnore(filepath.Join(dir, name))
+			}
+			stats.add(rules)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []IgnoreStat{{Pattern: "*.md", Count: 8, Source: path}, {Pattern: "x", Count: 4, Source: path}}, stats.Stats())
+	files, _ := stats.LoadedFiles()
+	assert.Equal(t, []string{path}, files, "listed once")
+}
+
+func TestIgnoreStats(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("*.log\n!keep.log\nbuild/\n*.tmp\n"), 0644))
+	rules := NewIgnoreRules()
+	require.NoError(t, rules.LoadGitignoreFile(path, dir))
+
+	for _, name := range []string{"a.log", "b.log", "keep.log", "c.tmp", "d.txt"} {
+		rules.ShouldIgnore(filepath.Join(dir, name))
+	}
+	rules.ShouldIgnore(filepath.Join(dir, "e.log"))
+	rules.TraceIgnore(filepath.Join(dir, "f.log"), io.Discard)
+
+	assert.Equal(t, []IgnoreStat{
+		{Pattern: "*.log", Count: 4, Source: path},
+		{Pattern: "!keep.log", Count: 1, Source: path},
+		{Pattern: "*.tmp", Count: 1, Source: path},
+		{Pattern: "build/", Count: 0, Source: path},
+	}, rules.Stats(), "most first, ties in file order; traces do not count")
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderIgnoreStats(&buf, rules.Stats()))
+	assert.True(t, strings.HasPrefix(buf.String(), "PATTERN    FILES  SOURCE\n*.log      4      "+path+"\n"), buf.String())
+}
+
+func TestScanIgnoreStats(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.md", "b.md", "docs/draft.md", "docs/c.txt"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+	gitignore := filepath.Join(root, ".gitignore")
+	require.NoError(t, os.WriteFile(gitignore, []byte("b.md\n"), 0644))
+	synthIgnore := filepath.Join(root, "docs", SynthsniffIgnoreName)
+	require.NoError(t, os.WriteFile(synthIgnore, []byte("draft.md\n*.json\n"), 0644))
+
+	stats := NewIgnoreStats()
+	cfg := Config{
+		UseGitignore: true,
+		IgnoreStats:  stats,
+		ExtraRules:   []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}},
+	}
+	_, err := Scan(context.Background(), []string{root}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []IgnoreStat{
+		{Pattern: "b.md", Count: 1, Source: gitignore},
+		{Pattern: "draft.md", Count: 1, Source: synthIgnore},
+		{Pattern: "*.json", Count: 0, Source: synthIgnore},
+	}, stats.Stats())
+
+	// Each scan counts into its own rules and adds them up, nothing resets
+	for range 2 {
+		_, err := Scan(context.Background(), []string{root}, cfg)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []IgnoreStat{
+		{Pattern: "b.md", Count: 3, Source: gitignore},
+		{Pattern: "draft.md", Count: 3, Source: synthIgnore},
+		{Pattern: "*.json", Count: 0, Source: synthIgnore},
+	}, stats.Stats())
+
+	var none *IgnoreStats
+	assert.Nil(t, none.Stats())
+}
@@ -0,0 +1,95 @@
+package sniff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// JUnit XML as read by Jenkins, Azure DevOps and GitLab: one test case
+// per scanned file. A smelly file is an error, a failure or skipped by
+// the highest severity among its rule hits.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitFailure `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// renderJUnit writes list as a JUnit XML test suite. Scan time is not
+// tracked per file, so every time attribute is 0.
+func renderJUnit(w io.Writer, list []Result) {
+	suite := buildJUnit(list)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		slog.Error("junit write failed", "err", err)
+		return
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		slog.Error("junit encode failed", "err", err)
+		return
+	}
+	fmt.Fprintln(w)
+}
+
+// buildJUnit turns results into a suite. Smelly files carry their rule
+// breakdown: as an error when an error-severity rule hit, as a failure
+// when a warn one did, else skipped.
+func buildJUnit(list []Result) junitSuite {
+	suite := junitSuite{Name: toolName, Tests: len(list), Time: "0", Cases: make([]junitCase, 0, len(list))}
+	for _, r := range list {
+		tc := junitCase{Name: displayPath(r.Path), ClassName: toolName, Time: "0"}
+		if r.Smelly {
+			f := &junitFailure{
+				Message: "score=" + FormatScore(r.Score),
+				Type:    "smelly",
+				Text:    junitBreakdown(r),
+			}
+			switch maxSeverity(r) {
+			case SeverityError:
+				suite.Errors++
+				tc.Error = f
+			case SeverityInfo:
+				suite.Skipped++
+				tc.Skipped = f
+			default:
+				suite.Failures++
+				tc.Failure = f
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return suite
+}
+
+// junitBreakdown lists each rule hit on its own line, sorted by name.
+func junitBreakdown(r Result) string {
+	var b strings.Builder
+	for _, n := range hitNames(r) {
+		h := r.Detail[n]
+		fmt.Fprintf(&b, "%s × %d (weight %d)%s%s\n", n, h.Count, h.Rule.Weight, cappedNote(h), formatLines(h.Lines))
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	list := []Result{
+		{
+			Path:   "smelly.md",
+			Score:  42,
+			Smelly: true,
+			Detail: map[string]RuleHit{
+				"em-dash": {Rule: Rule{Name: "em-dash", Weight: 3}, Count: 4, Scored: 4, Lines: []int{2, 7}},
+				"delve":   {Rule: Rule{Name: "delve", Weight: 10}, Count: 3, Scored: 3},
+			},
+		},
+		{Path: "clean.md", Score: 3},
+		{Path: StdinPath, Score: 0},
+	}
+
+	var buf bytes.Buffer
+	assert.True(t, Render(&buf, list, Config{Format: FormatJUnit}).AnyErrors)
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var suite junitSuite
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suite))
+	assert.Equal(t, "synthsniff", suite.Name)
+	assert.Equal(t, 3, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, "0", suite.Time)
+	require.Len(t, suite.Cases, 3)
+
+	failed := suite.Cases[0]
+	assert.Equal(t, "smelly.md", failed.Name)
+	require.NotNil(t, failed.Failure)
+	assert.Equal(t, "score=42", failed.Failure.Message)
+	assert.Equal(t, "delve × 3 (weight 10)\nem-dash × 4 (weight 3) lines 2, 7\n", failed.Failure.Text)
+	assert.Equal(t, 1, strings.Count(buf.String(), "<failure "), "exactly one failure element")
+
+	assert.Nil(t, suite.Cases[1].Failure, "clean files pass")
+	assert.Equal(t, "<stdin>", suite.Cases[2].Name)
+}
+
+func TestRenderStreamJUnit(t *testing.T) {
+	ch := make(chan Result, 1)
+	ch <- Result{Path: "a.md", Score: 1}
+	close(ch)
+
+	var buf bytes.Buffer
+	assert.Equal(t, RenderResult{}, RenderStream(&buf, ch, Config{Format: FormatJUnit}))
+	var suite junitSuite
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suite))
+	assert.Equal(t, 1, suite.Tests)
+	assert.Zero(t, suite.Failures)
+}
+
+// TestRenderJUnitSeverity verifies a smelly file's case follows its
+// highest rule severity: error, failure or skipped.
+func TestRenderJUnitSeverity(t *testing.T) {
+	hit := func(sev string) map[string]RuleHit {
+		return map[string]RuleHit{"r": {Rule: Rule{Name: "r", Weight: 1, Severity: sev}, Count: 1, Scored: 1}}
+	}
+	suite := buildJUnit([]Result{
+		{Path: "error.md", Score: 40, Smelly: true, Detail: hit(SeverityError)},
+		{Path: "warn.md", Score: 40, Smelly: true, Detail: hit("")},
+		{Path: "info.md", Score: 40, Smelly: true, Detail: hit(SeverityInfo)},
+	})
+	assert.Equal(t, 1, suite.Errors)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	assert.NotNil(t, suite.Cases[0].Error)
+	assert.NotNil(t, suite.Cases[1].Failure)
+	assert.NotNil(t, suite.Cases[2].Skipped)
+}
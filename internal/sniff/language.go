@@ -0,0 +1,144 @@
+package sniff
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languageExts maps lower-cased file extensions to language names.
+var languageExts = map[string]string{
+	".go":       "go",
+	".py":       "python",
+	".pyi":      "python",
+	".pyw":      "python",
+	".js":       "javascript",
+	".mjs":      "javascript",
+	".cjs":      "javascript",
+	".jsx":      "javascript",
+	".ts":       "typescript",
+	".tsx":      "typescript",
+	".rs":       "rust",
+	".java":     "java",
+	".kt":       "kotlin",
+	".swift":    "swift",
+	".c":        "c",
+	".h":        "c",
+	".cc":       "cpp",
+	".cpp":      "cpp",
+	".cxx":      "cpp",
+	".hpp":      "cpp",
+	".cs":       "csharp",
+	".rb":       "ruby",
+	".php":      "php",
+	".pl":       "perl",
+	".sh":       "shell",
+	".bash":     "shell",
+	".zsh":      "shell",
+	".sql":      "sql",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".mdx":      "markdown",
+	".rst":      "rst",
+	".txt":      "text",
+	".html":     "html",
+	".htm":      "html",
+	".css":      "css",
+	".xml":      "xml",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".toml":     "toml",
+}
+
+// shebangLanguages maps interpreter names from a "#!" line to languages.
+var shebangLanguages = map[string]string{
+	"python":  "python",
+	"python2": "python",
+	"python3": "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"dash":    "shell",
+}
+
+// languageMagic maps lower-cased content prefixes to languages, for
+// files whose name and first line tell nothing.
+var languageMagic = []struct{ prefix, lang string }{
+	{"<?php", "php"},
+	{"<?xml", "xml"},
+	{"<!doctype html", "html"},
+	{"<html", "html"},
+}
+
+// detectLanguage guesses the language of a file from its extension, then
+// its shebang line, then its first bytes; "" when none of them tells.
+func detectLanguage(name, content string) string {
+	if lang, ok := languageExts[strings.ToLower(filepath.Ext(name))]; ok {
+		return lang
+	}
+	if lang := shebangLanguage(content); lang != "" {
+		return lang
+	}
+	head := content
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	head = strings.ToLower(strings.TrimLeft(head, "\ufeff \t\r\n"))
+	for _, m := range languageMagic {
+		if strings.HasPrefix(head, m.prefix) {
+			return m.lang
+		}
+	}
+	return ""
+}
+
+// shebangLanguage reads the interpreter from a "#!" first line, looking
+// past /usr/bin/env and trailing version numbers such as python3.12.
+func shebangLanguage(content string) string {
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		// Skip env's own flags, e.g. "env -S python3 -u"
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interp = f
+				break
+			}
+		}
+	}
+	if lang, ok := shebangLanguages[interp]; ok {
+		return lang
+	}
+	return shebangLanguages[strings.TrimRight(interp, "0123456789.")]
+}
+
+// appliesToLanguage reports whether the rule runs on a file detected as
+// lang ("" when detection is off or found nothing). A rule with a
+// language filter runs only on files of that language.
+func (r Rule) appliesToLanguage(lang string) bool {
+	return r.Language == "" || (lang != "" && strings.EqualFold(r.Language, lang))
+}
+
+// languageName is lang for messages, "an unknown language" when "".
+func languageName(lang string) string {
+	if lang == "" {
+		return "an unknown language"
+	}
+	return lang
+}
@@ -0,0 +1,90 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name, path, content, want string
+	}{
+		{"go extension", "main.go", "package main\n", "go"},
+		{"extension ignores case", "README.MD", "hello", "markdown"},
+		{"env shebang", "tool", "#!/usr/bin/env python3\nprint(1)\n", "python"},
+		{"env flags", "tool", "#!/usr/bin/env -S node --no-warnings\n", "javascript"},
+		{"versioned interpreter", "tool", "#!/usr/local/bin/python3.12\n", "python"},
+		{"direct shebang", "run", "#!/bin/bash\necho hi\n", "shell"},
+		{"unknown interpreter", "run", "#!/usr/bin/awk -f\n", ""},
+		{"php magic", "index", "<?php echo 1;", "php"},
+		{"html magic", "page", "\n<!DOCTYPE html><html></html>", "html"},
+		{"nothing to go on", "notes", "just words", ""},
+		{"extension beats shebang", "x.py", "#!/bin/sh\n", "python"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectLanguage(tt.path, tt.content))
+		})
+	}
+}
+
+func TestRuleAppliesToLanguage(t *testing.T) {
+	goRule := Rule{Name: "go", Language: "Go"}
+	assert.True(t, goRule.appliesToLanguage("go"), "names ignore case")
+	assert.False(t, goRule.appliesToLanguage("python"))
+	assert.False(t, goRule.appliesToLanguage(""), "language rules need detection")
+	assert.True(t, Rule{Name: "any"}.appliesToLanguage(""))
+}
+
+// TestAnalyseDetectLanguage verifies a Go rule never fires on Python,
+// even when its extension filter lets the file through.
+func TestAnalyseDetectLanguage(t *testing.T) {
+	dir := t.TempDir()
+	py := filepath.Join(dir, "util.py")
+	require.NoError(t, os.WriteFile(py, []byte("# Helper returns the value.\n"), 0644))
+	goFile := filepath.Join(dir, "util.go")
+	require.NoError(t, os.WriteFile(goFile, []byte("// Helper returns the value.\n"), 0644))
+	rules := CompileRules([]Rule{{Name: "go-doc", Pattern: "Helper returns", Weight: 10, Language: "go", Exts: []string{".go", ".py"}}}, "")
+
+	cfg := Config{Threshold: 1, DetectLanguage: true}
+	assert.Empty(t, analyse(py, rules, cfg).Detail, "a .py file is not Go")
+	assert.Equal(t, 10.0, analyse(goFile, rules, cfg).Score)
+
+	cfg.DetectLanguage = false
+	assert.Empty(t, analyse(goFile, rules, cfg).Detail, "language rules are skipped without -detect-language")
+
+	script := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(script, []byte("#!/usr/bin/env python3\n# Helper returns the value.\n"), 0644))
+	rules = CompileRules([]Rule{{Name: "py-doc", Pattern: "Helper returns", Weight: 10, Language: "python"}}, "")
+	assert.Equal(t, 10.0, analyse(script, rules, Config{Threshold: 1, DetectLanguage: true}).Score, "found by its shebang")
+}
+
+func TestExplainLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "util.py")
+	require.NoError(t, os.WriteFile(path, []byte("Helper returns\n"), 0644))
+	rules := []Rule{{Name: "go-doc", Pattern: "Helper returns", Weight: 10, Language: "go"}}
+
+	e, err := Explain(path, rules, Config{Threshold: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "skipped, its language filter needs -detect-language", e.Steps[0].Reason)
+
+	e, err = Explain(path, rules, Config{Threshold: 1, DetectLanguage: true})
+	require.NoError(t, err)
+	assert.Equal(t, "skipped, content is python, not go", e.Steps[0].Reason)
+}
+
+// TestLanguageMatcher verifies a rule set that differs only by a
+// language filter gets its own matcher.
+func TestLanguageMatcher(t *testing.T) {
+	plain := []Rule{{Name: "x", Pattern: "Helper returns", Weight: 1}}
+	scoped := []Rule{{Name: "x", Pattern: "Helper returns", Weight: 1, Language: "go"}}
+
+	cfg := Config{Threshold: 1, DetectLanguage: true}
+	AnalyseString("Helper returns", "a.go", plain, cfg)
+	assert.Equal(t, 1.0, AnalyseString("Helper returns", "a.go", scoped, cfg).Score)
+}
@@ -0,0 +1,111 @@
+package sniff
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricsJob is the Pushgateway job label.
+const metricsJob = "synthsniff"
+
+// Metrics holds Prometheus counters for scans. Set Config.Metrics to have
+// Scan, ScanStream and the HTTP server update them; they accumulate for
+// the life of the value. All methods are safe for concurrent use and on a
+// nil *Metrics.
+type Metrics struct {
+	reg      *prometheus.Registry
+	files    prometheus.Counter
+	smelly   prometheus.Counter
+	duration prometheus.Histogram
+	ruleHits *prometheus.CounterVec
+	errors   prometheus.Counter
+}
+
+// NewMetrics registers the synthsniff metrics in a fresh registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		files: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "synthsniff_files_scanned_total",
+			Help: "Files scored.",
+		}),
+		smelly: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "synthsniff_smelly_files_total",
+			Help: "Files at or above the threshold.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "synthsniff_scan_duration_seconds",
+			Help:    "Time to score one file.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}),
+		ruleHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "synthsniff_rule_hits_total",
+			Help: "Pattern matches per rule.",
+		}, []string{"rule"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "synthsniff_errors_total",
+			Help: "Files or requests that could not be scored.",
+		}),
+	}
+	m.reg.MustRegister(m.files, m.smelly, m.duration, m.ruleHits, m.errors)
+	return m
+}
+
+// observe records one scored file.
+func (m *Metrics) observe(r Result) {
+	if m == nil {
+		return
+	}
+	m.files.Inc()
+	if r.Smelly {
+		m.smelly.Inc()
+	}
+	for name, h := range r.Detail {
+		m.ruleHits.WithLabelValues(name).Add(float64(h.Count))
+	}
+}
+
+// observeDuration records the time spent on one file.
+func (m *Metrics) observeDuration(d time.Duration) {
+	if m != nil {
+		m.duration.Observe(d.Seconds())
+	}
+}
+
+// addError records a file or request that could not be scored.
+func (m *Metrics) addError() {
+	if m != nil {
+		m.errors.Inc()
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{})
+}
+
+// WriteText writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteText(w io.Writer) error {
+	families, err := m.reg.Gather()
+	if err != nil {
+		return err
+	}
+	for _, f := range families {
+		if _, err := expfmt.MetricFamilyToText(w, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Push sends the metrics to the Pushgateway at url, replacing the
+// previous push for the synthsniff job.
+func (m *Metrics) Push(url string) error {
+	return push.New(url, metricsJob).Gatherer(m.reg).Push()
+}
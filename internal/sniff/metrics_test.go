@@ -0,0 +1,98 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricsText returns m in the Prometheus text format.
+func metricsText(t *testing.T, m *Metrics) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteText(&buf))
+	return buf.String()
+}
+
+func TestScanMetrics(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("MARK MARK MARK"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("MARK"), 0644))
+	m := NewMetrics()
+	cfg := Config{
+		Threshold:  20,
+		Metrics:    m,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	_, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	out := metricsText(t, m)
+	assert.Contains(t, out, "synthsniff_files_scanned_total 2")
+	assert.Contains(t, out, "synthsniff_smelly_files_total 1")
+	assert.Contains(t, out, `synthsniff_rule_hits_total{rule="mark"} 4`)
+	assert.Contains(t, out, "synthsniff_scan_duration_seconds_count 2")
+	assert.Contains(t, out, "synthsniff_errors_total 0")
+
+	// Counters accumulate across scans
+	_, err = Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	assert.Contains(t, metricsText(t, m), "synthsniff_files_scanned_total 4")
+}
+
+func TestMetricsNil(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.observe(Result{Smelly: true})
+		m.observeDuration(0)
+		m.addError()
+	})
+}
+
+func TestServerMetrics(t *testing.T) {
+	srv := newTestServer(t)
+	content := base64.StdEncoding.EncodeToString([]byte("MARK MARK MARK"))
+	postScan(t, srv.URL, ScanRequest{Content: content, Name: "a.md"})
+	postScan(t, srv.URL, ScanRequest{Content: "***"})
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	out := string(b)
+	assert.Contains(t, out, "synthsniff_files_scanned_total 1")
+	assert.Contains(t, out, "synthsniff_smelly_files_total 1")
+	assert.Contains(t, out, `synthsniff_rule_hits_total{rule="mark"} 3`)
+	assert.Contains(t, out, "synthsniff_scan_duration_seconds_count 1")
+	assert.Contains(t, out, "synthsniff_errors_total 1")
+}
+
+func TestMetricsPush(t *testing.T) {
+	var gotPath, gotBody string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(gw.Close)
+
+	m := NewMetrics()
+	m.observe(Result{Smelly: true})
+	require.NoError(t, m.Push(gw.URL))
+	assert.Equal(t, "PUT /metrics/job/synthsniff", gotPath)
+	assert.True(t, strings.Contains(gotBody, "synthsniff_files_scanned_total"), "metrics are sent")
+}
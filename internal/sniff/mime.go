@@ -0,0 +1,75 @@
+package sniff
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mimeSniffLen is how much of a file http.DetectContentType looks at.
+const mimeSniffLen = 512
+
+// detectMIME returns the media type of content, without parameters.
+// Plain text that looks like Markdown is reported as text/markdown,
+// which the standard sniffer never returns.
+func detectMIME(content string) string {
+	sample := content
+	if len(sample) > mimeSniffLen {
+		sample = sample[:mimeSniffLen]
+	}
+	mime := http.DetectContentType([]byte(sample))
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	if mime == "text/plain" && looksLikeMarkdown(sample) {
+		return "text/markdown"
+	}
+	return mime
+}
+
+// looksLikeMarkdown reports whether sample has a heading or code fence,
+// or at least two list, quote or link lines.
+func looksLikeMarkdown(sample string) bool {
+	weak := 0
+	for _, line := range strings.Split(sample, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "```"), isATXHeading(line):
+			return true
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "),
+			strings.HasPrefix(line, "> "), strings.Contains(line, "]("):
+			weak++
+		}
+	}
+	return weak >= 2
+}
+
+// isATXHeading reports whether line is "# Title" through "###### Title".
+func isATXHeading(line string) bool {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	return n >= 1 && n <= 6 && n < len(line) && line[n] == ' '
+}
+
+// mimeMatches reports whether a detected type satisfies a rule's MIME,
+// which may end in "/*" to match a whole family such as "text/*".
+func mimeMatches(want, got string) bool {
+	if family, ok := strings.CutSuffix(want, "/*"); ok {
+		return strings.HasPrefix(got, family+"/")
+	}
+	return strings.EqualFold(want, got)
+}
+
+// appliesTo reports whether the rule runs on a file with this extension
+// and detected MIME type ("" when detection is off). A rule with a MIME
+// filter runs on files of that type, plus any listed extensions.
+func (r Rule) appliesTo(ext, mime string) bool {
+	if r.MIME == "" {
+		return r.appliesToExt(ext)
+	}
+	if mime != "" && mimeMatches(r.MIME, mime) {
+		return true
+	}
+	return (r.Ext != "" || len(r.Exts) > 0) && r.appliesToExt(ext)
+}
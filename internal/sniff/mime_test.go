@@ -0,0 +1,67 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMIME(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"heading", "# Notes\n\nSome text.", "text/markdown"},
+		{"code fence", "intro\n```go\nx := 1\n```\n", "text/markdown"},
+		{"lists and links", "- one\n- two [docs](https://example.com)\n", "text/markdown"},
+		{"single list item", "- just one\n", "text/plain"},
+		{"hashtag is not a heading", "#notaheading\n", "text/plain"},
+		{"plain", "hello world\n", "text/plain"},
+		{"html", "<!DOCTYPE html><html></html>", "text/html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectMIME(tt.content))
+		})
+	}
+}
+
+func TestMIMEMatches(t *testing.T) {
+	assert.True(t, mimeMatches("text/markdown", "text/markdown"))
+	assert.True(t, mimeMatches("text/*", "text/plain"))
+	assert.False(t, mimeMatches("text/*", "application/json"))
+	assert.False(t, mimeMatches("text/markdown", "text/plain"))
+}
+
+func TestRuleAppliesTo(t *testing.T) {
+	md := Rule{Name: "md", MIME: "text/markdown"}
+	assert.True(t, md.appliesTo(".txt", "text/markdown"))
+	assert.False(t, md.appliesTo(".md", ""), "MIME rules need detection")
+	assert.False(t, md.appliesTo(".md", "text/plain"))
+
+	mdOrExt := Rule{Name: "md", MIME: "text/markdown", Exts: []string{".md"}}
+	assert.True(t, mdOrExt.appliesTo(".md", ""), "listed extensions still apply")
+
+	plain := Rule{Name: "any"}
+	assert.True(t, plain.appliesTo(".go", "text/plain"))
+}
+
+func TestAnalyseDetectMIME(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# Summary\n\nIn conclusion, it works.\n"), 0644))
+	rules := []Rule{{Name: "md-conclusion", Pattern: "In conclusion", Weight: 10, MIME: "text/markdown"}}
+
+	r := analyse(path, CompileRules(rules, ""), Config{Threshold: 1})
+	assert.Empty(t, r.Detail, "MIME rules are skipped without -detect-mime")
+
+	r = analyse(path, CompileRules(rules, ""), Config{Threshold: 1, DetectMIME: true})
+	assert.Equal(t, 10.0, r.Score, "Markdown content in a .txt file is matched by type")
+
+	r = AnalyseString("In conclusion, plain text.", "notes.txt", rules, Config{Threshold: 1, DetectMIME: true})
+	assert.Empty(t, r.Detail)
+}
@@ -0,0 +1,62 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinSize(t *testing.T) {
+	dir := t.TempDir()
+	exact := filepath.Join(dir, "exact.md")
+	small := filepath.Join(dir, "small.md")
+	require.NoError(t, os.WriteFile(exact, []byte("MARK MARK!"), 0644)) // 10 bytes
+	require.NoError(t, os.WriteFile(small, []byte("MARK MARK"), 0644))  // 9 bytes
+	rules := CompileRules([]Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, "")
+	cfg := Config{Threshold: 1, MinSize: 10}
+
+	r := analyse(exact, rules, cfg)
+	assert.False(t, r.Skipped, "a file of exactly MinSize bytes is scanned")
+	assert.Equal(t, 20.0, r.Score)
+
+	r = analyse(small, rules, cfg)
+	assert.Equal(t, Result{Path: small, Skipped: true, SkipReason: SkipTooSmall}, r)
+	assert.Equal(t, r, AnalyseCompiled([]byte("MARK MARK"), small, rules, cfg), "in-memory content too")
+	assert.Equal(t, 20.0, analyse(small, rules, Config{Threshold: 1}).Score, "no minimum by default")
+
+	files, err := ListFiles(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{exact}, files)
+}
+
+func TestRenderSkipped(t *testing.T) {
+	results := []Result{
+		{Path: "big.md", Score: 30, Smelly: true},
+		{Path: "tiny.md", Skipped: true, SkipReason: SkipTooSmall},
+	}
+
+	var buf bytes.Buffer
+	Render(&buf, results, Config{Threshold: 1, Format: FormatJSON})
+	var report struct{ Results []map[string]any }
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, true, report.Results[1]["skipped"])
+	assert.Equal(t, "too small", report.Results[1]["skipReason"])
+	assert.NotContains(t, report.Results[0], "skipped")
+
+	for _, cfg := range []Config{{}, {VeryVerbose: true}} {
+		buf.Reset()
+		cfg.Color = ColorNever
+		Render(&buf, results, cfg)
+		assert.NotContains(t, buf.String(), "tiny.md", "skipped files are only listed with -vvv")
+	}
+	buf.Reset()
+	Render(&buf, results, Config{UltraVerbose: true, Color: ColorNever})
+	assert.Contains(t, buf.String(), "tiny.md (skipped: too small)")
+}
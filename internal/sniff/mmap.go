@@ -0,0 +1,38 @@
+package sniff
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// DefaultMmapThreshold is the largest file read with ReadFile instead of
+// being memory mapped, unless changed with SetMmapThreshold.
+const DefaultMmapThreshold int64 = 16 * 1024
+
+// errNotRegular is mmapFile's error for pipes, sockets and devices,
+// which are passed over rather than reported as unreadable.
+var errNotRegular = errors.New("not a regular file")
+
+// mmapThreshold is the package-wide default for Config.MmapThreshold.
+// Accessed atomically so library callers may change it between scans.
+var mmapThreshold int64 = DefaultMmapThreshold
+
+// SetMmapThreshold sets the size up to which files are read with ReadFile
+// when Config.MmapThreshold is unset; larger files are memory mapped.
+// Fast local disks favour a higher value, network file systems a lower
+// one. 0 maps every non-empty file.
+func SetMmapThreshold(n int64) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&mmapThreshold, n)
+}
+
+// mmapThreshold returns the size up to which files are read instead of
+// mapped: the configured value, else the package default.
+func (c Config) mmapThreshold() int64 {
+	if c.MmapThreshold > 0 {
+		return c.MmapThreshold
+	}
+	return atomic.LoadInt64(&mmapThreshold)
+}
@@ -0,0 +1,54 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMmapFileThreshold(t *testing.T) {
+	dir := t.TempDir()
+	at := filepath.Join(dir, "at.txt")
+	above := filepath.Join(dir, "above.txt")
+	require.NoError(t, os.WriteFile(at, []byte(strings.Repeat("a", 100)), 0644))
+	require.NoError(t, os.WriteFile(above, []byte(strings.Repeat("a", 101)), 0644))
+
+	data, mapped, err := mmapFile(at, 100)
+	require.NoError(t, err)
+	assert.False(t, mapped, "a file exactly at the threshold is read")
+	assert.Len(t, data, 100)
+
+	data, mapped, err = mmapFile(above, 100)
+	require.NoError(t, err)
+	assert.True(t, mapped, "one byte above the threshold is mapped")
+	assert.Len(t, data, 101)
+	require.NoError(t, unmapFile(data))
+}
+
+func TestConfigMmapThreshold(t *testing.T) {
+	t.Cleanup(func() { SetMmapThreshold(DefaultMmapThreshold) })
+
+	assert.Equal(t, DefaultMmapThreshold, Config{}.mmapThreshold())
+	assert.Equal(t, int64(64), Config{MmapThreshold: 64}.mmapThreshold())
+
+	SetMmapThreshold(1 << 20)
+	assert.Equal(t, int64(1<<20), Config{}.mmapThreshold(), "library default applies when unset")
+	assert.Equal(t, int64(64), Config{MmapThreshold: 64}.mmapThreshold(), "the config wins")
+}
+
+func TestAnalyseMmapThreshold(t *testing.T) {
+	logBuf := captureLog(t, slog.LevelDebug)
+	path := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("MARK MARK"), 0644))
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}
+
+	r := analyse(path, CompileRules(rules, ""), Config{Threshold: 1, MmapThreshold: 4})
+	assert.Equal(t, 20.0, r.Score, "mapped content scores the same")
+	assert.Contains(t, logBuf.String(), "mmap=true")
+}
@@ -4,7 +4,6 @@
 package sniff
 
 import (
-	"errors"
 	"os"
 	"reflect"
 	"syscall"
@@ -13,8 +12,9 @@ import (
 
 // mmapFile reads a file using memory mapping instead of ReadFile
 // This reduces syscall overhead by avoiding extra copies
+// Files up to threshold bytes are read with ReadFile instead
 // Returns (data, isMapped, error) where isMapped indicates if unmapFile needs to be called
-func mmapFile(path string) ([]byte, bool, error) {
+func mmapFile(path string, threshold int64) ([]byte, bool, error) {
 	// Get file stats first
 	fi, err := os.Stat(path)
 	if err != nil {
@@ -23,7 +23,7 @@ func mmapFile(path string) ([]byte, bool, error) {
 
 	// Skip if not a regular file
 	if !fi.Mode().IsRegular() {
-		return nil, false, errors.New("not a regular file")
+		return nil, false, errNotRegular
 	}
 
 	// Get file size
@@ -33,7 +33,7 @@ func mmapFile(path string) ([]byte, bool, error) {
 	}
 
 	// Use ReadFile for small files (faster than mmap for small files)
-	if size < 16*1024 {
+	if size <= threshold {
 		data, err := os.ReadFile(path)
 		return data, false, err // Not memory mapped
 	}
@@ -0,0 +1,58 @@
+package sniff
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+)
+
+// ndjsonSummary is the last line of -format ndjson.
+type ndjsonSummary struct {
+	Type     string `json:"type"` // always "summary"; result lines have none
+	Total    int    `json:"total"`
+	Smelly   int    `json:"smelly"`
+	Warnings int    `json:"warnings"`
+}
+
+// renderNDJSON writes one compact JSON object per result, then the
+// summary line.
+func renderNDJSON(w io.Writer, list []Result) {
+	nw := newNDJSONWriter(w)
+	for _, r := range list {
+		nw.write(r)
+	}
+	nw.finish()
+}
+
+// ndjsonWriter emits result lines as they come and counts them for the
+// summary line.
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	summary ndjsonSummary
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w), summary: ndjsonSummary{Type: "summary"}}
+}
+
+func (n *ndjsonWriter) write(r Result) {
+	n.summary.Total++
+	switch {
+	case r.Smelly:
+		n.summary.Smelly++
+	case r.Warning:
+		n.summary.Warnings++
+	}
+	n.encode(r)
+}
+
+// finish writes the summary line.
+func (n *ndjsonWriter) finish() {
+	n.encode(n.summary)
+}
+
+func (n *ndjsonWriter) encode(v any) {
+	if err := n.enc.Encode(v); err != nil {
+		slog.Error("json encode failed", "err", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderNDJSON(t *testing.T) {
+	list := []Result{
+		{Path: "a.md", Score: 42, Smelly: true, Detail: map[string]RuleHit{"em-dash": {Rule: Rule{Name: "em-dash"}, Count: 14}}},
+		{Path: "b.md", Score: 20, Warning: true},
+		{Path: "c.md"},
+	}
+
+	var buf bytes.Buffer
+	rr := Render(&buf, list, Config{Format: FormatNDJSON})
+	assert.True(t, rr.AnyErrors)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	for i, want := range list {
+		var got Result
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got), "line %d stands alone", i)
+		assert.Equal(t, want.Path, got.Path)
+		assert.Equal(t, want.Smelly, got.Smelly)
+	}
+	assert.Equal(t, `{"type":"summary","total":3,"smelly":1,"warnings":1}`, lines[3])
+
+	// Streamed results are written one line each as they arrive
+	ch := make(chan Result)
+	var out bytes.Buffer
+	done := make(chan RenderResult)
+	go func() { done <- RenderStream(&out, ch, Config{Format: FormatNDJSON}) }()
+	ch <- list[0]
+	ch <- list[2]
+	close(ch)
+	assert.True(t, (<-done).AnyErrors)
+	lines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+	assert.Equal(t, `{"type":"summary","total":2,"smelly":1,"warnings":0}`, lines[2])
+
+	out.Reset()
+	Render(&out, nil, Config{Format: FormatNDJSON})
+	assert.Equal(t, `{"type":"summary","total":0,"smelly":0,"warnings":0}`+"\n", out.String())
+}
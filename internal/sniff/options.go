@@ -0,0 +1,140 @@
+package sniff
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// Option sets one Config field, for building a Config in code.
+type Option func(*Config)
+
+// NewConfig returns the package defaults (see Config.WithDefaults) with
+// opts applied in order.
+func NewConfig(opts ...Option) Config {
+	cfg := Config{}.WithDefaults()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// ScanWithOptions is Scan with a Config built by NewConfig(opts...).
+func ScanWithOptions(ctx context.Context, roots []string, opts ...Option) ([]Result, error) {
+	return Scan(ctx, roots, NewConfig(opts...))
+}
+
+// WithConfig replaces the whole Config, e.g. to build a Scanner from one
+// filled in by hand. Options after it still apply.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+// WithThreshold sets the score at which a file is smelly.
+func WithThreshold(n float64) Option {
+	return func(c *Config) { c.Threshold = n }
+}
+
+// WithWarnThreshold sets the score from which a file is a warning.
+func WithWarnThreshold(n float64) Option {
+	return func(c *Config) { c.WarnThreshold = n }
+}
+
+// WithWorkers sets the number of parallel workers; 0 picks one per CPU.
+func WithWorkers(n int) Option {
+	return func(c *Config) { c.Workers = n }
+}
+
+// WithDictPaths adds rule dictionaries, files or http(s) URLs.
+func WithDictPaths(paths ...string) Option {
+	return func(c *Config) { c.DictPaths = append(c.DictPaths, paths...) }
+}
+
+// WithTimeout stops the scan after d, keeping partial results.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Config) { c.Timeout = d }
+}
+
+// WithIgnorePatterns adds globs of files to skip, like -exclude.
+func WithIgnorePatterns(patterns ...string) Option {
+	return func(c *Config) { c.ExcludePatterns = append(c.ExcludePatterns, patterns...) }
+}
+
+// WithNamePatterns limits the scan to files whose base name matches
+// one of these globs.
+func WithNamePatterns(patterns ...string) Option {
+	return func(c *Config) { c.NamePatterns = append(c.NamePatterns, patterns...) }
+}
+
+// WithIncludePatterns limits the scan to files matching these globs.
+func WithIncludePatterns(patterns ...string) Option {
+	return func(c *Config) { c.IncludePatterns = append(c.IncludePatterns, patterns...) }
+}
+
+// WithDisabledRules skips the named rules.
+func WithDisabledRules(names ...string) Option {
+	return func(c *Config) { c.DisabledRules = append(c.DisabledRules, names...) }
+}
+
+// WithOnlyTags loads only the rules carrying one of these tags.
+func WithOnlyTags(tags ...string) Option {
+	return func(c *Config) { c.OnlyTags = append(c.OnlyTags, tags...) }
+}
+
+// WithExcludeTags skips the rules carrying one of these tags.
+func WithExcludeTags(tags ...string) Option {
+	return func(c *Config) { c.ExcludeTags = append(c.ExcludeTags, tags...) }
+}
+
+// WithWeight gives the named rule a new weight; 0 stops it scoring.
+func WithWeight(rule string, weight int) Option {
+	return func(c *Config) {
+		c.WeightOverrides = maps.Clone(c.WeightOverrides)
+		if c.WeightOverrides == nil {
+			c.WeightOverrides = make(map[string]int)
+		}
+		c.WeightOverrides[rule] = weight
+	}
+}
+
+// WithMaxErrors stops the scan with ErrTooManyErrors once more than n
+// files could not be read; 0 means no limit.
+func WithMaxErrors(n int) Option {
+	return func(c *Config) { c.MaxErrors = n }
+}
+
+// WithMaxSize skips files larger than n bytes; 0 means no limit.
+func WithMaxSize(n int64) Option {
+	return func(c *Config) { c.MaxSize = n }
+}
+
+// WithMinSize skips files smaller than n bytes; 0 means no limit.
+func WithMinSize(n int64) Option {
+	return func(c *Config) { c.MinSize = n }
+}
+
+// WithMmapThreshold memory-maps files larger than n bytes.
+func WithMmapThreshold(n int64) Option {
+	return func(c *Config) { c.MmapThreshold = n }
+}
+
+// WithNormalize scores per KB of content instead of per file.
+func WithNormalize() Option {
+	return func(c *Config) { c.Normalize = true }
+}
+
+// WithGitignore respects .gitignore files while walking.
+func WithGitignore() Option {
+	return func(c *Config) { c.UseGitignore = true }
+}
+
+// WithAutoDiscoverRules adds the rules of every RulesFileName the walk
+// meets for the files below it.
+func WithAutoDiscoverRules() Option {
+	return func(c *Config) { c.AutoDiscoverRules = true }
+}
+
+// WithRules adds rules on top of the built-in ones and any dictionaries.
+func WithRules(rules ...Rule) Option {
+	return func(c *Config) { c.ExtraRules = append(c.ExtraRules, rules...) }
+}
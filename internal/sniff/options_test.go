@@ -0,0 +1,88 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Option
+		check func(t *testing.T, c Config)
+	}{
+		{"threshold", WithThreshold(12), func(t *testing.T, c Config) { assert.Equal(t, float64(12), c.Threshold) }},
+		{"warn threshold", WithWarnThreshold(6), func(t *testing.T, c Config) { assert.Equal(t, float64(6), c.WarnThreshold) }},
+		{"workers", WithWorkers(3), func(t *testing.T, c Config) { assert.Equal(t, 3, c.Workers) }},
+		{"dict paths", WithDictPaths("a.yaml", "b.yaml"), func(t *testing.T, c Config) {
+			assert.Equal(t, PathList{"a.yaml", "b.yaml"}, c.DictPaths)
+		}},
+		{"timeout", WithTimeout(time.Minute), func(t *testing.T, c Config) { assert.Equal(t, time.Minute, c.Timeout) }},
+		{"max errors", WithMaxErrors(5), func(t *testing.T, c Config) { assert.Equal(t, 5, c.MaxErrors) }},
+		{"ignore patterns", WithIgnorePatterns("*.txt", "vendor"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"*.txt", "vendor"}, c.ExcludePatterns)
+		}},
+		{"name patterns", WithNamePatterns("*.md", "*.txt"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"*.md", "*.txt"}, c.NamePatterns)
+		}},
+		{"include patterns", WithIncludePatterns("*.md"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"*.md"}, c.IncludePatterns)
+		}},
+		{"disabled rules", WithDisabledRules("Em dash"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"Em dash"}, c.DisabledRules)
+		}},
+		{"only tags", WithOnlyTags("structure"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"structure"}, c.OnlyTags)
+		}},
+		{"exclude tags", WithExcludeTags("typography"), func(t *testing.T, c Config) {
+			assert.Equal(t, []string{"typography"}, c.ExcludeTags)
+		}},
+		{"weight", WithWeight("em-dash", 0), func(t *testing.T, c Config) {
+			assert.Equal(t, map[string]int{"em-dash": 0}, c.WeightOverrides)
+		}},
+		{"max size", WithMaxSize(100), func(t *testing.T, c Config) { assert.Equal(t, int64(100), c.MaxSize) }},
+		{"mmap threshold", WithMmapThreshold(1 << 20), func(t *testing.T, c Config) { assert.Equal(t, int64(1<<20), c.MmapThreshold) }},
+		{"normalize", WithNormalize(), func(t *testing.T, c Config) { assert.True(t, c.Normalize) }},
+		{"gitignore", WithGitignore(), func(t *testing.T, c Config) { assert.True(t, c.UseGitignore) }},
+		{"auto-discover rules", WithAutoDiscoverRules(), func(t *testing.T, c Config) { assert.True(t, c.AutoDiscoverRules) }},
+		{"rules", WithRules(Rule{Name: "x", Pattern: "x"}), func(t *testing.T, c Config) {
+			assert.Equal(t, []Rule{{Name: "x", Pattern: "x"}}, c.ExtraRules)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			tt.opt(&c)
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	assert.Equal(t, Config{}.WithDefaults(), NewConfig())
+
+	c := NewConfig(WithThreshold(5), WithDictPaths("a.yaml"), WithDictPaths("b.yaml"), WithThreshold(7))
+	assert.Equal(t, float64(7), c.Threshold, "later options win")
+	assert.Equal(t, PathList{"a.yaml", "b.yaml"}, c.DictPaths)
+	assert.Equal(t, FormatText, c.Format, "defaults stay")
+}
+
+func TestScanWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("delve delve delve"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("delve"), 0o644))
+
+	results, err := ScanWithOptions(context.Background(), []string{dir},
+		WithRules(Rule{Name: "delve", Pattern: "delve", Weight: 1}), WithIgnorePatterns("*.txt"), WithThreshold(3), WithWorkers(1))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, filepath.Join(dir, "a.md"), results[0].Path)
+	assert.True(t, results[0].Smelly)
+}
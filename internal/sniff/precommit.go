@@ -0,0 +1,125 @@
+package sniff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PreCommitHook is one hook as pre-commit's YAML files describe it.
+type PreCommitHook struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Entry       string   `yaml:"entry"`
+	Language    string   `yaml:"language"`
+	Types       []string `yaml:"types,flow"`
+}
+
+// DefaultPreCommitHook runs the installed sniff4ai binary in CI mode over
+// the files pre-commit passes, so a commit adding smelly text fails.
+var DefaultPreCommitHook = PreCommitHook{
+	ID:          "synthsniff",
+	Name:        "synthsniff",
+	Description: "Flag text that smells AI-generated",
+	Entry:       "sniff4ai -ci -quiet -summary off",
+	Language:    "system",
+	Types:       []string{"file"},
+}
+
+// WritePreCommitHooks writes h as a .pre-commit-hooks.yaml hook list.
+func WritePreCommitHooks(w io.Writer, h PreCommitHook) error {
+	return writeYAML(w, []PreCommitHook{h})
+}
+
+// InstallPreCommitHook adds h as a local repo hook to the pre-commit
+// config at path, creating the file if needed. It reports false, and
+// leaves the file alone, when a hook with h's id is already there.
+// Comments survive, but the file is re-indented.
+func InstallPreCommitHook(path string, h PreCommitHook) (bool, error) {
+	var doc yaml.Node
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return false, err
+	default:
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return false, fmt.Errorf("%s: not a pre-commit config", path)
+	}
+
+	repos := mappingValue(root, "repos")
+	switch {
+	case repos == nil:
+		repos = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "repos"}, repos)
+	case repos.Tag == "!!null":
+		*repos = yaml.Node{Kind: yaml.SequenceNode}
+	case repos.Kind != yaml.SequenceNode:
+		return false, fmt.Errorf("%s: repos is not a list", path)
+	}
+	var config struct {
+		Repos []struct {
+			Hooks []struct {
+				ID string `yaml:"id"`
+			} `yaml:"hooks"`
+		} `yaml:"repos"`
+	}
+	if err := root.Decode(&config); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, r := range config.Repos {
+		for _, hook := range r.Hooks {
+			if hook.ID == h.ID {
+				return false, nil
+			}
+		}
+	}
+
+	var repo yaml.Node
+	if err := repo.Encode(struct {
+		Repo  string          `yaml:"repo"`
+		Hooks []PreCommitHook `yaml:"hooks"`
+	}{"local", []PreCommitHook{h}}); err != nil {
+		return false, err
+	}
+	repos.Content = append(repos.Content, &repo)
+
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, &doc); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// mappingValue returns the value node for key in the mapping m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// writeYAML encodes v with two-space indentation.
+func writeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
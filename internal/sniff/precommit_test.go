@@ -0,0 +1,89 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWritePreCommitHooks(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePreCommitHooks(&buf, DefaultPreCommitHook))
+
+	var hooks []map[string]any
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &hooks))
+	require.Len(t, hooks, 1)
+	assert.Equal(t, "synthsniff", hooks[0]["id"])
+	assert.Equal(t, "sniff4ai -ci -quiet -summary off", hooks[0]["entry"])
+	assert.Equal(t, "system", hooks[0]["language"])
+	assert.Equal(t, []any{"file"}, hooks[0]["types"])
+	assert.Contains(t, buf.String(), "types: [file]")
+}
+
+// preCommitConfig is the part of .pre-commit-config.yaml the tests read.
+type preCommitConfig struct {
+	Repos []struct {
+		Repo  string          `yaml:"repo"`
+		Rev   string          `yaml:"rev"`
+		Hooks []PreCommitHook `yaml:"hooks"`
+	} `yaml:"repos"`
+}
+
+func readPreCommitConfig(t *testing.T, path string) preCommitConfig {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var c preCommitConfig
+	require.NoError(t, yaml.Unmarshal(b, &c))
+	return c
+}
+
+func TestInstallPreCommitHook(t *testing.T) {
+	t.Run("creates the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".pre-commit-config.yaml")
+		added, err := InstallPreCommitHook(path, DefaultPreCommitHook)
+		require.NoError(t, err)
+		assert.True(t, added)
+
+		c := readPreCommitConfig(t, path)
+		require.Len(t, c.Repos, 1)
+		assert.Equal(t, "local", c.Repos[0].Repo)
+		assert.Equal(t, []PreCommitHook{DefaultPreCommitHook}, c.Repos[0].Hooks)
+
+		added, err = InstallPreCommitHook(path, DefaultPreCommitHook)
+		require.NoError(t, err)
+		assert.False(t, added, "installed once")
+		assert.Len(t, readPreCommitConfig(t, path).Repos, 1)
+	})
+
+	t.Run("appends to existing repos", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".pre-commit-config.yaml")
+		existing := "# checks\nrepos:\n  - repo: https://github.com/pre-commit/pre-commit-hooks\n    rev: v4.6.0\n    hooks:\n      - id: trailing-whitespace\n"
+		require.NoError(t, os.WriteFile(path, []byte(existing), 0644))
+
+		added, err := InstallPreCommitHook(path, DefaultPreCommitHook)
+		require.NoError(t, err)
+		assert.True(t, added)
+
+		c := readPreCommitConfig(t, path)
+		require.Len(t, c.Repos, 2)
+		assert.Equal(t, "v4.6.0", c.Repos[0].Rev)
+		assert.Equal(t, "synthsniff", c.Repos[1].Hooks[0].ID)
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "# checks")
+	})
+
+	t.Run("rejects other documents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".pre-commit-config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("repos: 3\n"), 0644))
+		_, err := InstallPreCommitHook(path, DefaultPreCommitHook)
+		assert.Error(t, err)
+	})
+}
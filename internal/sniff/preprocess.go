@@ -0,0 +1,223 @@
+package sniff
+
+import "strings"
+
+// markdownExts are the extensions stripCodeBlocks treats as Markdown.
+var markdownExts = map[string]bool{".md": true, ".markdown": true, ".mdx": true}
+
+// preprocess returns base, a file's content in v's form, with what the
+// view leaves out blanked: everything but the front matter for
+// front-matter rules, else the front matter with StripFrontMatter and
+// code blocks for v.strip or ExcludeCodeBlocks. Only Markdown has front
+// matter and code blocks; elsewhere a front-matter view is empty.
+func preprocess(base, ext string, v view, cfg Config) string {
+	if !markdownExts[strings.ToLower(ext)] {
+		if v.front {
+			return ""
+		}
+		return base
+	}
+	meta, body := extractFrontMatter(base)
+	if v.front {
+		if meta == "" {
+			return ""
+		}
+		return meta + blankText(body)
+	}
+	if cfg.StripFrontMatter && meta != "" {
+		base = blankText(meta) + body
+	}
+	if v.strip || cfg.ExcludeCodeBlocks {
+		base = stripCodeBlocks(base, ext)
+	}
+	return base
+}
+
+// extractFrontMatter splits YAML front matter between "---" lines (or
+// "---" and "...") or TOML front matter between "+++" lines from the
+// start of content. meta keeps the delimiters and body starts after the
+// closing one; without a complete block meta is "" and body is content.
+func extractFrontMatter(content string) (meta, body string) {
+	start := 0
+	if strings.HasPrefix(content, "\ufeff") {
+		start = len("\ufeff")
+	}
+	line, off, ok := nextLine(content, start)
+	if !ok {
+		return "", content
+	}
+	var closers []string
+	switch strings.TrimRight(line, " \t\r") {
+	case "---":
+		closers = []string{"---", "..."}
+	case "+++":
+		closers = []string{"+++"}
+	default:
+		return "", content
+	}
+	for off < len(content) {
+		var end int
+		line, end, _ = nextLine(content, off)
+		for _, c := range closers {
+			if strings.TrimRight(line, " \t\r") == c {
+				return content[:end], content[end:]
+			}
+		}
+		off = end
+	}
+	return "", content
+}
+
+// nextLine returns the line starting at off without its newline, the
+// offset after that newline, and whether the line ended in one.
+func nextLine(content string, off int) (line string, next int, newline bool) {
+	i := strings.IndexByte(content[off:], '\n')
+	if i < 0 {
+		return content[off:], len(content), false
+	}
+	return content[off : off+i], off + i + 1, true
+}
+
+// blankText turns every byte of s but newlines into a space, keeping
+// offsets and line numbers.
+func blankText(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c != '\n' {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// stripCodeBlocks blanks the inside of fenced code blocks (``` and ~~~)
+// and <code> elements in Markdown, leaving other files alone. Every
+// stripped byte but a newline becomes a space, so offsets and line
+// numbers still match the original content. The fences and tags stay,
+// and a block left open runs to the end of the file.
+func stripCodeBlocks(content, ext string) string {
+	if !markdownExts[strings.ToLower(ext)] {
+		return content
+	}
+	var b []byte // copy of content, made on the first blanked byte
+	blank := func(from, to int) {
+		if from >= to {
+			return
+		}
+		if b == nil {
+			b = []byte(content)
+		}
+		for i := from; i < to; i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+	}
+
+	// Fenced blocks, line by line
+	var fence string // the opening fence while inside a block
+	for off := 0; off < len(content); {
+		end := strings.IndexByte(content[off:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += off
+		}
+		line := content[off:end]
+		switch {
+		case fence == "":
+			fence = openingFence(line)
+		case isClosingFence(line, fence):
+			fence = ""
+		default:
+			blank(off, end)
+		}
+		off = end + 1
+	}
+
+	// <code> elements in what is left
+	text := content
+	if b != nil {
+		text = string(b)
+	}
+	for off := 0; ; {
+		open, ok := nextCodeTag(text, off)
+		if !ok {
+			break
+		}
+		end := indexFoldASCII(text[open:], "</code>")
+		if end < 0 {
+			blank(open, len(text))
+			break
+		}
+		blank(open, open+end)
+		off = open + end + len("</code>")
+	}
+
+	if b == nil {
+		return content
+	}
+	return string(b)
+}
+
+// openingFence returns the fence a line opens, e.g. "```" or "~~~~", or
+// "" when it opens none. Up to three spaces of indent are allowed.
+func openingFence(line string) string {
+	line = strings.TrimRight(line, "\r")
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || trimmed == "" {
+		return ""
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+	if n < 3 || (c == '`' && strings.IndexByte(trimmed[n:], '`') >= 0) {
+		return "" // a backtick info string may not hold backticks
+	}
+	return trimmed[:n]
+}
+
+// isClosingFence reports whether line closes a block opened with fence:
+// the same character at least as many times, then only spaces.
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(strings.TrimRight(line, " \t\r"), " ")
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 || len(trimmed) < len(fence) {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// nextCodeTag returns the offset just past the next "<code>" or
+// "<code attr=...>" tag at or after off, ignoring ASCII case.
+func nextCodeTag(text string, off int) (int, bool) {
+	for {
+		i := indexFoldASCII(text[off:], "<code")
+		if i < 0 {
+			return 0, false
+		}
+		i += off + len("<code")
+		if i < len(text) && (text[i] == '>' || text[i] == ' ' || text[i] == '\t' || text[i] == '\n') {
+			if end := strings.IndexByte(text[i:], '>'); end >= 0 {
+				return i + end + 1, true
+			}
+			return 0, false
+		}
+		off = i // e.g. <codeblock>
+	}
+}
+
+// indexFoldASCII is strings.Index ignoring ASCII case; unlike
+// strings.ToLower it never changes byte offsets.
+func indexFoldASCII(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,128 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"backtick fence", "a\n```go\nx — y\n```\nb", "a\n```go\n       \n```\nb"},
+		{"tilde fence", "~~~\nx\n~~~~\nz", "~~~\n \n~~~~\nz"},
+		{"shorter fence does not close", "````\nx\n```\ny\n````\n", "````\n \n   \n \n````\n"},
+		{"unclosed fence", "```\nx\ny", "```\n \n "},
+		{"inline code tag", "a <code>x</code> b", "a <code> </code> b"},
+		{"code tag across lines", "<CODE class=\"q\">x\ny</Code>", "<CODE class=\"q\"> \n </Code>"},
+		{"not a code tag", "<codex>x</codex>", "<codex>x</codex>"},
+		{"indented too far", "    ```\nx\n", "    ```\nx\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripCodeBlocks(tt.content, ".md")
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, len(tt.content), len(got), "offsets are kept")
+		})
+	}
+
+	src := "```\nx\n```\n"
+	assert.Equal(t, src, stripCodeBlocks(src, ".txt"), "only Markdown is stripped")
+}
+
+// TestExcludeCodeBlocks verifies a pattern inside a code block is not
+// counted while the same pattern outside is, at the right line.
+func TestExcludeCodeBlocks(t *testing.T) {
+	doc := strings.Join([]string{
+		"Intro — text", // line 1
+		"```",
+		"prompt — quoted", // line 3, inside the fence
+		"```",
+		"Outro <code>— inline</code> — end", // line 5, one inside <code>
+	}, "\n")
+	rule := Rule{Name: "em", Pattern: "—", Weight: 1}
+	cfg := Config{Threshold: 100, CollectLines: true}
+
+	r := AnalyseString(doc, "a.md", []Rule{rule}, cfg)
+	assert.Equal(t, 4, r.Detail["em"].Count, "everything counts by default")
+
+	cfg.ExcludeCodeBlocks = true
+	r = AnalyseString(doc, "a.md", []Rule{rule}, cfg)
+	assert.Equal(t, 2, r.Detail["em"].Count)
+	assert.Equal(t, []int{1, 5}, r.Detail["em"].Lines)
+
+	r = AnalyseString(doc, "a.txt", []Rule{rule}, cfg)
+	assert.Equal(t, 4, r.Detail["em"].Count, "only Markdown files are stripped")
+
+	// Per rule, alongside a rule that still sees code blocks
+	cfg.ExcludeCodeBlocks = false
+	scoped := Rule{Name: "em-prose", Pattern: "—", Weight: 1, ExcludeCodeBlocks: true}
+	r = AnalyseString(doc, "a.md", []Rule{rule, scoped}, cfg)
+	assert.Equal(t, 4, r.Detail["em"].Count)
+	assert.Equal(t, 2, r.Detail["em-prose"].Count)
+	assert.Equal(t, []int{1, 5}, r.Detail["em-prose"].Lines)
+}
+
+func TestExtractFrontMatter(t *testing.T) {
+	tests := []struct {
+		name, content, meta string
+	}{
+		{"yaml", "---\ntitle: A\n---\nBody\n", "---\ntitle: A\n---\n"},
+		{"yaml dots close", "---\ntitle: A\n...\nBody", "---\ntitle: A\n...\n"},
+		{"toml", "+++\ntitle = \"A\"\n+++\nBody", "+++\ntitle = \"A\"\n+++\n"},
+		{"crlf and trailing spaces", "--- \r\na: 1\r\n---\r\nBody", "--- \r\na: 1\r\n---\r\n"},
+		{"empty block", "---\n---\nBody", "---\n---\n"},
+		{"at end of file", "---\na: 1\n---", "---\na: 1\n---"},
+		{"byte order mark", "\ufeff---\na: 1\n---\nBody", "\ufeff---\na: 1\n---\n"},
+		{"unclosed", "---\ntitle: A\nBody\n", ""},
+		{"mixed delimiters", "---\ntitle = 1\n+++\nBody", ""},
+		{"not at the start", "Intro\n---\na: 1\n---\n", ""},
+		{"longer rule", "----\na: 1\n----\n", ""},
+		{"delimiter alone", "---", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body := extractFrontMatter(tt.content)
+			assert.Equal(t, tt.meta, meta)
+			assert.Equal(t, tt.content, meta+body)
+		})
+	}
+}
+
+// TestStripFrontMatter verifies front matter delimiters no longer trip
+// markdown-hrule, and front-matter rules see nothing but the metadata.
+func TestStripFrontMatter(t *testing.T) {
+	doc := "---\ntitle: Delve — deep\n---\n\nBody — text\n\n---\n\nMore\n"
+	cfg := Config{Threshold: 1000, CollectLines: true}
+
+	r := AnalyseString(doc, "post.md", baseRules, cfg)
+	assert.Equal(t, 2, r.Detail["markdown-hrule"].Count, "the closing delimiter and the real rule")
+	assert.Equal(t, 2, r.Detail["em-dash"].Count)
+
+	cfg.StripFrontMatter = true
+	r = AnalyseString(doc, "post.md", baseRules, cfg)
+	assert.Equal(t, 1, r.Detail["markdown-hrule"].Count)
+	assert.Equal(t, []int{6}, r.Detail["markdown-hrule"].Lines, "lines still count the front matter")
+	assert.Equal(t, 1, r.Detail["em-dash"].Count)
+
+	rules := []Rule{
+		{Name: "delve-meta", Pattern: "Delve", Weight: 1, FrontMatterOnly: true},
+		{Name: "dash-meta", Pattern: "—", Weight: 1, FrontMatterOnly: true},
+	}
+	for _, strip := range []bool{false, true} {
+		cfg.StripFrontMatter = strip
+		r = AnalyseString(doc, "post.md", rules, cfg)
+		assert.Equal(t, 1, r.Detail["dash-meta"].Count, "only the title's dash")
+		assert.Equal(t, []int{2}, r.Detail["dash-meta"].Lines)
+		assert.Contains(t, r.Detail, "delve-meta")
+	}
+
+	r = AnalyseString("Delve —\n", "post.md", rules, cfg)
+	assert.Empty(t, r.Detail, "no front matter, nothing to match")
+	r = AnalyseString(doc, "post.txt", rules, cfg)
+	assert.Empty(t, r.Detail, "only Markdown has front matter")
+}
@@ -0,0 +1,152 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ProfileEntry holds one rule's match time on each file it ran on.
+type ProfileEntry struct {
+	Durations []time.Duration
+}
+
+// ruleProfile maps rule names to their match times.
+type ruleProfile map[string]*ProfileEntry
+
+// add records one match time for rule.
+func (p ruleProfile) add(rule string, d time.Duration) {
+	e, ok := p[rule]
+	if !ok {
+		e = &ProfileEntry{}
+		p[rule] = e
+	}
+	e.Durations = append(e.Durations, d)
+}
+
+// merge appends other's match times to p's.
+func (p ruleProfile) merge(other ruleProfile) {
+	for rule, o := range other {
+		e, ok := p[rule]
+		if !ok {
+			e = &ProfileEntry{}
+			p[rule] = e
+		}
+		e.Durations = append(e.Durations, o.Durations...)
+	}
+}
+
+// RuleProfiler times each rule's pattern matching per file. Set
+// Config.Profiler to one from NewRuleProfiler; scan workers keep their
+// own timings and merge them in when they finish, so matching takes no
+// shared lock. Safe for concurrent use and on a nil *RuleProfiler.
+type RuleProfiler struct {
+	mu      sync.Mutex
+	parent  *RuleProfiler // set on a worker's own profiler
+	profile ruleProfile
+}
+
+// NewRuleProfiler returns an empty profiler.
+func NewRuleProfiler() *RuleProfiler {
+	return &RuleProfiler{profile: make(ruleProfile)}
+}
+
+// worker returns a profiler for one goroutine's use, merged into p by
+// flush; nil when p is nil.
+func (p *RuleProfiler) worker() *RuleProfiler {
+	if p == nil {
+		return nil
+	}
+	return &RuleProfiler{parent: p, profile: make(ruleProfile)}
+}
+
+// flush merges a worker's timings into its parent.
+func (p *RuleProfiler) flush() {
+	if p == nil || p.parent == nil {
+		return
+	}
+	p.parent.mu.Lock()
+	p.parent.profile.merge(p.profile)
+	p.parent.mu.Unlock()
+	p.profile = make(ruleProfile)
+}
+
+// add records one match time for rule. A worker's profiler belongs to
+// one goroutine and is not locked.
+func (p *RuleProfiler) add(rule string, d time.Duration) {
+	if p == nil {
+		return
+	}
+	if p.parent != nil {
+		p.profile.add(rule, d)
+		return
+	}
+	p.mu.Lock()
+	p.profile.add(rule, d)
+	p.mu.Unlock()
+}
+
+// RuleTiming summarises one rule's match times.
+type RuleTiming struct {
+	Rule  string        `json:"rule"`
+	Files int           `json:"files"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+}
+
+// Stats returns the timings recorded so far, slowest mean first, then
+// by rule name.
+func (p *RuleProfiler) Stats() []RuleTiming {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]RuleTiming, 0, len(p.profile))
+	for rule, e := range p.profile {
+		if len(e.Durations) == 0 {
+			continue
+		}
+		ds := slices.Clone(e.Durations)
+		slices.Sort(ds)
+		var total time.Duration
+		for _, d := range ds {
+			total += d
+		}
+		out = append(out, RuleTiming{
+			Rule:  rule,
+			Files: len(ds),
+			Mean:  total / time.Duration(len(ds)),
+			P50:   percentile(ds, 50),
+			P95:   percentile(ds, 95),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Mean != out[j].Mean {
+			return out[i].Mean > out[j].Mean
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
+
+// percentile returns the nearest-rank q-th percentile of sorted ds.
+func percentile(ds []time.Duration, q int) time.Duration {
+	rank := (q*len(ds) + 99) / 100 // ceil(q/100 * n)
+	return ds[max(rank, 1)-1]
+}
+
+// RenderRuleProfile prints stats as a table, one rule per line.
+func RenderRuleProfile(w io.Writer, stats []RuleTiming) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tFILES\tMEAN\tP50\tP95")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%v\n", s.Rule, s.Files, s.Mean, s.P50, s.P95)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,78 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanProfileRules(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("Let's delve into MARK — a tapestry.\n"), 0644))
+	}
+	cfg := Config{
+		Workers:    2,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+		Profiler:   NewRuleProfiler(),
+	}.WithDefaults()
+
+	_, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+
+	rules, err := EffectiveRules(cfg)
+	require.NoError(t, err)
+	stats := cfg.Profiler.Stats()
+	byRule := make(map[string]RuleTiming, len(stats))
+	for _, s := range stats {
+		byRule[s.Rule] = s
+	}
+	for _, r := range rules {
+		if !r.appliesTo(".md", "") || r.Language != "" {
+			continue
+		}
+		s, ok := byRule[r.Name]
+		if assert.True(t, ok, "no timing for %s", r.Name) {
+			assert.Equal(t, 3, s.Files, r.Name)
+			assert.LessOrEqual(t, s.P50, s.P95, r.Name)
+		}
+	}
+	for i := 1; i < len(stats); i++ {
+		assert.GreaterOrEqual(t, stats[i-1].Mean, stats[i].Mean, "slowest first")
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderRuleProfile(&buf, stats))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{"RULE", "FILES", "MEAN", "P50", "P95"}, strings.Fields(lines[0]))
+	assert.Len(t, lines, len(stats)+1)
+	assert.Contains(t, buf.String(), "mark ")
+
+	var off Config
+	assert.Nil(t, off.Profiler.Stats(), "profiling is off by default")
+}
+
+func TestRuleProfilerStats(t *testing.T) {
+	p := NewRuleProfiler()
+	w := p.worker()
+	for i := 1; i <= 20; i++ {
+		w.add("slow", time.Duration(i)*time.Millisecond)
+	}
+	p.add("fast", time.Microsecond)
+	assert.Equal(t, []RuleTiming{{Rule: "fast", Files: 1, Mean: time.Microsecond, P50: time.Microsecond, P95: time.Microsecond}},
+		p.Stats(), "a worker's timings only count once flushed")
+
+	w.flush()
+	assert.Equal(t, []RuleTiming{
+		{Rule: "slow", Files: 20, Mean: 10500 * time.Microsecond, P50: 10 * time.Millisecond, P95: 19 * time.Millisecond},
+		{Rule: "fast", Files: 1, Mean: time.Microsecond, P50: time.Microsecond, P95: time.Microsecond},
+	}, p.Stats())
+}
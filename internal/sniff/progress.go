@@ -0,0 +1,95 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ansiClearLine returns the cursor to column 0 and erases the line.
+const ansiClearLine = "\r\x1b[2K"
+
+// Progress counts files as a scan runs. Set Config.Progress to have Scan
+// and ScanStream update it. The total grows while the walk discovers
+// files, so the percentage is an estimate until the walk finishes.
+// All methods are safe for concurrent use and on a nil *Progress.
+type Progress struct {
+	found  atomic.Int64
+	done   atomic.Int64
+	smelly atomic.Int64
+}
+
+func (p *Progress) addFound() {
+	if p != nil {
+		p.found.Add(1)
+	}
+}
+
+func (p *Progress) addDone() {
+	if p != nil {
+		p.done.Add(1)
+	}
+}
+
+func (p *Progress) addSmelly() {
+	if p != nil {
+		p.smelly.Add(1)
+	}
+}
+
+// Snapshot returns files finished, files discovered so far and smelly
+// results so far.
+func (p *Progress) Snapshot() (done, total, smelly int64) {
+	if p == nil {
+		return 0, 0, 0
+	}
+	return p.done.Load(), p.found.Load(), p.smelly.Load()
+}
+
+// Report redraws a single progress line on w every interval until the
+// returned stop function is called; stop erases the line. w should be a
+// terminal, as the line is updated in place with ANSI cursor control.
+func (p *Progress) Report(w io.Writer, interval time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-quit:
+				fmt.Fprint(w, ansiClearLine)
+				return
+			case <-tick.C:
+				fmt.Fprint(w, ansiClearLine+p.line())
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+			wg.Wait()
+		})
+	}
+}
+
+// line formats the current counts, e.g.
+// "Scanning… 1234/5000 files (24%) [3 smelly]".
+func (p *Progress) line() string {
+	done, total, smelly := p.Snapshot()
+	// Archive members and late discoveries can push done past the estimate
+	if total < done {
+		total = done
+	}
+	pct := int64(100)
+	if total > 0 {
+		pct = done * 100 / total
+	}
+	return fmt.Sprintf("Scanning… %d/%d files (%d%%) [%d smelly]", done, total, pct, smelly)
+}
@@ -0,0 +1,74 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanProgress(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		content := "plain"
+		if i < 2 {
+			content = "MARK MARK"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(content), 0644))
+	}
+
+	p := &Progress{}
+	cfg := Config{
+		Threshold:  20,
+		Progress:   p,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+	_, err := Scan(context.Background(), []string{dir}, cfg)
+	require.NoError(t, err)
+
+	done, total, smelly := p.Snapshot()
+	assert.Equal(t, int64(5), done)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, int64(2), smelly)
+	assert.Equal(t, "Scanning… 5/5 files (100%) [2 smelly]", p.line())
+}
+
+func TestProgressLineEstimate(t *testing.T) {
+	p := &Progress{}
+	assert.Equal(t, "Scanning… 0/0 files (100%) [0 smelly]", p.line())
+
+	for i := 0; i < 4; i++ {
+		p.addFound()
+	}
+	p.addDone()
+	assert.Equal(t, "Scanning… 1/4 files (25%) [0 smelly]", p.line())
+
+	var nilProgress *Progress
+	nilProgress.addDone() // must not panic
+	done, total, smelly := nilProgress.Snapshot()
+	assert.Zero(t, done+total+smelly)
+}
+
+func TestProgressReport(t *testing.T) {
+	p := &Progress{}
+	p.addFound()
+	p.addDone()
+
+	var buf bytes.Buffer
+	stop := p.Report(&buf, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	stop() // idempotent
+
+	out := buf.String()
+	assert.Contains(t, out, ansiClearLine+"Scanning… 1/1 files (100%) [0 smelly]")
+	assert.True(t, strings.HasSuffix(out, ansiClearLine), "line is erased on stop")
+}
@@ -0,0 +1,52 @@
+package sniff
+
+import (
+	"sort"
+)
+
+// Proximity makes a rule fire only when two patterns occur close together,
+// e.g. "In conclusion" within 200 bytes of "Furthermore". The rule's
+// Weight is added once per file; its Pattern is ignored.
+type Proximity struct {
+	PatternA    string `json:"patternA" yaml:"patternA"`
+	PatternB    string `json:"patternB" yaml:"patternB"`
+	MaxDistance int    `json:"maxDistance" yaml:"maxDistance"` // bytes between the two matches
+}
+
+// valid reports whether both patterns are set.
+func (p *Proximity) valid() bool {
+	return p.PatternA != "" && p.PatternB != "" && p.MaxDistance >= 0
+}
+
+// find returns the offset of the first PatternA occurrence with a
+// PatternB occurrence at most MaxDistance bytes away, in either order.
+// Distance is the gap between the two matches, so touching or
+// overlapping matches are 0 bytes apart.
+func (p *Proximity) find(content string) (int, bool) {
+	if !p.valid() {
+		return 0, false
+	}
+	offsA := matchOffsets(content, p.PatternA)
+	offsB := matchOffsets(content, p.PatternB)
+	lenA, lenB := len(p.PatternA), len(p.PatternB)
+
+	for _, a := range offsA {
+		// The nearest B on each side is the only candidate worth checking
+		j := sort.SearchInts(offsB, a)
+		if j < len(offsB) && gap(offsB[j]-a-lenA) <= p.MaxDistance {
+			return a, true
+		}
+		if j > 0 && gap(a-offsB[j-1]-lenB) <= p.MaxDistance {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+// gap clamps a negative distance (overlap) to zero.
+func gap(d int) int {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
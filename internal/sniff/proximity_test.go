@@ -0,0 +1,83 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProximityFind(t *testing.T) {
+	p := &Proximity{PatternA: "In conclusion", PatternB: "Furthermore", MaxDistance: 10}
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		ok      bool
+	}{
+		{"B after A within range", "In conclusion, yes. Furthermore", 0, true},
+		{"B before A within range", "xx Furthermore, In conclusion", 16, true},
+		{"too far apart", "In conclusion" + strings.Repeat(".", 11) + "Furthermore", 0, false},
+		{"exactly at the limit", "In conclusion" + strings.Repeat(".", 10) + "Furthermore", 0, true},
+		{"second A is the close one", "In conclusion" + strings.Repeat(".", 50) + "In conclusion Furthermore", 63, true},
+		{"only A", "In conclusion", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.find(tt.content)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	_, ok := (&Proximity{PatternA: "a", MaxDistance: 5}).find("a a")
+	assert.False(t, ok, "missing PatternB never matches")
+}
+
+func TestAnalyseProximity(t *testing.T) {
+	rules := []Rule{
+		{Name: "plain", Pattern: "Furthermore", Weight: 1},
+		{
+			Name:      "essay",
+			Weight:    20,
+			Proximity: &Proximity{PatternA: "In conclusion", PatternB: "Furthermore", MaxDistance: 200},
+		},
+	}
+	cfg := Config{Threshold: 20, CollectLines: true}
+
+	near := AnalyseString("Intro.\nFurthermore, x.\nIn conclusion, y.\n", "a.txt", rules, cfg)
+	assert.Equal(t, 21.0, near.Score)
+	assert.True(t, near.Smelly)
+	assert.Equal(t, 1, near.Detail["essay"].Count, "weight is added once")
+	assert.Equal(t, []int{3}, near.Detail["essay"].Lines)
+
+	far := AnalyseString("Furthermore"+strings.Repeat(" ", 300)+"In conclusion", "a.txt", rules, cfg)
+	assert.Equal(t, 1.0, far.Score)
+	assert.NotContains(t, far.Detail, "essay")
+}
+
+func TestLoadProximityRule(t *testing.T) {
+	dict := `- name: essay
+  weight: 20
+  proximity:
+    patternA: In conclusion
+    patternB: Furthermore
+    maxDistance: 200
+`
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(dict), 0644))
+
+	set, err := LoadRules([]string{path})
+	require.NoError(t, err)
+	rules := set.Active()
+	got := rules[len(rules)-1]
+	assert.Equal(t, &Proximity{PatternA: "In conclusion", PatternB: "Furthermore", MaxDistance: 200}, got.Proximity)
+	assert.Equal(t, "In conclusion <=200=> Furthermore", got.displayPattern())
+}
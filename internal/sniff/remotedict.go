@@ -0,0 +1,205 @@
+package sniff
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDictTimeout bounds the download of one remote dict.
+const DefaultDictTimeout = 10 * time.Second
+
+// maxRemoteDictSize caps a remote dict so a bad URL cannot exhaust memory.
+const maxRemoteDictSize = 16 << 20
+
+// dictFetcher downloads http(s) dicts. With a cache dir it keeps a copy of
+// each one, reused while its Cache-Control max-age lasts and as a
+// fallback when the server cannot be reached.
+type dictFetcher struct {
+	timeout  time.Duration // 0 = DefaultDictTimeout
+	insecure bool          // accept self-signed and otherwise invalid certs
+	cacheDir string        // "" = no offline copies
+}
+
+// newDictFetcher returns the fetcher for cfg's dict settings.
+func newDictFetcher(cfg Config) dictFetcher {
+	return dictFetcher{timeout: cfg.DictTimeout, insecure: cfg.InsecureDict, cacheDir: cfg.CacheDir}
+}
+
+// dictMeta is the sidecar stored next to a cached dict.
+type dictMeta struct {
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// isRemoteDict reports whether a dict path is an http or https URL.
+func isRemoteDict(p string) bool {
+	return strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://")
+}
+
+// remoteDictName returns the URL path, whose extension picks the format.
+func remoteDictName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Path
+	}
+	return rawURL
+}
+
+// loadRemoteDict fetches and parses the dict at rawURL. Word lists are
+// resolved on the local disk, so remote dicts cannot use them.
+func loadRemoteDict(rawURL string, f dictFetcher) ([]Rule, error) {
+	b, err := f.fetch(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("dict %s: %w", rawURL, err)
+	}
+	ext, err := decodeDict(rawURL, dictFormat(remoteDictName(rawURL)), b)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ext {
+		if r.WordList != "" {
+			return nil, fmt.Errorf("dict %s: rule %s: wordList is not supported in remote dicts", rawURL, r.Name)
+		}
+	}
+	return ext, nil
+}
+
+// fetch returns the body of rawURL, from the cache while it is fresh.
+func (f dictFetcher) fetch(rawURL string) ([]byte, error) {
+	cached, meta, haveCache := f.readCache(rawURL)
+	if haveCache && time.Now().Before(meta.Expires) {
+		return cached, nil
+	}
+
+	b, maxAge, store, err := f.download(rawURL)
+	if err != nil {
+		if haveCache {
+			slog.Warn("remote dict unavailable, using cached copy", "url", rawURL, "err", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if store {
+		f.writeCache(rawURL, b, dictMeta{URL: rawURL, Expires: time.Now().Add(maxAge)})
+	}
+	return b, nil
+}
+
+// download performs the GET and reads the caching directives.
+func (f dictFetcher) download(rawURL string) (body []byte, maxAge time.Duration, store bool, err error) {
+	timeout := f.timeout
+	if timeout <= 0 {
+		timeout = DefaultDictTimeout
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if f.insecure {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Timeout: timeout, Transport: tr}
+
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, false, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxRemoteDictSize+1))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(body) > maxRemoteDictSize {
+		return nil, 0, false, fmt.Errorf("larger than %d bytes", maxRemoteDictSize)
+	}
+	maxAge, store = parseCacheControl(resp.Header.Get("Cache-Control"))
+	return body, maxAge, store, nil
+}
+
+// parseCacheControl returns how long a response stays fresh and whether
+// it may be stored at all. Without max-age it is stale at once, so the
+// copy only serves as an offline fallback.
+func parseCacheControl(h string) (maxAge time.Duration, store bool) {
+	store = true
+	noCache := false
+	for _, d := range strings.Split(h, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-store":
+			store = false
+		case d == "no-cache":
+			noCache = true
+		case strings.HasPrefix(d, "max-age="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(d, "max-age=")); err == nil && n > 0 {
+				maxAge = time.Duration(n) * time.Second
+			}
+		}
+	}
+	if noCache {
+		maxAge = 0
+	}
+	return maxAge, store
+}
+
+// cachePath returns the offline copy's path: rules-<hash> plus the URL's
+// extension, .yaml when it has none.
+func (f dictFetcher) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	ext := strings.ToLower(path.Ext(remoteDictName(rawURL)))
+	if ext == "" {
+		ext = ".yaml"
+	}
+	return filepath.Join(f.cacheDir, "rules-"+hex.EncodeToString(sum[:8])+ext)
+}
+
+// readCache returns the cached body and its metadata, if both exist.
+func (f dictFetcher) readCache(rawURL string) ([]byte, dictMeta, bool) {
+	var meta dictMeta
+	if f.cacheDir == "" {
+		return nil, meta, false
+	}
+	p := f.cachePath(rawURL)
+	mb, err := os.ReadFile(p + ".meta")
+	if err != nil || json.Unmarshal(mb, &meta) != nil || meta.URL != rawURL {
+		return nil, meta, false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, meta, false
+	}
+	return b, meta, true
+}
+
+// writeCache stores the body and its metadata. Failures only cost the
+// offline copy, so they are reported and otherwise ignored.
+func (f dictFetcher) writeCache(rawURL string, b []byte, meta dictMeta) {
+	if f.cacheDir == "" {
+		return
+	}
+	p := f.cachePath(rawURL)
+	mb, err := json.Marshal(meta)
+	if err == nil {
+		err = os.MkdirAll(f.cacheDir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(p, b, 0644)
+	}
+	if err == nil {
+		err = os.WriteFile(p+".meta", mb, 0644)
+	}
+	if err != nil {
+		slog.Warn("dict cache write failed", "err", err)
+	}
+}
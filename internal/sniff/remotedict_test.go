@@ -0,0 +1,160 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const remoteYAML = "- {name: remote-marker, pattern: REMOTE, weight: 7}\n"
+
+// dictServer serves body at every path with the given Cache-Control and
+// counts the requests.
+func dictServer(t *testing.T, body, cacheControl string, status *atomic.Int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if code := status.Load(); code != 0 {
+			http.Error(w, "nope", int(code))
+			return
+		}
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestLoadRulesRemote(t *testing.T) {
+	var status atomic.Int32
+	srv, _ := dictServer(t, remoteYAML, "", &status)
+
+	set, err := LoadRules([]string{srv.URL + "/rules.yaml"})
+	require.NoError(t, err)
+	rules := set.Active()
+	last := rules[len(rules)-1]
+	assert.Equal(t, "remote-marker", last.Name)
+	assert.Equal(t, 7, last.Weight)
+
+	tomlSrv, _ := dictServer(t, "[[rules]]\nname = \"t\"\npattern = \"T\"\nweight = 1\n", "", &status)
+	set, err = LoadRules([]string{tomlSrv.URL + "/rules.toml?v=2"})
+	require.NoError(t, err, "the URL path's extension selects TOML")
+	rules = set.Active()
+	assert.Equal(t, "t", rules[len(rules)-1].Name)
+}
+
+func TestLoadRulesRemoteHTTPErrors(t *testing.T) {
+	for _, code := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusInternalServerError, http.StatusBadGateway} {
+		var status atomic.Int32
+		status.Store(int32(code))
+		srv, _ := dictServer(t, remoteYAML, "", &status)
+		_, err := LoadRules([]string{srv.URL + "/rules.yaml"})
+		assert.ErrorContains(t, err, http.StatusText(code), code)
+	}
+
+	var status atomic.Int32
+	srv, _ := dictServer(t, "- {name: w, wordList: words.txt, weight: 1}\n", "", &status)
+	_, err := LoadRules([]string{srv.URL + "/rules.yaml"})
+	assert.ErrorContains(t, err, "wordList")
+}
+
+func TestLoadRulesRemoteTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := loadRules([]string{srv.URL + "/rules.yaml"}, dictFetcher{timeout: 50 * time.Millisecond})
+	assert.Error(t, err)
+}
+
+func TestLoadRulesRemoteTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(remoteYAML))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // the rejected handshake
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	url := srv.URL + "/rules.yaml"
+
+	_, err := loadRules([]string{url}, dictFetcher{})
+	assert.Error(t, err, "self-signed certificates are rejected by default")
+
+	rules, err := loadRules([]string{url}, dictFetcher{insecure: true})
+	require.NoError(t, err)
+	assert.Equal(t, "remote-marker", rules[len(rules)-1].Name)
+}
+
+func TestLoadRulesRemoteCache(t *testing.T) {
+	var status atomic.Int32
+	srv, hits := dictServer(t, remoteYAML, "max-age=3600", &status)
+	url := srv.URL + "/rules.yaml"
+	f := dictFetcher{cacheDir: t.TempDir()}
+
+	_, err := loadRules([]string{url}, f)
+	require.NoError(t, err)
+	_, err = os.Stat(f.cachePath(url))
+	require.NoError(t, err, "the dict is stored as rules-<hash>.yaml")
+
+	rules, err := loadRules([]string{url}, f)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), hits.Load(), "a fresh copy is not fetched again")
+	assert.Equal(t, "remote-marker", rules[len(rules)-1].Name)
+
+	// Stale copies are re-fetched, and kept for when the server fails
+	staleSrv, staleHits := dictServer(t, remoteYAML, "no-cache", &status)
+	url = staleSrv.URL + "/rules.yaml"
+	_, err = loadRules([]string{url}, f)
+	require.NoError(t, err)
+	status.Store(http.StatusServiceUnavailable)
+	rules, err = loadRules([]string{url}, f)
+	require.NoError(t, err, "the offline copy is used")
+	assert.Equal(t, int32(2), staleHits.Load())
+	assert.Equal(t, "remote-marker", rules[len(rules)-1].Name)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		header string
+		maxAge time.Duration
+		store  bool
+	}{
+		{"", 0, true},
+		{"max-age=60", time.Minute, true},
+		{"public, Max-Age=120", 2 * time.Minute, true},
+		{"max-age=60, no-cache", 0, true},
+		{"no-store", 0, false},
+		{"max-age=oops", 0, true},
+	}
+	for _, tt := range tests {
+		maxAge, store := parseCacheControl(tt.header)
+		assert.Equal(t, tt.maxAge, maxAge, tt.header)
+		assert.Equal(t, tt.store, store, tt.header)
+	}
+}
+
+func TestRemoteDictPathsStayURLs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".synthsniff.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("dict: https://example.com/rules.yaml\n"), 0644))
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, PathList{"https://example.com/rules.yaml"}, cfg.DictPaths)
+	assert.Empty(t, ruleFiles(cfg, nil))
+}
@@ -1,6 +1,7 @@
 package sniff
 
 import (
+	"io"
 	"os"
 	"testing"
 )
@@ -32,7 +33,7 @@ func BenchmarkRenderJSON_1K_Results(b *testing.B) {
 
 	// Configure for JSON output
 	cfg := Config{
-		JSON: true,
+		Format: FormatJSON,
 	}
 
 	b.ResetTimer()
@@ -41,11 +42,27 @@ func BenchmarkRenderJSON_1K_Results(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		// Capture stdout to avoid console output affecting benchmark
 		captureStdoutBench(func() {
-			Render(results, cfg)
+			Render(os.Stdout, results, cfg)
 		})
 	}
 }
 
+// BenchmarkRenderHTML_1K_Results benchmarks the HTML report with a large result set
+func BenchmarkRenderHTML_1K_Results(b *testing.B) {
+	results := makeResults(1000)
+	cfg := Config{
+		Format:    FormatHTML,
+		Threshold: 30,
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Render(io.Discard, results, cfg)
+	}
+}
+
 // BenchmarkRenderText benchmarks various text rendering functions
 func BenchmarkRenderText(b *testing.B) {
 	// Test printUltra since it's called out specifically in requirements
@@ -76,7 +93,7 @@ func BenchmarkRenderText(b *testing.B) {
 
 		for i := 0; i < b.N; i++ {
 			captureStdoutBench(func() {
-				Render(results, cfg)
+				Render(os.Stdout, results, cfg)
 			})
 		}
 	})
@@ -95,7 +112,7 @@ func BenchmarkRenderText(b *testing.B) {
 
 		for i := 0; i < b.N; i++ {
 			captureStdoutBench(func() {
-				Render(results, cfg)
+				Render(os.Stdout, results, cfg)
 			})
 		}
 	})
@@ -115,15 +132,15 @@ func BenchmarkResultFunctions(b *testing.B) {
 		}
 	})
 
-	// Test anySmelly which is called for every render operation
-	b.Run("anySmelly", func(b *testing.B) {
+	// Test summarize which is called for every render operation
+	b.Run("summarize", func(b *testing.B) {
 		results := makeResults(1000) // 1000 results with ~1/3 smelly
 
 		b.ResetTimer()
 		b.ReportAllocs()
 
 		for i := 0; i < b.N; i++ {
-			_ = anySmelly(results)
+			_ = summarize(results)
 		}
 	})
 }
@@ -3,106 +3,554 @@ package sniff
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// Render prints results to stdout.
+// RenderResult summarises what Render wrote.
+type RenderResult struct {
+	AnyWarnings bool // some file scored in the warning band
+	AnyErrors   bool // some file is smelly (at or above Threshold)
+
+	timing timingStats // durations of timed results
+}
+
+// add folds one result into the summary.
+func (rr *RenderResult) add(r Result) {
+	rr.AnyWarnings = rr.AnyWarnings || r.Warning
+	rr.AnyErrors = rr.AnyErrors || r.Smelly
+	rr.timing.add(r.Duration)
+}
+
+// timingStats aggregates per-file durations for the -timing summary.
+type timingStats struct {
+	n             int
+	min, max, sum time.Duration
+}
+
+// add counts d; untimed results (0) are left out.
+func (t *timingStats) add(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if t.n == 0 || d < t.min {
+		t.min = d
+	}
+	t.max = max(t.max, d)
+	t.sum += d
+	t.n++
+}
+
+// formatDuration rounds d for display: to milliseconds from 1ms up, to
+// microseconds below.
+func formatDuration(d time.Duration) string {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// summarize returns the RenderResult for list.
+func summarize(list []Result) RenderResult {
+	var rr RenderResult
+	for _, r := range list {
+		rr.add(r)
+	}
+	return rr
+}
+
+// Render writes results to w and reports whether any file reached the
+// warning or error threshold.
 //
-// If cfg.JSON is true, it prints JSON to stdout.
-// Otherwise, it prints text to stdout.
-func Render(list []Result, cfg Config) bool {
-	if cfg.JSON {
-		return renderJSON(list)
+// cfg.Format selects JSON (laid out per cfg.JSONVersion, or a bare array
+// with FormatJSONArray), NDJSON, SARIF, HTML, CSV, JUnit, GitHub Actions
+// commands or (by default) text output. With cfg.Top only the worst files
+// are written, and with cfg.Invert only the clean ones (Result.Clean),
+// but the report still covers every result. cfg.CountOnly writes the
+// number of smelly files alone, as text or JSON.
+func Render(w io.Writer, list []Result, cfg Config) RenderResult {
+	rr := summarize(list)
+	if cfg.CountOnly {
+		renderCount(w, list, cfg)
+		return rr
+	}
+	shown := topResults(invertResults(list, cfg), cfg)
+	switch cfg.Format {
+	case FormatJSON:
+		renderJSON(w, newJSONReport(list, shown, cfg))
+		return rr
+	case FormatJSONArray:
+		renderJSONArray(w, shown)
+		return rr
+	case FormatSARIF:
+		renderSARIF(w, shown)
+		return rr
+	case FormatHTML:
+		renderHTML(w, shown, cfg)
+		return rr
+	case FormatNDJSON:
+		renderNDJSON(w, shown)
+		return rr
+	case FormatCSV:
+		renderCSV(w, shown)
+		return rr
+	case FormatJUnit:
+		renderJUnit(w, shown)
+		return rr
+	case FormatGHA:
+		renderGHA(w, shown, cfg)
+		return rr
+	}
+
+	if cfg.Print0 {
+		pw := print0Writer{w: w, invert: cfg.Invert}
+		for _, r := range shown {
+			pw.write(r)
+		}
+		return rr
+	}
+	st := newTextStyle(w, cfg)
+	for _, r := range shown {
+		printResult(w, st, r, cfg)
 	}
+	finishText(w, st, len(list), rr, cfg)
+	return rr
+}
+
+// print0Writer writes the paths of smelly files, or clean ones with
+// invert, separated by NUL bytes with none after the last, for xargs -0.
+type print0Writer struct {
+	w      io.Writer
+	invert bool
+	sep    string
+}
+
+// write prints r's path if it is smelly, or clean with invert.
+func (p *print0Writer) write(r Result) {
+	if (!p.invert && r.Smelly) || (p.invert && r.Clean()) {
+		fmt.Fprint(p.w, p.sep, r.Path)
+		p.sep = "\x00"
+	}
+}
+
+// countReport is the -count -format json output.
+type countReport struct {
+	Smelly int `json:"smelly"`
+	Clean  int `json:"clean"`
+	Total  int `json:"total"`
+}
 
+// renderCount writes how many files in list are smelly, or clean with
+// cfg.Invert, as a bare number without a newline; as JSON it writes the
+// smelly, clean and total counts.
+func renderCount(w io.Writer, list []Result, cfg Config) {
+	var c countReport
 	for _, r := range list {
 		switch {
-		case cfg.UltraVerbose:
-			printUltra(r)
-		case cfg.VeryVerbose:
-			printVery(r)
-		case cfg.Verbose && r.Smelly:
-			printSmelly(r, true)
 		case r.Smelly:
-			printSmelly(r, false)
+			c.Smelly++
+		case r.Clean():
+			c.Clean++
 		}
 	}
+	c.Total = len(list)
+	if cfg.Format == FormatJSON {
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			slog.Error("json encode failed", "err", err)
+		}
+		return
+	}
+	n := c.Smelly
+	if cfg.Invert {
+		n = c.Clean
+	}
+	fmt.Fprint(w, n)
+}
+
+// invertResults returns the clean results in list with cfg.Invert, else
+// list itself; see Result.Clean.
+func invertResults(list []Result, cfg Config) []Result {
+	if !cfg.Invert {
+		return list
+	}
+	clean := make([]Result, 0, len(list))
+	for _, r := range list {
+		if r.Clean() {
+			clean = append(clean, r)
+		}
+	}
+	return clean
+}
 
-	if cfg.UltraVerbose || cfg.VeryVerbose {
-		return anySmelly(list)
+// topResults ranks list by score, highest first and ties by path, and
+// keeps the first cfg.Top. Only smelly and warning files are ranked unless
+// cfg.TopAll is set. Without cfg.Top list is returned as is.
+func topResults(list []Result, cfg Config) []Result {
+	if cfg.Top <= 0 {
+		return list
 	}
-	if !anySmelly(list) {
-		fmt.Printf("✅ No AI smell detected in %d file(s)\n", len(list))
+	ranked := make([]Result, 0, len(list))
+	for _, r := range list {
+		if cfg.TopAll || r.Smelly || r.Warning {
+			ranked = append(ranked, r)
+		}
 	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	return ranked[:min(cfg.Top, len(ranked))]
+}
 
-	// Print loaded ignore files report
-	printIgnoreFilesReport(cfg)
+// RenderStream writes results as they arrive and reports like Render.
+// JSON is emitted as NDJSON (one object per line) and -format ndjson adds
+// its summary line; CSV rows, workflow commands, text and -print0 paths
+// are printed unsorted. JSON arrays, SARIF, HTML and JUnit need the full
+// set, as do ranking with cfg.Top, filtering with cfg.Invert and
+// counting with cfg.CountOnly, and are buffered. The channel is always drained.
+func RenderStream(w io.Writer, results <-chan Result, cfg Config) RenderResult {
+	switch {
+	case cfg.Top > 0, cfg.Invert, cfg.CountOnly, cfg.Format == FormatJSONArray, cfg.Format == FormatSARIF, cfg.Format == FormatHTML, cfg.Format == FormatJUnit:
+		var list []Result
+		for r := range results {
+			list = append(list, r)
+		}
+		return Render(w, list, cfg)
+	}
+
+	switch cfg.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		var rr RenderResult
+		for r := range results {
+			if err := enc.Encode(r); err != nil {
+				slog.Error("json encode failed", "err", err)
+			}
+			rr.add(r)
+		}
+		return rr
+	case FormatNDJSON:
+		nw := newNDJSONWriter(w)
+		var rr RenderResult
+		for r := range results {
+			nw.write(r)
+			rr.add(r)
+		}
+		nw.finish()
+		return rr
+	case FormatCSV:
+		cw := newCSVWriter(w)
+		var rr RenderResult
+		for r := range results {
+			cw.write(r)
+			rr.add(r)
+		}
+		cw.flush()
+		return rr
+	case FormatGHA:
+		var rr RenderResult
+		for r := range results {
+			printGHA(w, r, cfg)
+			rr.add(r)
+		}
+		return rr
+	}
+
+	if cfg.Print0 {
+		pw := print0Writer{w: w, invert: cfg.Invert}
+		var rr RenderResult
+		for r := range results {
+			pw.write(r)
+			rr.add(r)
+		}
+		return rr
+	}
+	st := newTextStyle(w, cfg)
+	total := 0
+	var rr RenderResult
+	for r := range results {
+		printResult(w, st, r, cfg)
+		total++
+		rr.add(r)
+	}
+	finishText(w, st, total, rr, cfg)
+	return rr
+}
+
+/* ---------- text ---------- */
+
+// printResult prints one file at the configured verbosity.
+func printResult(w io.Writer, st textStyle, r Result, cfg Config) {
+	switch {
+	case r.Err != "":
+		// Files that could not be read show at every verbosity
+		fmt.Fprintf(w, "%s%s %s\n", st.status(r), st.paint(ansiYellow, displayPath(r.Path)), st.meta("(error: "+r.Err+")"))
+		return
+	case r.Skipped:
+		// Only -vvv lists files left unscored
+		if cfg.UltraVerbose {
+			fmt.Fprintf(w, "%s%s %s\n", st.status(r), st.path(r), st.meta("(skipped: "+r.SkipReason+")"))
+		}
+		return
+	case cfg.UltraVerbose:
+		printUltra(w, st, r)
+	case cfg.VeryVerbose:
+		printVery(w, st, r)
+	case cfg.Verbose && (r.Smelly || r.Warning || cfg.Invert):
+		printSmelly(w, st, r, true)
+	case r.Smelly || r.Warning || cfg.Invert:
+		// -invert lists the clean files like smelly ones
+		printSmelly(w, st, r, false)
+	default:
+		return
+	}
+	printHeatmap(w, st, r)
+	printAliases(w, st, r)
+}
+
+// finishText prints the trailing summary.
+func finishText(w io.Writer, st textStyle, total int, rr RenderResult, cfg Config) {
+	if cfg.Normalize {
+		fmt.Fprintln(w, st.meta("Scores are per KB (raw score × 1000 / bytes); the threshold uses the same unit."))
+	}
+	verbose := cfg.UltraVerbose || cfg.VeryVerbose
+	if !verbose && !rr.AnyErrors && !rr.AnyWarnings {
+		fmt.Fprintf(w, "%s%s\n", st.icon(false), st.paint(ansiGreen, fmt.Sprintf("No AI smell detected in %d file(s)", total)))
+	}
+	if t := rr.timing; cfg.TimingMode && t.n > 0 {
+		fmt.Fprintln(w, st.meta(fmt.Sprintf("Analysis time per file: min %s, max %s, mean %s over %d file(s)",
+			formatDuration(t.min), formatDuration(t.max), formatDuration(t.sum/time.Duration(t.n)), t.n)))
+	}
+	if verbose {
+		return
+	}
 
-	return anySmelly(list)
+	// Print loaded ignore files report
+	printIgnoreFilesReport(w, st, cfg)
 }
 
 /* ---------- JSON ---------- */
 
-func renderJSON(list []Result) bool {
-	enc := json.NewEncoder(os.Stdout)
+// JSON report layouts, for Config.JSONVersion.
+const (
+	JSONReportV1 = 1 // summary, rule_stats and results
+	JSONReportV2 = 2 // version, config and scan_time first
+)
+
+// jsonReport is the JSON output: who wrote it, where and when, the scan
+// summary, rule stats with Config.RuleStats, and every result. Version 2
+// reports add the layout version, the settings that shaped the scores
+// and the scan's start time. "version" then holds the layout, so the
+// tool's own version moves to "generator_version".
+type jsonReport struct {
+	Generator        string          `json:"generator"`
+	Version          string          `json:"version"`
+	GeneratorVersion string          `json:"generator_version,omitempty"`
+	GitCommit        string          `json:"git_commit"`
+	ScanStartedAt    string          `json:"scan_started_at,omitempty"`  // RFC 3339, when Config.Started is set
+	ScanDurationMS   *int64          `json:"scan_duration_ms,omitempty"` // Config.Elapsed, with ScanStartedAt
+	Host             string          `json:"host,omitempty"`
+	Config           *jsonConfig     `json:"config,omitempty"`
+	ScanTime         string          `json:"scan_time,omitempty"` // RFC 3339, when Config.Started is set
+	Summary          ScanSummary     `json:"summary"`
+	RuleStats        []RuleAggregate `json:"rule_stats,omitempty"`
+	Results          []Result        `json:"results"`
+}
+
+// jsonConfig is the "config" block of a version 2 report.
+type jsonConfig struct {
+	Threshold float64 `json:"threshold"`
+	Rules     int     `json:"rules"` // Config.RuleCount
+	Workers   int     `json:"workers"`
+}
+
+// newJSONReport builds the report for shown, summarising every result in
+// list.
+func newJSONReport(list, shown []Result, cfg Config) jsonReport {
+	v := Version()
+	report := jsonReport{
+		Generator: toolName,
+		Version:   v.Version,
+		GitCommit: v.Commit,
+		Summary:   ComputeSummary(list, cfg.Elapsed),
+		Results:   shown,
+	}
+	if !cfg.Started.IsZero() {
+		ms := cfg.Elapsed.Milliseconds()
+		report.ScanStartedAt = cfg.Started.UTC().Format(time.RFC3339)
+		report.ScanDurationMS = &ms
+	}
+	if host, err := os.Hostname(); err == nil {
+		report.Host = host
+	}
+	if cfg.RuleStats {
+		report.RuleStats = AggregateRuleStats(list)
+	}
+	if cfg.JSONVersion == JSONReportV2 {
+		report.GeneratorVersion = report.Version
+		workers := cfg.Workers
+		if workers <= 0 {
+			workers = getMaxProcs()
+		}
+		report.Version = strconv.Itoa(JSONReportV2)
+		report.Config = &jsonConfig{Threshold: cfg.Threshold, Rules: cfg.RuleCount, Workers: workers}
+		if !cfg.Started.IsZero() {
+			report.ScanTime = cfg.Started.UTC().Format(time.RFC3339)
+		}
+	}
+	return report
+}
+
+// renderJSON writes report indented. encoding/json writes map keys in
+// sorted order, so each result's detail comes out the same every run.
+func renderJSON(w io.Writer, report jsonReport) {
+	if report.Results == nil {
+		report.Results = []Result{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		slog.Error("json encode failed", "err", err)
+	}
+}
+
+// renderJSONArray writes the results alone, as the first JSON reports
+// did.
+func renderJSONArray(w io.Writer, list []Result) {
+	if list == nil {
+		list = []Result{}
+	}
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	if err := enc.Encode(list); err != nil {
-		fmt.Fprintf(os.Stderr, "json encode error: %v\n", err)
+		slog.Error("json encode failed", "err", err)
 	}
-	return anySmelly(list)
 }
 
 /* ---------- text helpers ---------- */
 
-func anySmelly(rs []Result) bool {
-	for _, r := range rs {
-		if r.Smelly {
-			return true
-		}
+func printSmelly(w io.Writer, st textStyle, r Result, verbose bool) {
+	if verbose {
+		fmt.Fprintf(w, "%s%s %s %v\n", st.status(r), st.path(r), st.meta(scoreLabel(r, false)), hitCounts(r))
+		return
 	}
-	return false
+	fmt.Fprintf(w, "%s%s\t%s\n", st.status(r), st.path(r), st.meta(scoreLabel(r, false)))
 }
 
-func printSmelly(r Result, verbose bool) {
-	const siren = "🚨 "
-	if verbose {
-		fmt.Printf("%s%s (score %d) %v\n", siren, r.Path, r.Score, hitCounts(r))
-		return
+func printVery(w io.Writer, st textStyle, r Result) {
+	fmt.Fprintf(w, "%s%s %s\n", st.status(r), st.path(r), st.meta(scoreLabel(r, true)))
+	for _, name := range hitNames(r) {
+		h := r.Detail[name]
+		fmt.Fprintf(w, "  %s × %d%s%s\n", st.rule(name), h.Count, st.meta(cappedNote(h)), st.meta(formatLines(h.Lines)))
+		printSnippets(w, h.Snippets)
+	}
+}
+
+func printUltra(w io.Writer, st textStyle, r Result) {
+	timing := ""
+	if r.Duration > 0 {
+		timing = " " + st.meta("(analysed in "+formatDuration(r.Duration)+")")
+	}
+	fmt.Fprintf(w, "%s%s %s%s\n", st.status(r), st.path(r), st.meta(scoreLabel(r, true)), timing)
+	for _, n := range hitNames(r) {
+		h := r.Detail[n]
+		fmt.Fprintf(w, "  %s %s × %d%s %s%s\n", st.rule(h.Rule.Name), st.meta("["+h.Rule.severity()+"]"), h.Count, st.meta(cappedNote(h)),
+			st.meta(fmt.Sprintf("(pattern=%q weight=%d)", escape(h.Rule.displayPattern()), h.Rule.Weight)),
+			st.meta(formatLines(h.Lines)))
+		printSnippets(w, h.Snippets)
 	}
-	fmt.Printf("%s%s\t(score %d)\n", siren, r.Path, r.Score)
 }
 
-func printVery(r Result) {
-	icon := "✅"
-	if r.Smelly {
-		icon = "🚨"
+// scoreLabel returns "(score 42)", "(score 42, grade F)" when graded,
+// and with confidence "(score 42, confidence 73%)".
+func scoreLabel(r Result, confidence bool) string {
+	label := "(score " + FormatScore(r.Score)
+	if r.Grade != "" {
+		label += ", grade " + r.Grade
 	}
-	fmt.Printf("%s %s (score %d)\n", icon, r.Path, r.Score)
-	for name, h := range r.Detail {
-		fmt.Printf("  %s × %d\n", name, h.Count)
+	if confidence {
+		label += fmt.Sprintf(", confidence %d%%", int(math.Round(r.Confidence*100)))
 	}
+	return label + ")"
 }
 
-func printUltra(r Result) {
-	icon := "✅"
-	if r.Smelly {
-		icon = "🚨"
+// FormatScore prints a score without trailing zeros, rounded to two
+// decimals: 30, 12.5, 3.33.
+func FormatScore(s float64) string {
+	return strconv.FormatFloat(math.Round(s*100)/100, 'f', -1, 64)
+}
+
+// cappedNote renders " (scored 2)" when MaxMatches capped the hit, or
+// " (excluded)" when an exclude pattern stopped it scoring.
+func cappedNote(h RuleHit) string {
+	if h.Excluded {
+		return " (excluded)"
 	}
-	fmt.Printf("%s %s (score %d)\n", icon, r.Path, r.Score)
-	keys := make([]string, 0, len(r.Detail))
-	for k := range r.Detail {
-		keys = append(keys, k)
+	if h.Scored >= h.Count {
+		return ""
 	}
-	sort.Strings(keys)
-	for _, n := range keys {
-		h := r.Detail[n]
-		fmt.Printf("  %s × %d (pattern=%q weight=%d)\n",
-			h.Rule.Name, h.Count, escape(h.Rule.Pattern), h.Rule.Weight)
+	return fmt.Sprintf(" (scored %d)", h.Scored)
+}
+
+// maxListedLines caps the line numbers printed per rule in text output.
+const maxListedLines = 10
+
+// formatLines renders " lines 3, 7, 12", eliding past maxListedLines, or
+// "" when no lines were collected.
+func formatLines(lines []int) string {
+	switch len(lines) {
+	case 0:
+		return ""
+	case 1:
+		return " line " + strconv.Itoa(lines[0])
+	}
+	var b strings.Builder
+	b.WriteString(" lines ")
+	for i, n := range lines {
+		if i == maxListedLines {
+			fmt.Fprintf(&b, ", … +%d more", len(lines)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// printSnippets lists match context under a rule, capped like line numbers.
+func printSnippets(w io.Writer, snippets []string) {
+	for i, s := range snippets {
+		if i == maxListedLines {
+			fmt.Fprintf(w, "    … +%d more\n", len(snippets)-i)
+			return
+		}
+		fmt.Fprintf(w, "    %s\n", s)
+	}
+}
+
+// hitNames returns the names of the rules r hit, sorted, so reports
+// list them in the same order every run.
+func hitNames(r Result) []string {
+	names := make([]string, 0, len(r.Detail))
+	for n := range r.Detail {
+		names = append(names, n)
 	}
+	sort.Strings(names)
+	return names
 }
 
+// hitCounts maps each rule r hit to its count. fmt prints maps sorted by
+// key, so -v lines are stable.
 func hitCounts(r Result) map[string]int {
 	out := make(map[string]int, len(r.Detail))
 	for n, h := range r.Detail {
@@ -115,15 +563,21 @@ func escape(s string) string {
 	return strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s)
 }
 
-// printIgnoreFilesReport prints information about loaded gitignore files
-func printIgnoreFilesReport(cfg Config) {
+// printIgnoreFilesReport prints information about loaded gitignore files,
+// as collected in cfg.IgnoreStats
+func printIgnoreFilesReport(w io.Writer, st textStyle, cfg Config) {
+	ignoreFiles, synthFiles := cfg.IgnoreStats.LoadedFiles()
 	// Always print when gitignore is enabled and files are loaded
-	if !cfg.UseGitignore || len(LoadedIgnoreFiles) == 0 {
-		return
+	if cfg.UseGitignore && len(ignoreFiles) > 0 {
+		fmt.Fprintln(w, "\n"+st.meta("Loaded ignore files:"))
+		for _, path := range ignoreFiles {
+			fmt.Fprintf(w, "  - %s\n", st.meta(path))
+		}
 	}
-
-	fmt.Println("\nLoaded ignore files:")
-	for _, path := range LoadedIgnoreFiles {
-		fmt.Printf("  - %s\n", path)
+	if len(synthFiles) > 0 {
+		fmt.Fprintln(w, "\n"+st.meta("Loaded "+SynthsniffIgnoreName+" files:"))
+		for _, path := range synthFiles {
+			fmt.Fprintf(w, "  - %s\n", st.meta(path))
+		}
 	}
 }
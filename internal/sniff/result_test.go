@@ -3,27 +3,42 @@ package sniff
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// TestAnySmelly verifies detecting smelly results in a slice.
-func TestAnySmelly(t *testing.T) {
+// TestSummarize verifies detecting smelly and warning results in a slice.
+func TestSummarize(t *testing.T) {
 	tests := []struct {
 		name     string
 		results  []Result
 		expected bool
+		warnings bool
 	}{
 		{
 			name:     "empty results",
 			results:  []Result{},
 			expected: false,
 		},
+		{
+			name: "warning only",
+			results: []Result{
+				{Path: "file1.txt", Score: 10, Smelly: false},
+				{Path: "file2.txt", Score: 20, Warning: true},
+			},
+			expected: false,
+			warnings: true,
+		},
 		{
 			name: "no smelly results",
 			results: []Result{
@@ -52,8 +67,9 @@ func TestAnySmelly(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := anySmelly(tt.results)
-			assert.Equal(t, tt.expected, result)
+			rr := summarize(tt.results)
+			assert.Equal(t, tt.expected, rr.AnyErrors)
+			assert.Equal(t, tt.warnings, rr.AnyWarnings)
 		})
 	}
 }
@@ -147,7 +163,7 @@ func TestPrintSmelly(t *testing.T) {
 
 	// Test non-verbose output
 	output := captureOutput(func() {
-		printSmelly(result, false)
+		printSmelly(os.Stdout, plainStyle, result, false)
 	})
 	assert.Contains(t, output, "🚨 test.md")
 	assert.Contains(t, output, "(score 42)")
@@ -156,7 +172,7 @@ func TestPrintSmelly(t *testing.T) {
 
 	// Test verbose output
 	output = captureOutput(func() {
-		printSmelly(result, true)
+		printSmelly(os.Stdout, plainStyle, result, true)
 	})
 	assert.Contains(t, output, "🚨 test.md")
 	assert.Contains(t, output, "(score 42)")
@@ -176,8 +192,9 @@ func TestPrintVery(t *testing.T) {
 	}
 
 	smelly := Result{
-		Path:  "smelly.md",
-		Score: 42,
+		Path:       "smelly.md",
+		Score:      42,
+		Confidence: 0.73,
 		Detail: map[string]RuleHit{
 			"rule1": {Rule: Rule{Name: "rule1"}, Count: 5},
 			"rule2": {Rule: Rule{Name: "rule2"}, Count: 3},
@@ -187,18 +204,18 @@ func TestPrintVery(t *testing.T) {
 
 	// Test clean output
 	output := captureOutput(func() {
-		printVery(clean)
+		printVery(os.Stdout, plainStyle, clean)
 	})
 	assert.Contains(t, output, "✅ clean.md")
-	assert.Contains(t, output, "(score 10)")
+	assert.Contains(t, output, "(score 10, confidence 0%)")
 	assert.Contains(t, output, "rule1 × 2")
 
 	// Test smelly output
 	output = captureOutput(func() {
-		printVery(smelly)
+		printVery(os.Stdout, plainStyle, smelly)
 	})
 	assert.Contains(t, output, "🚨 smelly.md")
-	assert.Contains(t, output, "(score 42)")
+	assert.Contains(t, output, "(score 42, confidence 73%)")
 	assert.Contains(t, output, "rule1 × 5")
 	assert.Contains(t, output, "rule2 × 3")
 }
@@ -210,7 +227,7 @@ func TestPrintUltra(t *testing.T) {
 		Score: 42,
 		Detail: map[string]RuleHit{
 			"rule1": {
-				Rule:  Rule{Name: "rule1", Pattern: "pattern1", Weight: 5},
+				Rule:  Rule{Name: "rule1", Pattern: "pattern1", Weight: 5, Severity: SeverityError},
 				Count: 5,
 			},
 			"rule2": {
@@ -222,14 +239,14 @@ func TestPrintUltra(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		printUltra(result)
+		printUltra(os.Stdout, plainStyle, result)
 	})
 	assert.Contains(t, output, "🚨 test.md")
-	assert.Contains(t, output, "(score 42)")
-	assert.Contains(t, output, "rule1 × 5")
+	assert.Contains(t, output, "(score 42, confidence 0%)")
+	assert.Contains(t, output, "rule1 [error] × 5")
 	assert.Contains(t, output, "\"pattern1\"")
 	assert.Contains(t, output, "weight=5")
-	assert.Contains(t, output, "rule2 × 3")
+	assert.Contains(t, output, "rule2 [warn] × 3", "severity defaults to warn")
 	// The pattern is doubly escaped - once by the escape function, and once for string representation
 	assert.Contains(t, output, "\"pattern\\\\nwith\\\\nnewlines\"")
 	assert.Contains(t, output, "weight=3")
@@ -275,8 +292,7 @@ func TestRenderJSON(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		smelly := renderJSON(results)
-		assert.True(t, smelly)
+		renderJSON(os.Stdout, jsonReport{Summary: ComputeSummary(results, 0), Results: results})
 	})
 
 	// Verify JSON contains the expected data (accounting for possible whitespace variations)
@@ -288,38 +304,43 @@ func TestRenderJSON(t *testing.T) {
 	assert.Contains(t, output, `"smelly": true`)
 }
 
+// captureLog sends slog output at level and above to the returned buffer
+// for the rest of the test.
+func captureLog(t *testing.T, level slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
 // TestRenderJSON_EncodeError forces json.Encoder.Encode to fail so that the
-// error‑logging branch inside renderJSON (result.go:47‑48) is covered.
+// error‑logging branch inside renderJSON is covered.
 func TestRenderJSON_EncodeError(t *testing.T) {
-	// ---- 1. swap stdout/stderr ------------------------------------------------
-	origStdout, origStderr := os.Stdout, os.Stderr
+	// ---- 1. swap stdout, capture the log --------------------------------------
+	origStdout := os.Stdout
 	_, stdoutW, _ := os.Pipe() // Encode() will write to stdoutW
-	stderrR, stderrW, _ := os.Pipe()
+	logBuf := captureLog(t, slog.LevelInfo)
 
 	os.Stdout = stdoutW
-	os.Stderr = stderrW
 
 	// Close stdoutW *before* Render runs - any write now fails immediately.
 	_ = stdoutW.Close()
 
 	// ---- 2. run code under test ----------------------------------------------
 	results := []Result{{Path: "dummy", Smelly: true}}
-	smelly := Render(results, Config{JSON: true})
+	smelly := Render(os.Stdout, results, Config{Format: FormatJSON}).AnyErrors
 
-	// ---- 3. restore FDs -------------------------------------------------------
-	_ = stderrW.Close()
-	os.Stdout, os.Stderr = origStdout, origStderr
-
-	// Grab everything the code wrote to stderr.
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, stderrR)
+	// ---- 3. restore stdout ----------------------------------------------------
+	os.Stdout = origStdout
 
 	// ---- 4. assertions --------------------------------------------------------
 	if !smelly {
 		t.Fatalf("Render returned %v, want true", smelly)
 	}
-	if want := "json encode error:"; !strings.Contains(buf.String(), want) {
-		t.Fatalf("stderr %q does not contain %q", buf.String(), want)
+	if want := "json encode failed"; !strings.Contains(logBuf.String(), want) {
+		t.Fatalf("log %q does not contain %q", logBuf.String(), want)
 	}
 }
 
@@ -369,20 +390,20 @@ func TestRender(t *testing.T) {
 		{
 			name:        "very verbose mode",
 			config:      Config{VeryVerbose: true},
-			contains:    []string{"🚨 smelly.md", "✅ clean.md", "(score 42)", "(score 10)", "rule1", "rule2"},
+			contains:    []string{"🚨 smelly.md", "✅ clean.md", "(score 42, confidence 0%)", "(score 10, confidence 0%)", "rule1", "rule2"},
 			notContains: []string{"No AI smell detected"},
 			wantSmelly:  true,
 		},
 		{
 			name:        "ultra verbose mode",
 			config:      Config{UltraVerbose: true},
-			contains:    []string{"🚨 smelly.md", "✅ clean.md", "(score 42)", "(score 10)", "rule1", "rule2", "pattern"},
+			contains:    []string{"🚨 smelly.md", "✅ clean.md", "(score 42, confidence 0%)", "(score 10, confidence 0%)", "rule1", "rule2", "pattern"},
 			notContains: []string{"No AI smell detected"},
 			wantSmelly:  true,
 		},
 		{
 			name:        "JSON mode",
-			config:      Config{JSON: true},
+			config:      Config{Format: FormatJSON},
 			contains:    []string{`"path": "clean.md"`, `"path": "smelly.md"`, `"score": 42`},
 			notContains: []string{"🚨", "✅", "No AI smell detected"},
 			wantSmelly:  true,
@@ -392,7 +413,7 @@ func TestRender(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			output := captureOutput(func() {
-				smelly := Render(results, tt.config)
+				smelly := Render(os.Stdout, results, tt.config).AnyErrors
 				assert.Equal(t, tt.wantSmelly, smelly, "Unexpected smelly return value")
 			})
 
@@ -420,9 +441,405 @@ func TestRender(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		smelly := Render(cleanResults, Config{})
-		require.False(t, smelly, "Should report no smelly files")
+		rr := Render(os.Stdout, cleanResults, Config{})
+		require.Equal(t, RenderResult{}, rr, "Should report no smelly files")
 	})
 	assert.Contains(t, output, "✅ No AI smell detected in 2 file(s)")
 	assert.NotContains(t, output, "🚨")
 }
+
+// TestRenderStream verifies NDJSON and unsorted text streaming.
+func TestRenderStream(t *testing.T) {
+	feed := func(rs ...Result) <-chan Result {
+		ch := make(chan Result, len(rs))
+		for _, r := range rs {
+			ch <- r
+		}
+		close(ch)
+		return ch
+	}
+	smelly := Result{Path: "smelly.md", Score: 42, Smelly: true}
+	clean := Result{Path: "clean.md", Score: 1}
+
+	var buf bytes.Buffer
+	assert.True(t, RenderStream(&buf, feed(smelly, clean), Config{Format: FormatJSON}).AnyErrors)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "one JSON object per line")
+	assert.True(t, strings.HasPrefix(lines[0], `{"path":"smelly.md"`))
+	assert.True(t, strings.HasPrefix(lines[1], `{"path":"clean.md"`))
+
+	buf.Reset()
+	assert.True(t, RenderStream(&buf, feed(smelly, clean), Config{}).AnyErrors)
+	assert.Contains(t, buf.String(), "🚨 smelly.md")
+	assert.NotContains(t, buf.String(), "clean.md")
+
+	buf.Reset()
+	assert.Equal(t, RenderResult{}, RenderStream(&buf, feed(clean), Config{}))
+	assert.Contains(t, buf.String(), "✅ No AI smell detected in 1 file(s)")
+}
+
+func TestFormatLines(t *testing.T) {
+	assert.Equal(t, "", formatLines(nil))
+	assert.Equal(t, " line 4", formatLines([]int{4}))
+	assert.Equal(t, " lines 3, 7", formatLines([]int{3, 7}))
+	assert.Equal(t, " lines 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, … +2 more",
+		formatLines([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}))
+}
+
+func TestFormatScore(t *testing.T) {
+	assert.Equal(t, "30", FormatScore(30))
+	assert.Equal(t, "12.5", FormatScore(12.5))
+	assert.Equal(t, "3.33", FormatScore(10.0/3))
+	assert.Equal(t, "0", FormatScore(0))
+}
+
+func TestRenderNormalized(t *testing.T) {
+	list := []Result{{Path: "a.md", Score: 12.345, RawScore: 5, Smelly: true}}
+
+	var buf bytes.Buffer
+	Render(&buf, list, Config{Normalize: true, Threshold: 2.5})
+	assert.Contains(t, buf.String(), "a.md\t(score 12.35)")
+	assert.Contains(t, buf.String(), "Scores are per KB")
+
+	buf.Reset()
+	Render(&buf, list, Config{Format: FormatJSON, Normalize: true})
+	assert.Contains(t, buf.String(), `"score": 12.345`)
+	assert.Contains(t, buf.String(), `"rawScore": 5`)
+}
+
+// TestRenderWarnings verifies files in the warning band are shown and reported.
+func TestRenderWarnings(t *testing.T) {
+	cfg := Config{Threshold: 30, WarnThreshold: 15}
+	r := AnalyseString("MARK MARK", "a.md", []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, cfg)
+	assert.False(t, r.Smelly)
+	assert.True(t, r.Warning)
+
+	var buf bytes.Buffer
+	rr := Render(&buf, []Result{r}, cfg)
+	assert.Equal(t, RenderResult{AnyWarnings: true}, rr)
+	assert.Contains(t, buf.String(), "⚠️ a.md")
+	assert.NotContains(t, buf.String(), "No AI smell detected")
+
+	r = AnalyseString("MARK", "a.md", []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, cfg)
+	assert.False(t, r.Warning, "below the warn threshold")
+
+	doc := buildSARIF([]Result{{Path: "w.md", Score: 20, Warning: true, Detail: map[string]RuleHit{"mark": {Count: 2}}}})
+	require.Len(t, doc.Runs[0].Results, 1)
+	assert.Equal(t, "warning", doc.Runs[0].Results[0].Level)
+}
+
+// TestRenderTiming verifies durations in -vvv, JSON and the text summary.
+func TestRenderTiming(t *testing.T) {
+	list := []Result{
+		{Path: "a.md", Duration: 12 * time.Millisecond},
+		{Path: "b.md", Duration: 4 * time.Millisecond},
+		{Path: "c.md"},
+	}
+
+	var buf bytes.Buffer
+	Render(&buf, list, Config{Threshold: 30, TimingMode: true, Color: ColorNever})
+	assert.Contains(t, buf.String(), "Analysis time per file: min 4ms, max 12ms, mean 8ms over 2 file(s)\n")
+
+	buf.Reset()
+	Render(&buf, list, Config{Threshold: 30, UltraVerbose: true, Color: ColorNever})
+	assert.Contains(t, buf.String(), "a.md (score 0, confidence 0%) (analysed in 12ms)\n")
+	assert.Contains(t, buf.String(), "c.md (score 0, confidence 0%)\n")
+	assert.NotContains(t, buf.String(), "Analysis time", "no summary without TimingMode")
+
+	buf.Reset()
+	Render(&buf, list, Config{Format: FormatJSON})
+	assert.Contains(t, buf.String(), `"duration_ms": 12`)
+	assert.Equal(t, 2, strings.Count(buf.String(), "duration_ms"), "untimed results leave it out")
+}
+
+// TestRenderTop verifies -top ranks flagged files and drops the rest from
+// the output only.
+func TestRenderPrint0(t *testing.T) {
+	list := []Result{
+		{Path: "my notes.md", Score: 35, Smelly: true},
+		{Path: "clean.md", Score: 5},
+		{Path: "warn.md", Score: 20, Warning: true},
+		{Path: "dir/it's.md", Score: 50, Smelly: true},
+	}
+	cfg := Config{Threshold: 30, Print0: true}
+
+	var buf bytes.Buffer
+	rr := Render(&buf, list, cfg)
+	assert.True(t, rr.AnyErrors)
+	assert.Equal(t, []string{"my notes.md", "dir/it's.md"}, strings.Split(buf.String(), "\x00"))
+	assert.False(t, strings.HasSuffix(buf.String(), "\x00"), "no NUL after the last path")
+
+	buf.Reset()
+	ch := make(chan Result, len(list))
+	for _, r := range list {
+		ch <- r
+	}
+	close(ch)
+	RenderStream(&buf, ch, cfg)
+	assert.Equal(t, "my notes.md\x00dir/it's.md", buf.String())
+
+	buf.Reset()
+	Render(&buf, list[1:2], cfg)
+	assert.Empty(t, buf.String(), "clean files print nothing")
+}
+
+func TestRenderInvert(t *testing.T) {
+	list := []Result{
+		{Path: "smelly.md", Score: 35, Smelly: true},
+		{Path: "clean.md", Score: 5},
+		{Path: "warn.md", Score: 20, Warning: true},
+	}
+	cfg := Config{Threshold: 30, WarnThreshold: 15, Invert: true, Color: ColorNever}
+
+	var buf bytes.Buffer
+	rr := Render(&buf, list, cfg)
+	assert.True(t, rr.AnyErrors, "the report covers the smelly file")
+	assert.NotContains(t, buf.String(), "smelly.md")
+	assert.Contains(t, buf.String(), "clean.md")
+	assert.Contains(t, buf.String(), "warn.md")
+
+	buf.Reset()
+	cfg.Format = FormatJSON
+	Render(&buf, list, cfg)
+	var decoded jsonReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, []string{"clean.md", "warn.md"}, resultPaths(decoded.Results))
+	assert.Equal(t, 3, decoded.Summary.Files, "the summary covers the whole scan")
+
+	buf.Reset()
+	cfg.Format = FormatNDJSON
+	ch := make(chan Result, len(list))
+	for _, r := range list {
+		ch <- r
+	}
+	close(ch)
+	RenderStream(&buf, ch, cfg)
+	assert.NotContains(t, buf.String(), "smelly.md")
+	assert.Contains(t, buf.String(), "clean.md")
+
+	buf.Reset()
+	cfg.Format, cfg.Print0 = FormatText, true
+	Render(&buf, list, cfg)
+	assert.Equal(t, "clean.md\x00warn.md", buf.String())
+
+	// Unread and skipped files are not clean, so no output lists them
+	list = append(list, Result{Path: "broken.zip", Err: "zip: not a valid zip file"}, Result{Path: "big.md", Skipped: true})
+	buf.Reset()
+	Render(&buf, list, cfg)
+	assert.Equal(t, "clean.md\x00warn.md", buf.String())
+
+	buf.Reset()
+	cfg.Print0, cfg.Format = false, FormatJSON
+	Render(&buf, list, cfg)
+	decoded = jsonReport{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, []string{"clean.md", "warn.md"}, resultPaths(decoded.Results))
+
+	buf.Reset()
+	cfg.Format, cfg.CountOnly = FormatText, true
+	Render(&buf, list, cfg)
+	assert.Equal(t, "2", buf.String())
+}
+
+func TestRenderCount(t *testing.T) {
+	list := []Result{
+		{Path: "a.md", Score: 35, Smelly: true},
+		{Path: "b.md", Score: 50, Smelly: true},
+		{Path: "c.md", Score: 5},
+		{Path: "d.md", Score: 20, Warning: true},
+		{Path: "e.zip", Err: "zip: not a valid zip file"},
+	}
+	cfg := Config{Threshold: 30, CountOnly: true}
+
+	var buf bytes.Buffer
+	rr := Render(&buf, list, cfg)
+	assert.True(t, rr.AnyErrors)
+	n, err := strconv.Atoi(buf.String())
+	require.NoError(t, err, "a bare integer without a newline")
+	assert.Equal(t, 2, n)
+
+	buf.Reset()
+	cfg.Invert = true
+	Render(&buf, list, cfg)
+	assert.Equal(t, "2", buf.String(), "clean files, not the unreadable one")
+
+	buf.Reset()
+	cfg.Invert, cfg.Format = false, FormatJSON
+	Render(&buf, list, cfg)
+	assert.JSONEq(t, `{"smelly": 2, "clean": 2, "total": 5}`, buf.String())
+
+	buf.Reset()
+	cfg.Format = FormatText
+	ch := make(chan Result)
+	close(ch)
+	RenderStream(&buf, ch, cfg)
+	assert.Equal(t, "0", buf.String())
+}
+
+func TestRenderTop(t *testing.T) {
+	list := []Result{
+		{Path: "a.md", Score: 35, Smelly: true},
+		{Path: "b.md", Score: 90, Smelly: true},
+		{Path: "c.md", Score: 20, Warning: true},
+		{Path: "d.md", Score: 50, Smelly: true},
+		{Path: "e.md", Score: 5},
+	}
+	cfg := Config{Threshold: 30, WarnThreshold: 15, Top: 2, Color: ColorNever}
+
+	assert.Equal(t, []string{"b.md", "d.md"}, resultPaths(topResults(list, cfg)))
+	cfg.Top = 10
+	assert.Equal(t, []string{"b.md", "d.md", "a.md", "c.md"}, resultPaths(topResults(list, cfg)), "clean files are not ranked")
+	cfg.TopAll = true
+	top := topResults(list, cfg)
+	assert.Equal(t, []string{"b.md", "d.md", "a.md", "c.md", "e.md"}, resultPaths(top))
+	assert.False(t, top[4].Smelly)
+
+	cfg.Top, cfg.TopAll = 1, false
+	var buf bytes.Buffer
+	rr := Render(&buf, list, cfg)
+	assert.True(t, rr.AnyErrors)
+	assert.True(t, rr.AnyWarnings, "the report covers files that were not shown")
+	assert.Contains(t, buf.String(), "b.md")
+	assert.NotContains(t, buf.String(), "d.md")
+	assert.Len(t, list, 5, "the results themselves are kept")
+
+	buf.Reset()
+	cfg.Format = FormatJSON
+	Render(&buf, list, cfg)
+	var decoded jsonReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, []string{"b.md"}, resultPaths(decoded.Results))
+	assert.Equal(t, 5, decoded.Summary.Files, "the summary covers the whole scan")
+
+	buf.Reset()
+	cfg.Format = FormatText
+	ch := make(chan Result, len(list))
+	for _, r := range list {
+		ch <- r
+	}
+	close(ch)
+	RenderStream(&buf, ch, cfg)
+	assert.Contains(t, buf.String(), "b.md")
+	assert.NotContains(t, buf.String(), "a.md")
+}
+
+func resultPaths(list []Result) []string {
+	var out []string
+	for _, r := range list {
+		out = append(out, r.Path)
+	}
+	return out
+}
+
+func TestRenderJSONVersions(t *testing.T) {
+	results := []Result{
+		{Path: "a.md", Score: 40, Smelly: true, Detail: map[string]RuleHit{"x": {Rule: Rule{Name: "x", Weight: 20}, Count: 2, Scored: 2}}},
+		{Path: "b.md", Score: 1},
+	}
+	render := func(cfg Config) map[string]json.RawMessage {
+		var buf bytes.Buffer
+		Render(&buf, results, cfg)
+		var report map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report), buf.String())
+		return report
+	}
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	v1 := render(Config{Format: FormatJSON, Threshold: 30})
+	assert.ElementsMatch(t, []string{"generator", "version", "git_commit", "host", "summary", "results"}, mapKeys(v1))
+	assert.Equal(t, v1, render(Config{Format: FormatJSON, Threshold: 30, JSONVersion: JSONReportV1}), "0 is version 1")
+	assert.JSONEq(t, `"synthsniff"`, string(v1["generator"]))
+	assert.JSONEq(t, `"`+Version().Version+`"`, string(v1["version"]))
+	assert.JSONEq(t, `"`+Version().Commit+`"`, string(v1["git_commit"]))
+	assert.JSONEq(t, `"`+host+`"`, string(v1["host"]))
+
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	timed := render(Config{Format: FormatJSON, Threshold: 30, Started: started, Elapsed: 1234 * time.Millisecond})
+	assert.JSONEq(t, `"2026-03-01T12:00:00Z"`, string(timed["scan_started_at"]))
+	assert.JSONEq(t, `1234`, string(timed["scan_duration_ms"]))
+
+	v2 := render(Config{Format: FormatJSON, JSONVersion: JSONReportV2, Threshold: 30, Workers: 3, RuleCount: 12, Started: started, RuleStats: true})
+	assert.ElementsMatch(t, []string{"generator", "version", "generator_version", "git_commit", "scan_started_at", "scan_duration_ms", "host",
+		"config", "scan_time", "summary", "rule_stats", "results"}, mapKeys(v2))
+	assert.JSONEq(t, `"2"`, string(v2["version"]), "the layout version")
+	assert.Equal(t, v1["version"], v2["generator_version"])
+	assert.JSONEq(t, `{"threshold": 30, "rules": 12, "workers": 3}`, string(v2["config"]))
+	assert.JSONEq(t, `"2026-03-01T12:00:00Z"`, string(v2["scan_time"]))
+	assert.Equal(t, v1["results"], v2["results"])
+	var summary ScanSummary
+	require.NoError(t, json.Unmarshal(v2["summary"], &summary))
+	assert.Equal(t, 2, summary.Files)
+	assert.Equal(t, 1, summary.Smelly)
+
+	// The bare array, buffered when streamed too
+	var buf bytes.Buffer
+	Render(&buf, results, Config{Format: FormatJSONArray, Started: started})
+	var list []Result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &list), "no metadata around the array")
+	assert.Equal(t, []string{"a.md", "b.md"}, []string{list[0].Path, list[1].Path})
+	stream := make(chan Result, len(results))
+	for _, r := range results {
+		stream <- r
+	}
+	close(stream)
+	var streamed bytes.Buffer
+	RenderStream(&streamed, stream, Config{Format: FormatJSONArray, Started: started})
+	assert.Equal(t, buf.String(), streamed.String())
+
+	buf.Reset()
+	Render(&buf, nil, Config{Format: FormatJSONArray})
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+// mapKeys returns m's keys.
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// TestRenderDeterministic verifies every format writes the same bytes
+// each time, whatever order the detail maps iterate in.
+func TestRenderDeterministic(t *testing.T) {
+	detail := make(map[string]RuleHit)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("rule%02d", i)
+		detail[name] = RuleHit{Rule: Rule{Name: name, Pattern: name, Weight: 1 + i%3}, Count: i + 1, Scored: i + 1, Lines: []int{i + 1}}
+	}
+	results := []Result{
+		{Path: "a.md", Score: 60, Smelly: true, Detail: detail},
+		{Path: "b.md", Score: 20, Warning: true, Detail: detail},
+		{Path: "c.md"},
+	}
+	base := Config{Threshold: 30, WarnThreshold: 10, Color: ColorNever}
+	modes := map[string]func(*Config){
+		"text":         func(*Config) {},
+		"verbose":      func(c *Config) { c.Verbose = true },
+		"very verbose": func(c *Config) { c.VeryVerbose = true },
+		"ultra":        func(c *Config) { c.UltraVerbose = true },
+		"json":         func(c *Config) { c.Format = FormatJSON },
+		"json v2":      func(c *Config) { c.Format, c.JSONVersion, c.RuleStats = FormatJSON, JSONReportV2, true },
+		"ndjson":       func(c *Config) { c.Format = FormatNDJSON },
+		"sarif":        func(c *Config) { c.Format = FormatSARIF },
+		"csv":          func(c *Config) { c.Format = FormatCSV },
+		"junit":        func(c *Config) { c.Format = FormatJUnit },
+		"gha":          func(c *Config) { c.Format = FormatGHA },
+		"html":         func(c *Config) { c.Format = FormatHTML },
+	}
+	for name, set := range modes {
+		t.Run(name, func(t *testing.T) {
+			cfg := base
+			set(&cfg)
+			var first bytes.Buffer
+			Render(&first, results, cfg)
+			for i := 0; i < 5; i++ {
+				var again bytes.Buffer
+				Render(&again, results, cfg)
+				require.Equal(t, first.String(), again.String())
+			}
+		})
+	}
+}
@@ -2,79 +2,415 @@ package sniff
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Rule describes a pattern and how to score it.
 type Rule struct {
-	Name        string   `json:"name"        yaml:"name"`
-	Pattern     string   `json:"pattern"     yaml:"pattern"`
-	Weight      int      `json:"weight"      yaml:"weight"`
-	MinCount    int      `json:"minCount,omitempty"    yaml:"minCount,omitempty"`
-	MinPercent  float64  `json:"minPercent,omitempty"  yaml:"minPercent,omitempty"` // 0-100
-	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
-	Ext         string   `json:"ext,omitempty"         yaml:"ext,omitempty"`  // single .md
-	Exts        []string `json:"exts,omitempty"        yaml:"exts,omitempty"` // [".md",".txt"]
+	Name            string   `json:"name"        yaml:"name"`
+	Pattern         string   `json:"pattern"     yaml:"pattern"`
+	Patterns        []string `json:"patterns,omitempty"    yaml:"patterns,omitempty"` // alternatives; replaces Pattern when set
+	Weight          int      `json:"weight"      yaml:"weight"`
+	MinCount        int      `json:"minCount,omitempty"    yaml:"minCount,omitempty"`
+	MinPercent      float64  `json:"minPercent,omitempty"  yaml:"minPercent,omitempty"` // 0-100
+	MaxMatches      int      `json:"maxMatches,omitempty"  yaml:"maxMatches,omitempty"` // cap on scored occurrences, 0 = none
+	CaseInsensitive bool     `json:"caseInsensitive,omitempty" yaml:"caseInsensitive,omitempty"`
+	Normalize       string   `json:"normalize,omitempty"   yaml:"normalize,omitempty"` // NFC, NFD, NFKC or NFKD; overrides Config.UnicodeNorm
+	Description     string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Severity        string   `json:"severity,omitempty"    yaml:"severity,omitempty"` // info, warn (default) or error
+	Ext             string   `json:"ext,omitempty"         yaml:"ext,omitempty"`      // single .md
+	Exts            []string `json:"exts,omitempty"        yaml:"exts,omitempty"`     // [".md",".txt"]
+	MIME            string   `json:"mime,omitempty"        yaml:"mime,omitempty"`     // text/markdown, text/*; needs Config.DetectMIME
+	Language        string   `json:"language,omitempty"    yaml:"language,omitempty"` // go, python, markdown, ...; needs Config.DetectLanguage
+	Tags            []string `json:"tags,omitempty"        yaml:"tags,omitempty"`     // typography, structure, ...; see Config.OnlyTags
+
+	// Exclude and Excludes are anti-patterns: a file containing any of
+	// them gets no score from this rule, e.g. "<!-- human-written -->".
+	Exclude  string   `json:"exclude,omitempty"  yaml:"exclude,omitempty"`
+	Excludes []string `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+
+	// ExcludeCodeBlocks keeps the rule out of fenced code blocks and
+	// <code> elements in Markdown; see Config.ExcludeCodeBlocks.
+	ExcludeCodeBlocks bool `json:"excludeCodeBlocks,omitempty" yaml:"excludeCodeBlocks,omitempty"`
+
+	// FrontMatterOnly limits the rule to a Markdown file's front matter,
+	// even with Config.StripFrontMatter.
+	FrontMatterOnly bool `json:"frontMatterOnly,omitempty" yaml:"frontMatterOnly,omitempty"`
+
+	// PositionTracking adds the rule's matches to the file's heatmap even
+	// without Config.Heatmap. Finding every offset makes it slower.
+	PositionTracking bool `json:"positionTracking,omitempty" yaml:"positionTracking,omitempty"`
+
+	// MaxFileSize, when set, skips the rule on files larger than this
+	// many bytes, e.g. a costly pattern on huge logs. Config.MaxSize
+	// skips the whole file instead.
+	MaxFileSize int64 `json:"maxFileSize,omitempty" yaml:"maxFileSize,omitempty"`
+
+	// Proximity, when set, replaces Pattern with a two-pattern check.
+	Proximity *Proximity `json:"proximity,omitempty" yaml:"proximity,omitempty"`
+
+	// Group names a rule group; see GroupConfig.
+	Group         string `json:"group,omitempty" yaml:"group,omitempty"`
+	minGroupScore int    // from the dict's groups section
+
+	// WordList, when set, replaces Pattern with every word or phrase in
+	// that file (one per line, # comments). Each occurrence scores Weight.
+	WordList string   `json:"wordList,omitempty" yaml:"wordList,omitempty"`
+	words    []string // loaded from WordList by LoadRules
 }
 
 // defaults
 var baseRules = []Rule{
 	{
-		Name:    "markdown-hrule",
-		Pattern: "\n---\n",
-		Weight:  30,
-		Ext:     ".md",
+		Name:     "markdown-hrule",
+		Pattern:  "\n---\n",
+		Weight:   30,
+		Severity: SeverityError,
+		Ext:      ".md",
+		Tags:     []string{TagStructure},
 	},
 	{
-		Name:    "en-dash",
-		Pattern: "\u2013",
-		Weight:  10,
+		Name:     "en-dash",
+		Pattern:  "\u2013",
+		Weight:   10,
+		Severity: SeverityWarn,
+		Tags:     []string{TagTypography},
 	},
 	{
-		Name:    "em-dash",
-		Pattern: "\u2014",
-		Weight:  3,
+		Name:     "em-dash",
+		Pattern:  "\u2014",
+		Weight:   3,
+		Severity: SeverityInfo,
+		Tags:     []string{TagTypography},
 	},
 	{
-		Name:    "left-double-quote",
-		Pattern: "\u201C",
-		Weight:  10,
+		Name:     "left-double-quote",
+		Pattern:  "\u201C",
+		Weight:   10,
+		Severity: SeverityWarn,
+		Tags:     []string{TagTypography},
 	},
 	{
-		Name:    "right-double-quote",
-		Pattern: "\u201D",
-		Weight:  10,
+		Name:     "right-double-quote",
+		Pattern:  "\u201D",
+		Weight:   10,
+		Severity: SeverityWarn,
+		Tags:     []string{TagTypography},
 	},
 	{
-		Name:    "non-breaking-space",
-		Pattern: "\u00A0",
-		Weight:  10,
+		Name:     "non-breaking-space",
+		Pattern:  "\u00A0",
+		Weight:   10,
+		Severity: SeverityWarn,
+		Tags:     []string{TagTypography},
 	},
 }
 
-// LoadRules merges a user dictionary with defaults.
-func LoadRules(path string) ([]Rule, error) {
-	if path == "" {
+// LoadRules merges user dictionaries, in order, with defaults. Paths
+// starting with http:// or https:// are downloaded with the default
+// timeout and no offline copy. RuleSet.Compile prepares the result for
+// matching.
+func LoadRules(paths []string) (RuleSet, error) {
+	rules, err := loadRules(paths, dictFetcher{})
+	if err != nil {
+		return RuleSet{}, err
+	}
+	return NewRuleSet(rules...)
+}
+
+// loadRules is LoadRules with remote dicts fetched through f.
+func loadRules(paths []string, f dictFetcher) ([]Rule, error) {
+	if len(paths) == 0 {
 		return baseRules, nil
 	}
 
-	b, err := os.ReadFile(path)
+	var custom []Rule
+	for _, path := range paths {
+		var ext []Rule
+		var err error
+		if isRemoteDict(path) {
+			ext, err = loadRemoteDict(path, f)
+		} else {
+			ext, err = loadDict(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		custom = append(custom, ext...)
+	}
+	// Names are checked across all files, so two dicts cannot both
+	// define or override the same rule
+	return mergeRules(baseRules, custom)
+}
+
+// loadDict reads the rules of one dictionary file.
+func loadDict(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readDict(f, path, dictFormat(path), filepath.Dir(path))
+}
+
+// LoadRulesFromReader reads one dictionary from r, in the "json", "yaml"
+// or "toml" format, for rules embedded in a binary or fetched from
+// elsewhere. Only the reader's rules are returned; MergeWithBase adds the
+// defaults. Word lists are resolved against the working directory.
+func LoadRulesFromReader(r io.Reader, format string) ([]Rule, error) {
+	switch f := strings.ToLower(format); f {
+	case "json", "toml":
+		format = f
+	case "yaml", "yml":
+		format = "yaml"
+	default:
+		return nil, fmt.Errorf("invalid rule format %q (want json, yaml or toml)", format)
+	}
+	return readDict(r, "reader", format, "")
+}
+
+// readDict decodes one dictionary from r and loads its word lists, with
+// relative paths resolved against dir. src names the dict in errors.
+func readDict(r io.Reader, src, format, dir string) ([]Rule, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("dict %s: %w", src, err)
+	}
+	ext, err := decodeDict(src, format, b)
 	if err != nil {
 		return nil, err
 	}
+	if err := loadWordLists(ext, dir); err != nil {
+		return nil, err
+	}
+	return ext, nil
+}
+
+// dictFormat picks the decoder for a dict by its name: "toml" for .toml
+// files, else "" to accept JSON or YAML.
+func dictFormat(name string) string {
+	if isTOML(name) {
+		return "toml"
+	}
+	return ""
+}
+
+// decodeDict decodes dict content in format ("json", "yaml", "toml", or
+// "" for JSON or YAML); src names it in errors.
+func decodeDict(src, format string, b []byte) ([]Rule, error) {
+	// A dict is either a bare rule list or {rules: [...], groups: {...}};
+	// TOML has no top-level arrays, so it always uses [[rules]] tables
 	var ext []Rule
+	var file dictFile
+	tryJSON := format == "" || format == "json"
+	tryYAML := format == "" || format == "yaml"
 	switch {
-	case json.Unmarshal(b, &ext) == nil:
-	case yaml.Unmarshal(b, &ext) == nil:
+	case format == "toml":
+		if err := decodeTOML(b, &file); err != nil {
+			return nil, fmt.Errorf("dict %s: %w", src, err)
+		}
+		ext = file.Rules
+		if err := applyGroups(ext, file.Groups); err != nil {
+			return nil, fmt.Errorf("dict %s: %w", src, err)
+		}
+	case tryJSON && json.Unmarshal(b, &ext) == nil:
+	case tryYAML && yaml.Unmarshal(b, &ext) == nil:
+	case tryJSON && json.Unmarshal(b, &file) == nil, tryYAML && yaml.Unmarshal(b, &file) == nil:
+		ext = file.Rules
+		if err := applyGroups(ext, file.Groups); err != nil {
+			return nil, fmt.Errorf("dict %s: %w", src, err)
+		}
+	case format == "json":
+		return nil, fmt.Errorf("dict %s: must be JSON", src)
+	case format == "yaml":
+		return nil, fmt.Errorf("dict %s: must be YAML", src)
 	default:
-		return nil, errors.New("dict must be JSON or YAML")
+		return nil, fmt.Errorf("dict %s: must be JSON or YAML", src)
+	}
+	if err := checkNormForms(ext); err != nil {
+		return nil, fmt.Errorf("dict %s: %w", src, err)
+	}
+	if err := checkSeverities(ext); err != nil {
+		return nil, fmt.Errorf("dict %s: %w", src, err)
+	}
+	return ext, nil
+}
+
+// MergeWithBase returns the base rules followed by custom. A custom rule
+// named like an earlier one (ignoring case) replaces it in place, so the
+// last definition of a name wins.
+func MergeWithBase(custom []Rule) []Rule {
+	out := make([]Rule, len(baseRules), len(baseRules)+len(custom))
+	copy(out, baseRules)
+	pos := make(map[string]int, cap(out))
+	for i, r := range out {
+		pos[strings.ToLower(r.Name)] = i
+	}
+	for _, r := range custom {
+		key := strings.ToLower(r.Name)
+		if i, ok := pos[key]; ok {
+			out[i] = r
+			continue
+		}
+		pos[key] = len(out)
+		out = append(out, r)
+	}
+	return out
+}
+
+// mergeRules appends custom rules to base. A custom rule named like a base
+// rule (ignoring case) replaces it in place, which is how weights are
+// tuned; two custom rules with one name are an error.
+func mergeRules(base, custom []Rule) ([]Rule, error) {
+	pos := make(map[string]int, len(base)+len(custom))
+	out := make([]Rule, len(base), len(base)+len(custom))
+	copy(out, base)
+	for i, r := range base {
+		pos[strings.ToLower(r.Name)] = i
+	}
+
+	overridden := make(map[string]bool)
+	for _, r := range custom {
+		key := strings.ToLower(r.Name)
+		i, ok := pos[key]
+		switch {
+		case !ok:
+			pos[key] = len(out)
+			out = append(out, r)
+		case i < len(base) && !overridden[key]:
+			overridden[key] = true
+			out[i] = r
+		default:
+			return nil, fmt.Errorf("duplicate rule name %q", r.Name)
+		}
 	}
+	return out, nil
+}
+
+// FilterRules returns rules without the ones named in disabled (ignoring
+// case). Names that match no rule are reported on stderr.
+func FilterRules(rules []Rule, disabled []string) []Rule {
+	if len(disabled) == 0 {
+		return rules
+	}
+	off := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		off[strings.ToLower(name)] = true
+	}
+
+	out := make([]Rule, 0, len(rules))
+	used := make(map[string]bool, len(disabled))
+	for _, r := range rules {
+		if key := strings.ToLower(r.Name); off[key] {
+			used[key] = true
+			continue
+		}
+		out = append(out, r)
+	}
+	for _, name := range disabled {
+		if !used[strings.ToLower(name)] {
+			slog.Warn("disabled rule matches no loaded rule", "rule", name)
+		}
+	}
+	return out
+}
+
+// OverrideWeights returns rules with the weights in weights, keyed by rule
+// name (case-insensitive), in place of their own; rules itself is not
+// modified. A weight of 0 keeps the rule's hits in Result.Detail without
+// scoring them, and a negative weight lowers the score. Names matching
+// no rule are logged.
+func OverrideWeights(rules []Rule, weights map[string]int) []Rule {
+	if len(weights) == 0 {
+		return rules
+	}
+	byName := make(map[string]int, len(weights))
+	for name, w := range weights {
+		byName[strings.ToLower(name)] = w
+	}
+
+	out := slices.Clone(rules)
+	used := make(map[string]bool, len(weights))
+	for i := range out {
+		key := strings.ToLower(out[i].Name)
+		if w, ok := byName[key]; ok {
+			out[i].Weight = w
+			used[key] = true
+		}
+	}
+	for name := range weights {
+		if !used[strings.ToLower(name)] {
+			slog.Warn("weight override matches no loaded rule", "rule", name)
+		}
+	}
+	return out
+}
+
+// checkPatternCollisions returns an error when two rules share a Pattern,
+// whatever their names. Used by -strict-dict.
+func checkPatternCollisions(rules []Rule) error {
+	seen := make(map[string]string, len(rules))
+	for _, r := range rules {
+		if r.Proximity != nil || r.WordList != "" {
+			continue // pattern unused
+		}
+		for _, p := range r.patterns() {
+			if prev, ok := seen[p]; ok && p != "" {
+				return fmt.Errorf("rules %s and %s share pattern %q", prev, r.Name, p)
+			}
+			seen[p] = r.Name
+		}
+	}
+	return nil
+}
+
+// loadWordLists reads the word list of every rule that names one. Relative
+// paths are resolved against dir.
+func loadWordLists(rules []Rule, dir string) error {
+	for i := range rules {
+		if rules[i].WordList == "" {
+			continue
+		}
+		rules[i].WordList = resolveRelative(dir, rules[i].WordList)
+		words, err := readWordList(rules[i].WordList)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rules[i].Name, err)
+		}
+		rules[i].words = words
+	}
+	return nil
+}
 
-	return append(baseRules, ext...), nil
+// readWordList returns the distinct non-empty, non-comment lines of path.
+func readWordList(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		w := strings.TrimSpace(line)
+		if w == "" || strings.HasPrefix(w, "#") || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words, nil
+}
+
+// appliesToSize reports whether the rule runs on a file of n bytes.
+func (r Rule) appliesToSize(n int64) bool {
+	return r.MaxFileSize <= 0 || n <= r.MaxFileSize
 }
 
 // appliesToExt reports whether this rule should run on the file ext.
@@ -93,6 +429,37 @@ func (r Rule) appliesToExt(ext string) bool {
 	return false
 }
 
+// patterns returns the literal patterns the rule counts: its word list,
+// its alternatives, or Pattern alone.
+func (r Rule) patterns() []string {
+	switch {
+	case r.WordList != "":
+		return r.words
+	case len(r.Patterns) > 0:
+		return r.Patterns
+	}
+	return []string{r.Pattern}
+}
+
+// excludePatterns returns Exclude and Excludes, skipping empty entries.
+func (r Rule) excludePatterns() []string {
+	var out []string
+	for _, p := range append([]string{r.Exclude}, r.Excludes...) {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// scoredCount caps count at MaxMatches when set.
+func (r Rule) scoredCount(count int) int {
+	if r.MaxMatches > 0 && count > r.MaxMatches {
+		return r.MaxMatches
+	}
+	return count
+}
+
 // passesThresholds checks optional minCount/minPercent.
 func (r Rule) passesThresholds(count int, fileLen int) bool {
 	if r.MinCount > 0 && count < r.MinCount {
@@ -106,5 +473,20 @@ func (r Rule) passesThresholds(count int, fileLen int) bool {
 	return true
 }
 
+// displayPattern is the rule's pattern as shown in reports; proximity
+// rules read as "A <=200=> B", word lists as "@words.txt (42 words)" and
+// alternatives as "A | B".
+func (r Rule) displayPattern() string {
+	switch {
+	case r.Proximity != nil:
+		return fmt.Sprintf("%s <=%d=> %s", r.Proximity.PatternA, r.Proximity.MaxDistance, r.Proximity.PatternB)
+	case r.WordList != "":
+		return fmt.Sprintf("@%s (%d words)", filepath.Base(r.WordList), len(r.words))
+	case len(r.Patterns) > 0:
+		return strings.Join(r.Patterns, " | ")
+	}
+	return r.Pattern
+}
+
 // RelPathExt helper
 func RelPathExt(p string) string { return filepath.Ext(p) }
@@ -2,8 +2,10 @@
 package sniff
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,7 +76,12 @@ func TestLoadRules(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rules, err := LoadRules(tt.dictPath)
+			var paths []string
+			if tt.dictPath != "" {
+				paths = []string{tt.dictPath}
+			}
+			set, err := LoadRules(paths)
+			rules := set.Active()
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -284,3 +291,206 @@ func TestRelPathExt(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadRulesOverridesBaseRule(t *testing.T) {
+	dict := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte("- {name: EM-Dash, pattern: \"\\u2014\", weight: 1}\n"), 0644))
+
+	set, err := LoadRules([]string{dict})
+	require.NoError(t, err)
+	rules := set.Active()
+	require.Len(t, rules, len(baseRules), "the override replaces the base rule")
+	for i, r := range rules {
+		if r.Pattern == "\u2014" {
+			assert.Equal(t, "EM-Dash", r.Name)
+			assert.Equal(t, 1, r.Weight)
+			assert.Equal(t, "em-dash", baseRules[i].Name, "the override keeps the base rule's position")
+		}
+	}
+	assert.Equal(t, 3, baseRules[2].Weight, "base rules are not modified")
+}
+
+func TestLoadRulesDuplicateCustomNames(t *testing.T) {
+	dict := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte(`
+- {name: delve, pattern: delve, weight: 1}
+- {name: Delve, pattern: Delve, weight: 2}
+`), 0644))
+	_, err := LoadRules([]string{dict})
+	assert.ErrorContains(t, err, `duplicate rule name "Delve"`)
+
+	require.NoError(t, os.WriteFile(dict, []byte(`
+- {name: em-dash, pattern: "\u2014", weight: 1}
+- {name: em-dash, pattern: "\u2014", weight: 2}
+`), 0644))
+	_, err = LoadRules([]string{dict})
+	assert.Error(t, err, "a base rule can only be overridden once")
+}
+
+func TestLoadRulesMultipleDicts(t *testing.T) {
+	dir := t.TempDir()
+	marketing := filepath.Join(dir, "marketing.yaml")
+	legal := filepath.Join(dir, "legal.json")
+	require.NoError(t, os.WriteFile(marketing, []byte("- {name: synergy, pattern: synergy, weight: 5}\n"), 0644))
+	require.NoError(t, os.WriteFile(legal, []byte(`[{"name": "herein", "pattern": "herein", "weight": 2}]`), 0644))
+
+	set, err := LoadRules([]string{marketing, legal})
+	require.NoError(t, err)
+	rules := set.Active()
+	require.Len(t, rules, len(baseRules)+2)
+	assert.Equal(t, "synergy", rules[len(baseRules)].Name, "files are merged in order")
+	assert.Equal(t, "herein", rules[len(baseRules)+1].Name)
+
+	// Name collisions apply across files
+	require.NoError(t, os.WriteFile(legal, []byte(`[{"name": "Synergy", "pattern": "herein", "weight": 2}]`), 0644))
+	_, err = LoadRules([]string{marketing, legal})
+	assert.ErrorContains(t, err, "duplicate rule name")
+}
+
+// TestLoadRulesFromReader verifies decoding a dict from a reader in each
+// format, without the base rules.
+func TestLoadRulesFromReader(t *testing.T) {
+	for format, dict := range map[string]string{
+		"json": `[{"name": "mark", "pattern": "MARK", "weight": 4}]`,
+		"YAML": "- name: mark\n  pattern: MARK\n  weight: 4\n",
+		"yml":  "rules:\n  - name: mark\n    pattern: MARK\n    weight: 4\n",
+		"toml": "[[rules]]\nname = \"mark\"\npattern = \"MARK\"\nweight = 4\n",
+	} {
+		t.Run(format, func(t *testing.T) {
+			rules, err := LoadRulesFromReader(strings.NewReader(dict), format)
+			require.NoError(t, err)
+			assert.Equal(t, []Rule{{Name: "mark", Pattern: "MARK", Weight: 4}}, rules)
+		})
+	}
+
+	_, err := LoadRulesFromReader(strings.NewReader("- name: mark\n"), "json")
+	assert.ErrorContains(t, err, "must be JSON")
+	_, err = LoadRulesFromReader(strings.NewReader("[]"), "xml")
+	assert.Error(t, err)
+	_, err = LoadRulesFromReader(strings.NewReader("- name: x\n  weight: 1\n  wordList: missing.txt\n"), "yaml")
+	assert.Error(t, err)
+}
+
+// TestMergeWithBase verifies custom rules follow the base rules and
+// replace same-named ones in place.
+func TestMergeWithBase(t *testing.T) {
+	assert.Equal(t, baseRules, MergeWithBase(nil))
+
+	rules := MergeWithBase([]Rule{
+		{Name: "EM-DASH", Pattern: "—", Weight: 1},
+		{Name: "mark", Pattern: "MARK", Weight: 4},
+		{Name: "mark", Pattern: "MARK", Weight: 5},
+	})
+	require.Len(t, rules, len(baseRules)+1)
+	assert.Equal(t, 1, rules[2].Weight, "em-dash replaced in place")
+	assert.Equal(t, Rule{Name: "mark", Pattern: "MARK", Weight: 5}, rules[len(baseRules)], "the last definition wins")
+	assert.Equal(t, 3, baseRules[2].Weight, "base rules are not modified")
+}
+
+func TestCheckPatternCollisions(t *testing.T) {
+	assert.NoError(t, checkPatternCollisions(baseRules))
+	assert.NoError(t, checkPatternCollisions([]Rule{
+		{Name: "a", WordList: "a.txt"},
+		{Name: "b", WordList: "b.txt"},
+	}), "rules without a pattern never collide")
+
+	err := checkPatternCollisions(append(baseRules, Rule{Name: "dash", Pattern: "\u2014"}))
+	assert.ErrorContains(t, err, "rules em-dash and dash")
+}
+
+func TestLoadScanRulesStrictDict(t *testing.T) {
+	cfg := Config{ExtraRules: []Rule{{Name: "dash", Pattern: "\u2014", Weight: 1}}}
+	rules, err := loadScanRules(cfg)
+	require.NoError(t, err)
+	assert.Len(t, rules, len(baseRules)+1)
+
+	cfg.StrictDict = true
+	_, err = loadScanRules(cfg)
+	assert.Error(t, err)
+
+	cfg.ExtraRules = []Rule{{Name: "x", Pattern: "x"}, {Name: "X", Pattern: "y"}}
+	cfg.StrictDict = false
+	_, err = loadScanRules(cfg)
+	assert.Error(t, err, "config-file rules are checked for duplicate names too")
+}
+
+func TestFilterRules(t *testing.T) {
+	rules := FilterRules(baseRules, []string{"EM-DASH", "no-such-rule"})
+	assert.Len(t, rules, len(baseRules)-1)
+	for _, r := range rules {
+		assert.NotEqual(t, "em-dash", r.Name)
+	}
+	assert.Equal(t, "em-dash", baseRules[2].Name, "base rules are not modified")
+	assert.Equal(t, baseRules, FilterRules(baseRules, nil))
+}
+
+// TestOverrideWeights verifies a weight override changes only the named
+// rule's score, and that weight 0 keeps its hits in the detail.
+func TestOverrideWeights(t *testing.T) {
+	rules := OverrideWeights(baseRules, map[string]int{"EM-DASH": 2, "no-such-rule": 1})
+	require.Len(t, rules, len(baseRules))
+	for i, r := range rules {
+		if r.Name == "em-dash" {
+			assert.Equal(t, 2, r.Weight)
+			continue
+		}
+		assert.Equal(t, baseRules[i].Weight, r.Weight, r.Name)
+	}
+	assert.Equal(t, 3, baseRules[2].Weight, "base rules are not modified")
+	assert.Equal(t, baseRules, OverrideWeights(baseRules, nil))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one — two – three"), 0644))
+	scan := func(weights map[string]int) Result {
+		t.Helper()
+		results, err := Scan(context.Background(), []string{dir}, Config{Threshold: 1, WeightOverrides: weights})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+	assert.Equal(t, 13.0, scan(nil).Score)
+	assert.Equal(t, 12.0, scan(map[string]int{"em-dash": 2}).Score, "the en-dash keeps its weight")
+	assert.Equal(t, 5.0, scan(map[string]int{"em-dash": -5}).Score)
+
+	r := scan(map[string]int{"em-dash": 0})
+	assert.Equal(t, 10.0, r.Score)
+	require.Contains(t, r.Detail, "em-dash", "a zero weight still counts")
+	assert.Equal(t, 1, r.Detail["em-dash"].Count)
+}
+
+func TestScanDisabledRule(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one — two – three"), 0644))
+
+	results, err := Scan(context.Background(), []string{dir}, Config{Threshold: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Detail, "em-dash")
+
+	results, err = Scan(context.Background(), []string{dir}, Config{Threshold: 1, DisabledRules: []string{"em-dash"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NotContains(t, results[0].Detail, "em-dash")
+	assert.Equal(t, 10.0, results[0].Score, "only the en-dash still scores")
+}
+
+// TestRuleMaxFileSize verifies a rule is skipped on files over its
+// MaxFileSize and still runs on files at or under it.
+func TestRuleMaxFileSize(t *testing.T) {
+	rules := CompileRules([]Rule{
+		{Name: "capped", Pattern: "delve", Weight: 1, MaxFileSize: 20},
+		{Name: "open", Pattern: "delve", Weight: 1},
+	}, "")
+
+	small := AnalyseCompiled([]byte("delve into it"), "a.txt", rules, Config{Threshold: 1})
+	assert.Contains(t, small.Detail, "capped")
+	assert.Equal(t, 2, small.RawScore)
+
+	exact := AnalyseCompiled([]byte("delve "+strings.Repeat("x", 14)), "a.txt", rules, Config{Threshold: 1})
+	assert.Contains(t, exact.Detail, "capped", "a file of exactly MaxFileSize bytes is scored")
+
+	big := AnalyseCompiled([]byte("delve into it, at length"), "a.txt", rules, Config{Threshold: 1})
+	assert.NotContains(t, big.Detail, "capped")
+	assert.Contains(t, big.Detail, "open")
+	assert.Equal(t, 1, big.RawScore)
+}
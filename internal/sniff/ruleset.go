@@ -0,0 +1,134 @@
+package sniff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RuleSet holds rules with unique names (ignoring case), for combining
+// rules from several sources. A disabled rule stays in the set, so it can
+// be listed or re-added elsewhere, but Active leaves it out.
+//
+// The zero RuleSet is empty and ready to use. Copies share state, so
+// modify a RuleSet through one variable only.
+type RuleSet struct {
+	rules    []Rule
+	disabled map[string]bool // lower-cased names
+}
+
+// NewRuleSet returns a set of rules, failing on a repeated name.
+func NewRuleSet(rules ...Rule) (RuleSet, error) {
+	var s RuleSet
+	err := s.Add(rules...)
+	return s, err
+}
+
+// Add appends rules in order. A name already in the set, or given twice,
+// is an error and then nothing is added.
+func (s *RuleSet) Add(rules ...Rule) error {
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		key := strings.ToLower(r.Name)
+		if seen[key] || s.index(key) >= 0 {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		seen[key] = true
+	}
+	s.rules = append(s.rules, rules...)
+	return nil
+}
+
+// Remove drops the named rule and reports whether it was in the set.
+func (s *RuleSet) Remove(name string) bool {
+	key := strings.ToLower(name)
+	i := s.index(key)
+	if i < 0 {
+		return false
+	}
+	s.rules = append(s.rules[:i:i], s.rules[i+1:]...)
+	delete(s.disabled, key)
+	return true
+}
+
+// Disable keeps the named rule but leaves it out of Active, and reports
+// whether it was in the set.
+func (s *RuleSet) Disable(name string) bool {
+	key := strings.ToLower(name)
+	if s.index(key) < 0 {
+		return false
+	}
+	if s.disabled == nil {
+		s.disabled = make(map[string]bool)
+	}
+	s.disabled[key] = true
+	return true
+}
+
+// Merge adds every rule of other, disabled ones staying disabled. Like
+// Add, a name in both sets is an error and nothing is merged.
+func (s *RuleSet) Merge(other RuleSet) error {
+	if err := s.Add(other.rules...); err != nil {
+		return err
+	}
+	for key := range other.disabled {
+		if s.disabled == nil {
+			s.disabled = make(map[string]bool)
+		}
+		s.disabled[key] = true
+	}
+	return nil
+}
+
+// Active returns the enabled rules in order.
+func (s RuleSet) Active() []Rule {
+	out := make([]Rule, 0, len(s.rules))
+	for _, r := range s.rules {
+		if !s.disabled[strings.ToLower(r.Name)] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Len returns the number of rules, disabled ones included.
+func (s RuleSet) Len() int {
+	return len(s.rules)
+}
+
+// Compile compiles the active rules for matching; see CompileRules. It
+// is how a set reaches scoring, so disabled rules never score.
+func (s RuleSet) Compile(form string) []CompiledRule {
+	return CompileRules(s.Active(), form)
+}
+
+// MarshalJSON writes the active rules as a plain rule list, the format
+// dictionaries use.
+func (s RuleSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Active())
+}
+
+// UnmarshalJSON reads a plain rule list, failing on a repeated name.
+func (s *RuleSet) UnmarshalJSON(b []byte) error {
+	var rules []Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return err
+	}
+	set, err := NewRuleSet(rules...)
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// index returns the position of the rule with the lower-cased name key,
+// or -1.
+func (s RuleSet) index(key string) int {
+	for i, r := range s.rules {
+		if strings.ToLower(r.Name) == key {
+			return i
+		}
+	}
+	return -1
+}
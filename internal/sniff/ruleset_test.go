@@ -0,0 +1,103 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleNames(rules []Rule) []string {
+	names := make([]string, 0, len(rules))
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestRuleSetAdd(t *testing.T) {
+	set, err := NewRuleSet(Rule{Name: "a", Pattern: "a"}, Rule{Name: "b", Pattern: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+
+	require.NoError(t, set.Add(Rule{Name: "c", Pattern: "c"}))
+	assert.Equal(t, []string{"a", "b", "c"}, ruleNames(set.Active()))
+
+	err = set.Add(Rule{Name: "d", Pattern: "d"}, Rule{Name: "B", Pattern: "x"})
+	require.ErrorContains(t, err, `duplicate rule name "B"`)
+	assert.Equal(t, 3, set.Len(), "a failed Add adds nothing")
+
+	err = set.Add(Rule{Name: "e", Pattern: "e"}, Rule{Name: "E", Pattern: "e"})
+	require.Error(t, err)
+	assert.Equal(t, 3, set.Len())
+
+	_, err = NewRuleSet(Rule{Name: "a"}, Rule{Name: "a"})
+	assert.Error(t, err)
+}
+
+func TestRuleSetRemove(t *testing.T) {
+	set, err := NewRuleSet(Rule{Name: "a"}, Rule{Name: "b"}, Rule{Name: "c"})
+	require.NoError(t, err)
+	require.True(t, set.Disable("b"))
+
+	assert.True(t, set.Remove("B"))
+	assert.False(t, set.Remove("b"))
+	assert.Equal(t, []string{"a", "c"}, ruleNames(set.Active()))
+
+	require.NoError(t, set.Add(Rule{Name: "b"}), "a removed name can be added again")
+	assert.Equal(t, []string{"a", "c", "b"}, ruleNames(set.Active()), "and is enabled")
+}
+
+func TestRuleSetDisable(t *testing.T) {
+	var set RuleSet
+	require.NoError(t, set.Add(Rule{Name: "a", Pattern: "alpha"}, Rule{Name: "b", Pattern: "beta"}))
+
+	assert.True(t, set.Disable("A"))
+	assert.False(t, set.Disable("missing"))
+	assert.Equal(t, []string{"b"}, ruleNames(set.Active()))
+	assert.Equal(t, 2, set.Len())
+
+	compiled := set.Compile("")
+	require.Len(t, compiled, 1)
+	assert.Equal(t, "b", compiled[0].Name)
+	r := AnalyseCompiled([]byte("alpha beta"), "a.txt", compiled, Config{Threshold: 1})
+	assert.NotContains(t, r.Detail, "a", "a disabled rule does not score")
+	assert.Contains(t, r.Detail, "b")
+
+	assert.Error(t, set.Add(Rule{Name: "a"}), "a disabled rule still holds its name")
+}
+
+func TestRuleSetMerge(t *testing.T) {
+	set, err := NewRuleSet(Rule{Name: "a"})
+	require.NoError(t, err)
+	other, err := NewRuleSet(Rule{Name: "b"}, Rule{Name: "c"})
+	require.NoError(t, err)
+	other.Disable("c")
+
+	require.NoError(t, set.Merge(other))
+	assert.Equal(t, 3, set.Len())
+	assert.Equal(t, []string{"a", "b"}, ruleNames(set.Active()), "c stays disabled")
+
+	conflict, err := NewRuleSet(Rule{Name: "d"}, Rule{Name: "A"})
+	require.NoError(t, err)
+	require.ErrorContains(t, set.Merge(conflict), `duplicate rule name "A"`)
+	assert.Equal(t, 3, set.Len(), "a failed Merge merges nothing")
+}
+
+func TestRuleSetJSON(t *testing.T) {
+	set, err := NewRuleSet(Rule{Name: "a", Pattern: "alpha", Weight: 2}, Rule{Name: "b", Pattern: "beta", Weight: 1})
+	require.NoError(t, err)
+	set.Disable("b")
+
+	b, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	var back RuleSet
+	require.NoError(t, json.Unmarshal(b, &back))
+	assert.Equal(t, set.Active(), back.Active())
+	assert.Equal(t, 1, back.Len(), "disabled rules are not written")
+
+	assert.Error(t, json.Unmarshal([]byte(`[{"name":"x"},{"name":"X"}]`), &back))
+}
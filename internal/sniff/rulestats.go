@@ -0,0 +1,65 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RuleAggregate is one rule's activity across a whole scan.
+type RuleAggregate struct {
+	Rule                   string `json:"rule"`
+	TotalHits              int    `json:"total_hits"`               // occurrences in every file, excluded ones too
+	FilesHit               int    `json:"files_hit"`                // files the rule matched in
+	TotalScoreContribution int    `json:"total_score_contribution"` // scored hits × weight, summed
+}
+
+// AggregateRuleStats totals each rule's hits over results, ranked by
+// hits, then score contribution, then name. Rules that never matched are
+// left out.
+func AggregateRuleStats(results []Result) []RuleAggregate {
+	byRule := make(map[string]*RuleAggregate)
+	for _, r := range results {
+		for name, h := range r.Detail {
+			a, ok := byRule[name]
+			if !ok {
+				a = &RuleAggregate{Rule: name}
+				byRule[name] = a
+			}
+			a.TotalHits += h.Count
+			a.FilesHit++
+			a.TotalScoreContribution += h.Scored * h.Rule.Weight
+		}
+	}
+
+	out := make([]RuleAggregate, 0, len(byRule))
+	for _, a := range byRule {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.TotalHits != b.TotalHits {
+			return a.TotalHits > b.TotalHits
+		}
+		if a.TotalScoreContribution != b.TotalScoreContribution {
+			return a.TotalScoreContribution > b.TotalScoreContribution
+		}
+		return a.Rule < b.Rule
+	})
+	return out
+}
+
+// RenderRuleStats prints stats as a ranked list, one rule per line, e.g.
+// "  em-dash: 412 hits in 37 file(s), +1236".
+func RenderRuleStats(w io.Writer, stats []RuleAggregate, cfg Config) {
+	st := newTextStyle(w, cfg)
+	if len(stats) == 0 {
+		fmt.Fprintln(w, st.meta("Rule stats: no rule matched"))
+		return
+	}
+	fmt.Fprintln(w, st.meta("Rule stats:"))
+	for _, a := range stats {
+		fmt.Fprintf(w, "  %s: %s in %d file(s), %s\n", st.rule(a.Rule), plural(a.TotalHits, "hit", "hits"), a.FilesHit,
+			st.meta(fmt.Sprintf("+%d", a.TotalScoreContribution)))
+	}
+}
@@ -0,0 +1,58 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateRuleStats(t *testing.T) {
+	dash := Rule{Name: "em-dash", Weight: 3}
+	delve := Rule{Name: "delve", Weight: 10}
+	quote := Rule{Name: "quote", Weight: 5}
+	results := []Result{
+		{Path: "a.md", Detail: map[string]RuleHit{
+			"em-dash": {Rule: dash, Count: 4, Scored: 4},
+			"delve":   {Rule: delve, Count: 1, Scored: 1},
+		}},
+		{Path: "b.md", Detail: map[string]RuleHit{
+			"em-dash": {Rule: dash, Count: 2, Scored: 2},
+			"delve":   {Rule: delve, Count: 5, Scored: 2}, // capped by maxMatches
+		}},
+		{Path: "c.md", Detail: map[string]RuleHit{
+			"quote": {Rule: quote, Count: 6, Scored: 0, Excluded: true},
+		}},
+		{Path: "clean.md"},
+	}
+
+	assert.Equal(t, []RuleAggregate{
+		{Rule: "delve", TotalHits: 6, FilesHit: 2, TotalScoreContribution: 30},
+		{Rule: "em-dash", TotalHits: 6, FilesHit: 2, TotalScoreContribution: 18},
+		{Rule: "quote", TotalHits: 6, FilesHit: 1, TotalScoreContribution: 0},
+	}, AggregateRuleStats(results))
+	assert.Empty(t, AggregateRuleStats(nil))
+
+	var buf bytes.Buffer
+	RenderRuleStats(&buf, AggregateRuleStats(results[:1]), Config{Color: ColorNever})
+	assert.Equal(t, "Rule stats:\n  em-dash: 4 hits in 1 file(s), +12\n  delve: 1 hit in 1 file(s), +10\n", buf.String())
+}
+
+func TestRenderJSONRuleStats(t *testing.T) {
+	results := []Result{{Path: "a.md", Detail: map[string]RuleHit{"x": {Rule: Rule{Name: "x", Weight: 2}, Count: 3, Scored: 3}}}}
+
+	var buf bytes.Buffer
+	Render(&buf, results, Config{Format: FormatJSON})
+	assert.NotContains(t, buf.String(), "rule_stats")
+
+	buf.Reset()
+	Render(&buf, results, Config{Format: FormatJSON, RuleStats: true})
+	var report struct {
+		RuleStats []map[string]any `json:"rule_stats"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, []map[string]any{{"rule": "x", "total_hits": 3.0, "files_hit": 1.0, "total_score_contribution": 6.0}}, report.RuleStats)
+}
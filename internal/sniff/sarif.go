@@ -0,0 +1,150 @@
+package sniff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "synthsniff"
+	toolURI      = "https://github.com/JoobyPM/synthsniff"
+)
+
+// SARIF 2.1.0 subset used by GitHub code scanning and IDE viewers.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func renderSARIF(w io.Writer, list []Result) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(buildSARIF(list)); err != nil {
+		slog.Error("sarif encode failed", "err", err)
+	}
+}
+
+// buildSARIF emits one result per triggered rule of every smelly or
+// warning file, at the level of the rule's severity.
+func buildSARIF(list []Result) sarifLog {
+	ruleIndex := make(map[string]int)
+	rules := []sarifRule{}
+	results := []sarifResult{}
+
+	for _, r := range list {
+		if !r.Smelly && !r.Warning {
+			continue
+		}
+		for _, n := range hitNames(r) {
+			h := r.Detail[n]
+			if h.Excluded {
+				continue // matched, but not a finding
+			}
+			idx, ok := ruleIndex[n]
+			if !ok {
+				idx = len(rules)
+				ruleIndex[n] = idx
+				sr := sarifRule{ID: n}
+				if h.Rule.Description != "" {
+					sr.ShortDescription = &sarifMessage{Text: h.Rule.Description}
+				}
+				rules = append(rules, sr)
+			}
+
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(r.Path)},
+			}
+			if line := h.FirstLine(); line > 0 {
+				loc.Region = &sarifRegion{StartLine: line}
+			}
+			results = append(results, sarifResult{
+				RuleID:    n,
+				RuleIndex: idx,
+				Level:     sarifLevel(h.Rule.severity()),
+				Message: sarifMessage{Text: fmt.Sprintf(
+					"%s matched %d time(s); file score %s", n, h.Count, FormatScore(r.Score))},
+				Locations: []sarifLocation{{PhysicalLocation: loc}},
+			})
+		}
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// sarifLevel maps a rule severity to a SARIF level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityInfo:
+		return "note"
+	case SeverityError:
+		return "error"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,82 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRenderSARIF verifies the SARIF document shape and per-rule results.
+func TestRenderSARIF(t *testing.T) {
+	results := []Result{
+		{
+			Path:   "clean.md",
+			Score:  10,
+			Detail: map[string]RuleHit{"rule1": {Rule: Rule{Name: "rule1"}, Count: 1, Lines: []int{2}}},
+		},
+		{
+			Path:  "docs/smelly.md",
+			Score: 42,
+			Detail: map[string]RuleHit{
+				"rule1": {Rule: Rule{Name: "rule1", Description: "first rule", Severity: SeverityError}, Count: 5, Lines: []int{3}},
+				"rule2": {Rule: Rule{Name: "rule2"}, Count: 3, Lines: []int{7}},
+				"rule3": {Rule: Rule{Name: "rule3", Severity: SeverityInfo}, Count: 1, Lines: []int{9}},
+			},
+			Smelly: true,
+		},
+	}
+
+	output := captureOutput(func() {
+		assert.True(t, Render(os.Stdout, results, Config{Format: FormatSARIF}).AnyErrors)
+	})
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(output), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "synthsniff", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 3)
+	assert.Equal(t, "rule1", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "first rule", run.Tool.Driver.Rules[0].ShortDescription.Text)
+	assert.Nil(t, run.Tool.Driver.Rules[1].ShortDescription)
+
+	// Only the smelly file is reported, once per triggered rule, at the
+	// level of the rule's severity
+	require.Len(t, run.Results, 3)
+	for i, res := range run.Results {
+		assert.Equal(t, i, res.RuleIndex)
+		assert.Equal(t, []string{"error", "warning", "note"}[i], res.Level)
+		assert.Equal(t, "docs/smelly.md", res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	assert.Equal(t, 3, run.Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, 7, run.Results[1].Locations[0].PhysicalLocation.Region.StartLine)
+}
+
+// TestRenderSARIF_NoResults verifies an empty scan still yields valid arrays.
+func TestRenderSARIF_NoResults(t *testing.T) {
+	output := captureOutput(func() {
+		assert.Equal(t, RenderResult{}, Render(os.Stdout, nil, Config{Format: FormatSARIF}))
+	})
+	assert.Contains(t, output, `"results": []`)
+	assert.Contains(t, output, `"rules": []`)
+}
+
+// TestAnalyseSARIFLine verifies analyse records the first matching line.
+func TestAnalyseSARIFLine(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("one\ntwo MARK\nthree MARK\n"), 0644))
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}}
+
+	result := analyse(testFile, CompileRules(rules, ""), Config{Threshold: 1, Format: FormatSARIF})
+	assert.Equal(t, 2, result.Detail["mark"].FirstLine())
+
+	result = analyse(testFile, CompileRules(rules, ""), Config{Threshold: 1})
+	assert.Zero(t, result.Detail["mark"].Lines, "line lookup should only run for SARIF")
+}
@@ -1,21 +1,21 @@
 package sniff
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// LoadedIgnoreFiles keeps track of the ignore files loaded during scanning
-// so they can be reported at the end
-var LoadedIgnoreFiles []string
-
 // getMaxProcs returns the number of available cores, limited to 4
 func getMaxProcs() int {
 	maxProcs := runtime.NumCPU()
@@ -30,58 +30,230 @@ var mmapGate = make(chan struct{}, getMaxProcs())
 
 // RuleHit stores hit count plus full rule metadata.
 type RuleHit struct {
-	Rule  Rule `json:"rule"`
-	Count int  `json:"count"`
+	Rule     Rule     `json:"rule"`
+	Count    int      `json:"count"`              // every occurrence
+	Scored   int      `json:"scored"`             // occurrences that scored, at most Rule.MaxMatches
+	Excluded bool     `json:"excluded,omitempty"` // an exclude pattern matched, so nothing scored
+	Lines    []int    `json:"lines,omitempty"`    // 1-based line of each match, see Config.CollectLines
+	Snippets []string `json:"snippets,omitempty"` // context around each match, see Config.Snippets
+}
+
+// FirstLine returns the line of the first match, or 0 when lines were
+// not collected.
+func (h RuleHit) FirstLine() int {
+	if len(h.Lines) == 0 {
+		return 0
+	}
+	return h.Lines[0]
 }
 
 // Result is one file's outcome.
 type Result struct {
-	Path   string             `json:"path"`
-	Score  int                `json:"score"`
-	Detail map[string]RuleHit `json:"detail,omitempty"`
-	Smelly bool               `json:"smelly"`
+	Path        string             `json:"path"`
+	Score       float64            `json:"score"`    // RawScore, or per KB with Config.Normalize
+	RawScore    int                `json:"rawScore"` // sum of scored hits × weight
+	Detail      map[string]RuleHit `json:"detail,omitempty"`
+	Smelly      bool               `json:"smelly"`
+	Warning     bool               `json:"warning,omitempty"`     // between Config.WarnThreshold and Threshold
+	Confidence  float64            `json:"confidence"`            // 0 to 1, the score's share of Config.ConfidenceScale × Threshold
+	Allowlisted bool               `json:"allowlisted,omitempty"` // matched Config.Allowlist, so never smelly
+	Grade       string             `json:"grade,omitempty"`       // A to F with Config.Grades
+	Chunked     bool               `json:"chunked,omitempty"`     // over Config.MaxSize, scored in chunks with Config.ChunkedScan
+	ChunkCount  int                `json:"chunkCount,omitempty"`  // chunks scored when Chunked
+	Heatmap     []int              `json:"heatmap,omitempty"`     // tracked matches per equal section of the file; see Config.Heatmap
+	Aliases     []string           `json:"aliases,omitempty"`     // other paths with the same content, skipped with Config.DeduplicateContent
+	Skipped     bool               `json:"skipped,omitempty"`     // left unscored, see SkipReason
+	SkipReason  string             `json:"skipReason,omitempty"`  // why, e.g. SkipTooSmall
+	Err         string             `json:"error,omitempty"`       // why the file could not be read; the scan goes on, see Config.MaxErrors
+	Duration    time.Duration      `json:"-"`                     // read and score time with Config.TimingMode, JSON duration_ms
+}
+
+// Clean reports whether r was read and scored and is not smelly; files
+// that could not be read or were skipped are neither.
+func (r Result) Clean() bool {
+	return !r.Smelly && r.Err == "" && !r.Skipped
+}
+
+// MarshalJSON writes Duration as duration_ms, in milliseconds, when the
+// result was timed, and heatmapPeak with a heatmap.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	var peak *int
+	if p := r.HeatmapPeak(); p >= 0 {
+		peak = &p
+	}
+	return json.Marshal(struct {
+		plain
+		HeatmapPeak *int    `json:"heatmapPeak,omitempty"`
+		DurationMS  float64 `json:"duration_ms,omitempty"`
+	}{plain(r), peak, durationMS(r.Duration)})
+}
+
+// durationMS converts d to milliseconds, keeping microseconds.
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// scanGracePeriod is how long a cancelled or timed-out scan waits for
+// files already being scored before it gives up on them.
+var scanGracePeriod = 5 * time.Second
+
+// Scanner scores files with a rule set loaded once, so repeated scans,
+// a watch loop or a server skip reloading dictionaries. It is safe for
+// concurrent use.
+type Scanner struct {
+	cfg   Config
+	rules []CompiledRule
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// ErrScannerClosed is returned by scans started after Scanner.Close.
+var ErrScannerClosed = errors.New("scanner closed")
+
+// ErrTooManyErrors ends a scan once more than Config.MaxErrors files
+// could not be read.
+var ErrTooManyErrors = errors.New("too many file errors")
+
+// NewScanner builds a Config with NewConfig(opts...) and loads its
+// rules: the built-in ones, dictionaries and ExtraRules, less the
+// disabled ones.
+func NewScanner(opts ...Option) (*Scanner, error) {
+	cfg := NewConfig(opts...)
+	rules, err := loadScanRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{cfg: cfg, rules: rules}, nil
 }
 
 // Scan recursively walks each path and scores files.
 //
-// It returns a list of results sorted by path.
-func Scan(roots []string, cfg Config) ([]Result, error) {
-	// Load rules
-	rules, err := LoadRules(cfg.DictPath)
+// It returns a list of results sorted by path. A file that cannot be read
+// gets a result with Err set and the scan goes on. Cancelling ctx stops
+// the walk and any batches not yet started, and Scan returns ctx.Err().
+// When the Timeout passes first, or more than MaxErrors files fail, Scan
+// returns the results collected so far with an error wrapping
+// context.DeadlineExceeded or ErrTooManyErrors.
+func (s *Scanner) Scan(ctx context.Context, roots []string) ([]Result, error) {
+	results, err := DrainResults(s.ScanStream(ctx, roots))
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrTooManyErrors) {
+		return nil, err
+	}
+
+	// Sort results by path
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+
+	return results, err
+}
+
+// ScanStream is Scan without buffering: results are sent as workers produce
+// them, in no particular order; sort them afterwards if needed. With
+// Config.DeduplicateContent they are held until the last file is scored,
+// so each carries its aliases. The result channel is closed when the scan
+// ends; the error channel then yields at most one error (a walk failure,
+// cancellation, timeout or ErrTooManyErrors) and is closed too.
+//
+// The result channel must be read until it is closed, also after ctx is
+// cancelled, or the scan's goroutines block on it and leak. DrainResults
+// does that for a consumer that stops early.
+func (s *Scanner) ScanStream(ctx context.Context, roots []string) (<-chan Result, <-chan error) {
+	if err := s.checkOpen(); err != nil {
+		return failedScan(err)
+	}
+	return scanStream(ctx, roots, s.rules, s.cfg)
+}
+
+// checkOpen returns ErrScannerClosed once Close was called.
+func (s *Scanner) checkOpen() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrScannerClosed
+	}
+	return nil
+}
+
+// Rules returns the loaded rules, in matching order.
+func (s *Scanner) Rules() []Rule {
+	return Rules(s.rules)
+}
+
+// Config returns the scanner's settings.
+func (s *Scanner) Config() Config {
+	return s.cfg
+}
+
+// Close stops the scanner from starting new scans; scans already
+// running finish normally. It always returns nil.
+func (s *Scanner) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Scan loads cfg's rules and scans roots once; see Scanner.Scan. Use a
+// Scanner to scan more than once with the same rules.
+func Scan(ctx context.Context, roots []string, cfg Config) ([]Result, error) {
+	s, err := NewScanner(WithConfig(cfg))
 	if err != nil {
 		return nil, err
 	}
+	defer s.Close()
+	return s.Scan(ctx, roots)
+}
 
-	// Initialize ignore rules if gitignore support is enabled
-	var ignoreRules *IgnoreRules
-	if cfg.UseGitignore {
-		ignoreRules = NewIgnoreRules()
+// ScanStream loads cfg's rules and streams one scan of roots; see
+// Scanner.ScanStream. A rule loading error arrives on the error channel.
+func ScanStream(ctx context.Context, roots []string, cfg Config) (<-chan Result, <-chan error) {
+	s, err := NewScanner(WithConfig(cfg))
+	if err != nil {
+		return failedScan(err)
+	}
+	defer s.Close()
+	return s.ScanStream(ctx, roots)
+}
 
-		// Reset the global ignore files list at the start of a scan
-		LoadedIgnoreFiles = nil
+// failedScan returns ScanStream's channels for a scan that could not
+// start: no results, then err.
+func failedScan(err error) (<-chan Result, <-chan error) {
+	resultsChan := make(chan Result)
+	close(resultsChan)
+	errChan := make(chan error, 1)
+	errChan <- err
+	close(errChan)
+	return resultsChan, errChan
+}
 
-		// Load custom ignore file if specified
-		if cfg.IgnoreFile != "" {
-			if err := ignoreRules.LoadCustomIgnoreFile(cfg.IgnoreFile); err != nil {
-				return nil, fmt.Errorf("failed to load ignore file: %v", err)
-			}
-			// Add to global list instead of cfg.LoadedIgnoreFiles
-			LoadedIgnoreFiles = append(LoadedIgnoreFiles, cfg.IgnoreFile)
-		}
+// scanStream runs one scan with compiled rules; see Scanner.ScanStream.
+func scanStream(ctx context.Context, roots []string, rules []CompiledRule, cfg Config) (<-chan Result, <-chan error) {
+	ignoreRules, err := prepareWalk(roots, cfg)
+	var allow allowlist
+	if err == nil {
+		allow, err = loadAllowlist(cfg.Allowlist)
+	}
+	if err != nil {
+		return failedScan(err)
+	}
 
-		// Pre-load gitignore files from all root directories
-		for _, root := range roots {
-			info, err := os.Stat(root)
-			if err != nil {
-				return nil, err
-			}
+	errChan := make(chan error, 1)
+	// Too many file errors cancel the scan with ErrTooManyErrors as cause
+	ctx, abort := context.WithCancelCause(ctx)
+	cancel := func() { abort(nil) }
+	if cfg.Timeout > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, cfg.Timeout)
+		cancel = func() { stop(); abort(nil) }
+	}
 
-			if info.IsDir() {
-				if err := ignoreRules.FindAndLoadGitignores(root); err != nil {
-					return nil, fmt.Errorf("failed to load gitignore files: %v", err)
-				}
-			}
-		}
+	// Reuse results for unchanged files when a cache directory is set
+	var cache *scanCache
+	if cfg.CacheDir != "" {
+		cache = loadCache(cfg.CacheDir, cacheFingerprint(rules, cfg))
 	}
 
 	// Set number of workers
@@ -91,35 +263,106 @@ func Scan(roots []string, cfg Config) ([]Result, error) {
 	}
 
 	// Create job channels for each worker (buffered with size 4)
-	jobChannels := make([]chan []string, numWorkers)
+	jobChannels := make([]chan []scanJob, numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		jobChannels[i] = make(chan []string, 4)
+		jobChannels[i] = make(chan []scanJob, 4)
 	}
 
 	// Create a shared results channel
 	resultsChan := make(chan Result, numWorkers)
 
+	// send hands a result to the caller and counts it for progress
+	// and metrics. Results from workers that outlive the grace period
+	// are dropped, as the channel is closed by then.
+	var gate sync.RWMutex
+	abandoned := false
+	send := func(r Result) {
+		gate.RLock()
+		defer gate.RUnlock()
+		if abandoned {
+			return
+		}
+		r = allow.apply(r, r.Path)
+		if r.Smelly {
+			cfg.Progress.addSmelly()
+		}
+		cfg.Metrics.observe(r)
+		cfg.onResult(r)
+		resultsChan <- r
+	}
+
+	// emit sends a result, or holds it until the scan ends so it can
+	// carry its aliases when duplicate content is skipped. Files that
+	// could not be read are counted here, so MaxErrors stops the scan
+	// even while results are held.
+	dups := newDedup(cfg)
+	pass := send
+	if dups != nil {
+		pass = dups.hold
+	}
+	var fileErrors atomic.Int64
+	emit := func(r Result) {
+		if r.Err != "" {
+			cfg.Metrics.addError()
+			if n := fileErrors.Add(1); cfg.MaxErrors > 0 && n > int64(cfg.MaxErrors) {
+				abort(fmt.Errorf("%w: more than %d", ErrTooManyErrors, cfg.MaxErrors))
+			}
+		}
+		pass(r)
+	}
+
 	// Start worker goroutines
 	var workersWg sync.WaitGroup
 	workersWg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
 		go func(workerID int) {
 			defer workersWg.Done()
+			files := 0
+			defer func() { slog.Debug("worker finished", "worker", workerID, "files", files) }()
+			// Rule timings go to the worker's own profiler, merged on exit
+			cfg := cfg
+			if cfg.Profiler != nil {
+				cfg.Profiler = cfg.Profiler.worker()
+				defer cfg.Profiler.flush()
+			}
 			// Each worker processes files from its own dedicated channel
-			for paths := range jobChannels[workerID] {
-				for _, path := range paths {
-					resultsChan <- analyse(path, rules, cfg)
+			for jobs := range jobChannels[workerID] {
+				// Keep draining after cancellation so the walker never blocks
+				if ctx.Err() != nil {
+					continue
+				}
+				for _, job := range jobs {
+					path := job.path
+					start := time.Now()
+					// Files under a directory config get its threshold and rules
+					rules, cfg := job.dir.apply(rules, cfg)
+					switch {
+					case job.err != nil:
+						// A directory the walk could not read
+						emit(cfg.failedResult(path, job.err))
+					case path == StdinPath:
+						emit(analyseStdin(rules, cfg))
+					case isArchive(path):
+						if err := scanArchive(path, rules, cfg, emit); err != nil {
+							slog.Error("archive scan failed", "path", path, "err", err)
+							emit(cfg.failedResult(path, err))
+						}
+					case dups != nil && dups.duplicate(path):
+						// Counted as done, reported as an alias of the first copy
+					case cache != nil && job.dir == nil:
+						// The cache is keyed by the scan's own rules and options
+						emit(analyseCached(path, rules, cfg, cache))
+					default:
+						emit(analyse(path, rules, cfg))
+					}
+					cfg.Metrics.observeDuration(time.Since(start))
+					cfg.Progress.addDone()
+					files++
 				}
 			}
 		}(i)
 	}
 
-	// Start a goroutine to close the results channel when all workers are done
-	go func() {
-		workersWg.Wait()
-		close(resultsChan)
-	}()
-
 	// Start a goroutine to walk the directories and distribute files to workers
 	walkerErrorChan := make(chan error, 1)
 	go func() {
@@ -130,31 +373,317 @@ func Scan(roots []string, cfg Config) ([]Result, error) {
 			}
 		}()
 
-		err := walkDirBreadthFirst(roots, cfg.DictPath, jobChannels, ignoreRules, cfg.UseGitignore)
+		err := walkDirBreadthFirst(ctx, roots, jobChannels, scanWalkOptions(cfg, rules, ignoreRules))
+		// The walk is the only caller of ShouldIgnore, so the counts are final
+		cfg.IgnoreStats.add(ignoreRules)
 		walkerErrorChan <- err
 	}()
 
-	// Collect results as they arrive
-	var results []Result
-	for result := range resultsChan {
-		results = append(results, result)
+	// Close the results channel when all workers are done, then report
+	// the walker error (or cancellation) on the error channel. After a
+	// cancellation, stalled workers or a walker stuck on a slow file
+	// system get scanGracePeriod before the scan ends without them.
+	go func() {
+		defer cancel()
+		workersDone := make(chan struct{})
+		go func() {
+			workersWg.Wait()
+			close(workersDone)
+		}()
+		stalled := false
+		select {
+		case <-workersDone:
+		case <-ctx.Done():
+			select {
+			case <-workersDone:
+			case <-time.After(scanGracePeriod):
+				stalled = true
+			}
+		}
+		if dups != nil {
+			dups.flush(send)
+		}
+		gate.Lock()
+		abandoned = true
+		gate.Unlock()
+
+		if cache != nil {
+			if err := cache.save(); err != nil {
+				slog.Warn("cache write failed", "err", err)
+			}
+		}
+		close(resultsChan)
+
+		var err error
+		if !stalled {
+			err = <-walkerErrorChan
+		}
+		if err != nil {
+			cfg.Metrics.addError()
+		}
+		if ctx.Err() != nil {
+			// The walk stops on cancellation too; report why
+			err = context.Cause(ctx)
+		}
+		if errors.Is(err, context.DeadlineExceeded) && cfg.Timeout > 0 {
+			err = fmt.Errorf("scan timed out after %v: %w", cfg.Timeout, err)
+		}
+		if err != nil {
+			errChan <- err
+		}
+		close(errChan)
+	}()
+
+	return resultsChan, errChan
+}
+
+// DrainResults reads results until ScanStream closes it and returns what
+// was left, plus the scan's error, if any.
+func DrainResults(results <-chan Result, errs <-chan error) ([]Result, error) {
+	var rest []Result
+	for r := range results {
+		rest = append(rest, r)
 	}
+	return rest, <-errs
+}
 
-	// Check if the directory walker encountered an error
-	if err := <-walkerErrorChan; err != nil {
+// loadScanRules loads the dictionaries plus config-file rules and compiles
+// them once, before any worker starts.
+func loadScanRules(cfg Config) ([]CompiledRule, error) {
+	start := time.Now()
+	rules, err := loadRules(cfg.DictPaths, newDictFetcher(cfg))
+	if err != nil {
 		return nil, err
 	}
+	compiled, err := buildScanRules(rules, cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("rules loaded", "rules", len(compiled), "dicts", len(cfg.DictPaths), "duration", time.Since(start))
+	return compiled, nil
+}
 
-	// Sort results by path
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Path < results[j].Path
-	})
+// buildScanRules applies cfg's rule settings to the rules loaded from its
+// dicts and compiles them. rules is not modified.
+func buildScanRules(rules []Rule, cfg Config) ([]CompiledRule, error) {
+	// Config-file rules may tune dict and base rules by name
+	rules, err := mergeRules(rules, cfg.ExtraRules)
+	if err != nil {
+		return nil, err
+	}
+	rules = OverrideWeights(rules, cfg.WeightOverrides)
+	rules = filterSeverity(FilterRules(rules, cfg.DisabledRules), cfg.MinSeverity)
+	rules = FilterTags(rules, cfg.OnlyTags, cfg.ExcludeTags)
+	if cfg.StrictDict {
+		if err := checkPatternCollisions(rules); err != nil {
+			return nil, err
+		}
+	}
+	return CompileRules(rules, cfg.UnicodeNorm), nil
+}
 
-	return results, nil
+// followSymlinks reports whether the walk should follow links, warning
+// once when the platform cannot do so safely.
+func followSymlinks(cfg Config) bool {
+	if cfg.FollowSymlinks && !symlinksSupported {
+		slog.Warn("-follow-symlinks is not supported on this platform; links are skipped")
+		return false
+	}
+	return cfg.FollowSymlinks
+}
+
+// matchesAny reports whether the base name or the full path of path
+// matches one of the filepath.Match patterns.
+func matchesAny(path string, patterns []string) bool {
+	base := filepath.Base(path)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesName reports whether path's base name matches one of patterns,
+// or whether there are none.
+func matchesName(path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	base := filepath.Base(path)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGlobs returns an error for the first malformed pattern.
+func checkGlobs(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// ruleFiles returns the absolute paths of the dictionaries and every word
+// list, which are never scored themselves.
+func ruleFiles(cfg Config, rules []CompiledRule) map[string]bool {
+	skip := make(map[string]bool)
+	add := func(p string) {
+		if abs, err := filepath.Abs(p); err == nil {
+			skip[abs] = true
+		}
+	}
+	for _, p := range cfg.DictPaths {
+		if !isRemoteDict(p) {
+			add(p)
+		}
+	}
+	for _, r := range rules {
+		if r.WordList != "" {
+			add(r.WordList)
+		}
+	}
+	return skip
+}
+
+// isRuleFile reports whether path is one of the files from ruleFiles.
+func isRuleFile(path string, skip map[string]bool) bool {
+	if len(skip) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && skip[abs]
+}
+
+// prepareWalk checks the name, include and exclude globs and loads the
+// ignore rules.
+func prepareWalk(roots []string, cfg Config) (*IgnoreRules, error) {
+	if err := checkGlobs(cfg.NamePatterns); err != nil {
+		return nil, err
+	}
+	if err := checkGlobs(cfg.IncludePatterns); err != nil {
+		return nil, err
+	}
+	if err := checkGlobs(cfg.ExcludePatterns); err != nil {
+		return nil, err
+	}
+
+	// Ignore rules always exist: the walk adds .synthsniffignore files as
+	// it meets them, and gitignore support pre-loads .gitignore files
+	ignoreRules := NewIgnoreRules()
+	if cfg.UseGitignore {
+		// Load custom ignore file if specified
+		if cfg.IgnoreFile != "" {
+			if err := ignoreRules.LoadCustomIgnoreFile(cfg.IgnoreFile); err != nil {
+				return nil, fmt.Errorf("failed to load ignore file: %v", err)
+			}
+			ignoreRules.addFile(cfg.IgnoreFile)
+		}
+
+		// Pre-load gitignore files from all root directories
+		for _, root := range roots {
+			if root == StdinPath {
+				continue
+			}
+			info, err := os.Stat(root)
+			if err != nil {
+				return nil, err
+			}
+
+			if info.IsDir() {
+				if err := ignoreRules.FindAndLoadGitignores(root); err != nil {
+					return nil, fmt.Errorf("failed to load gitignore files: %v", err)
+				}
+			}
+		}
+	}
+
+	return ignoreRules, nil
+}
+
+// walkOptions controls which paths walkDirBreadthFirst hands to workers.
+type walkOptions struct {
+	skip         map[string]bool // dictionary and word list files, see ruleFiles
+	ignoreRules  *IgnoreRules
+	useGitignore bool
+	followLinks  bool
+	maxDepth     int      // directory levels read, 1 = named directories only, 0 = unlimited
+	names        []string // when set, walked files' base names must match one of these globs
+	include      []string // when set, walked files must match one of these globs
+	exclude      []string // files matching one of these globs are skipped, named ones too
+	progress     *Progress
+
+	// dirConfig, when set, reads a directory's config file and rules file
+	// on top of its parent's settings; see loadDirConfig
+	dirConfig func(dir string, entries []os.DirEntry, parent *dirConfig) *dirConfig
+}
+
+// walkDepth turns Config.MaxDepth into walkOptions.maxDepth.
+func walkDepth(maxDepth *int) int {
+	if maxDepth == nil || *maxDepth < 0 {
+		return 0
+	}
+	return *maxDepth + 1
+}
+
+// scanWalkOptions returns the walk settings of a scan with cfg.
+func scanWalkOptions(cfg Config, rules []CompiledRule, ignoreRules *IgnoreRules) walkOptions {
+	return walkOptions{
+		skip:         ruleFiles(cfg, rules),
+		ignoreRules:  ignoreRules,
+		useGitignore: cfg.UseGitignore,
+		followLinks:  followSymlinks(cfg),
+		maxDepth:     walkDepth(cfg.MaxDepth),
+		names:        cfg.NamePatterns,
+		include:      cfg.IncludePatterns,
+		exclude:      cfg.ExcludePatterns,
+		progress:     cfg.Progress,
+		dirConfig:    dirConfigLoader(cfg),
+	}
+}
+
+// dirConfigLoader returns the walk's dirConfig hook, or nil when
+// per-directory config files are off and rules files are not looked for.
+func dirConfigLoader(cfg Config) func(string, []os.DirEntry, *dirConfig) *dirConfig {
+	if cfg.NoDirConfigs && !cfg.AutoDiscoverRules {
+		return nil
+	}
+	dicts := newDictCache(cfg)
+	return func(dir string, entries []os.DirEntry, parent *dirConfig) *dirConfig {
+		return loadDirConfig(dir, entries, parent, cfg, dicts)
+	}
+}
+
+// scanJob is one file for a worker and the directory settings it falls
+// under (nil for the scan's own), or a directory the walk could not read
+// and why.
+type scanJob struct {
+	path string
+	dir  *dirConfig
+	err  error
+}
+
+// queuedDir is a directory waiting to be read, its depth (the named
+// roots are depth 1), the settings inherited from its parents and the
+// index of the root it is under.
+type queuedDir struct {
+	path  string
+	depth int
+	conf  *dirConfig
+	root  int
 }
 
 // walkDirBreadthFirst walks directories breadth-first and sends files to job channels
-func walkDirBreadthFirst(roots []string, dictPath string, jobChannels []chan []string, ignoreRules *IgnoreRules, useGitignore bool) error {
+func walkDirBreadthFirst(ctx context.Context, roots []string, jobChannels []chan []scanJob, opts walkOptions) error {
+
 	// Constants
 	const batchSize = 32 // Size of each batch of paths
 
@@ -163,86 +692,168 @@ func walkDirBreadthFirst(roots []string, dictPath string, jobChannels []chan []s
 	numWorkers := len(jobChannels)
 
 	// Create a queue for breadth-first traversal
-	dirQueue := []string{}
+	dirQueue := []queuedDir{}
 
 	// Keep track of the current batch for each worker
-	currentBatches := make([][]string, numWorkers)
+	currentBatches := make([][]scanJob, numWorkers)
 
 	// Helper function to send a batch if it's full
 	sendBatchIfFull := func(workerID int) {
 		if len(currentBatches[workerID]) >= batchSize {
 			jobChannels[workerID] <- currentBatches[workerID]
-			currentBatches[workerID] = make([]string, 0, batchSize)
+			currentBatches[workerID] = make([]scanJob, 0, batchSize)
 		}
 	}
 
+	// queue adds a file to the next worker's batch using round-robin
+	queue := func(path string, conf *dirConfig, err error) {
+		currentBatches[nextWorker] = append(currentBatches[nextWorker], scanJob{path, conf, err})
+		sendBatchIfFull(nextWorker)
+		nextWorker = (nextWorker + 1) % numWorkers
+		opts.progress.addFound()
+	}
+
+	// With links followed, the same directory or file can turn up under
+	// several paths; each is walked or scanned once
+	dirsSeen, filesSeen := inodeSet{}, inodeSet{}
+
+	// Files queued under each named directory, to warn about those
+	// where no name matched
+	found := make(map[int]int)
+
 	// Add initial roots to the queue
-	for _, root := range roots {
+	for i, root := range roots {
+		// Standard input has nothing to stat; a worker reads it
+		if root == StdinPath {
+			queue(root, nil, nil)
+			continue
+		}
+
 		info, err := os.Stat(root)
 		if err != nil {
 			return err
 		}
 
 		if info.IsDir() {
-			dirQueue = append(dirQueue, root)
+			if opts.followLinks && !dirsSeen.add(root) {
+				continue
+			}
+			dirQueue = append(dirQueue, queuedDir{root, 1, nil, i})
+			found[i] = 0
 		} else {
-			// Skip dictionary file
-			if dictPath != "" && filepath.Clean(root) == filepath.Clean(dictPath) {
+			// Skip dictionary and word list files, and excluded files
+			// even when named explicitly
+			if isRuleFile(root, opts.skip) || matchesAny(root, opts.exclude) {
 				continue
 			}
 
-			// Add file to the next worker's batch
-			currentBatches[nextWorker] = append(currentBatches[nextWorker], root)
-			sendBatchIfFull(nextWorker)
-
-			// Round-robin to the next worker
-			nextWorker = (nextWorker + 1) % numWorkers
+			queue(root, nil, nil)
 		}
 	}
 
 	// Process directories breadth-first
 	for len(dirQueue) > 0 {
+		// Stop between directory reads once the caller cancels
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Get the next directory from the queue
 		dir := dirQueue[0]
 		dirQueue = dirQueue[1:]
 
-		// Read directory entries
-		entries, err := os.ReadDir(dir)
+		// Read directory entries; an unreadable directory, e.g. one
+		// without permission, is reported as its own failed result
+		entries, err := os.ReadDir(dir.path)
 		if err != nil {
-			return err
+			queue(dir.path, dir.conf, err)
+			continue
+		}
+
+		// A .synthsniffignore applies to this directory's entries and,
+		// since the walk is breadth-first, to everything below it
+		if opts.ignoreRules != nil {
+			opts.ignoreRules.loadSynthsniffIgnore(dir.path, entries)
+		}
+
+		// Likewise a config file here overrides settings for this subtree
+		conf := dir.conf
+		if opts.dirConfig != nil {
+			conf = opts.dirConfig(dir.path, entries, conf)
 		}
 
 		// Process each entry
 		for _, entry := range entries {
-			entryPath := filepath.Join(dir, entry.Name())
+			entryPath := filepath.Join(dir.path, entry.Name())
 
-			if entry.IsDir() {
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				if !opts.followLinks {
+					continue
+				}
+				target, err := os.Stat(entryPath)
+				if err != nil {
+					slog.Warn("skipping broken symlink", "path", entryPath, "err", err)
+					continue
+				}
+				if !target.IsDir() && !target.Mode().IsRegular() {
+					continue
+				}
+				isDir = target.IsDir()
+			}
+
+			if isDir {
 				// Skip .git directories
 				if entry.Name() == ".git" {
 					continue
 				}
 
-				// Check gitignore rules for directories
-				if useGitignore && ignoreRules != nil && ignoreRules.ShouldIgnore(entryPath) {
+				// Stop descending past the depth limit
+				if opts.maxDepth > 0 && dir.depth >= opts.maxDepth {
+					continue
+				}
+
+				// Check ignore rules for directories
+				if opts.ignoreRules != nil && opts.ignoreRules.ShouldIgnore(entryPath) {
+					continue
+				}
+
+				// A directory reached twice is a symlink cycle or a second
+				// link to a tree already queued
+				if opts.followLinks && !dirsSeen.add(entryPath) {
+					slog.Warn("skipping directory already visited (symlink cycle?)", "path", entryPath)
 					continue
 				}
 
 				// Add subdirectory to the queue for breadth-first traversal
-				dirQueue = append(dirQueue, entryPath)
+				dirQueue = append(dirQueue, queuedDir{entryPath, dir.depth + 1, conf, dir.root})
 			} else {
-				// Skip dictionary file
-				if dictPath != "" && filepath.Clean(entryPath) == filepath.Clean(dictPath) {
+				// Skip dictionary and word list files, ignore lists and
+				// config files
+				if isRuleFile(entryPath, opts.skip) || entry.Name() == SynthsniffIgnoreName || entry.Name() == RulesFileName || isConfigFileName(entry.Name()) {
+					continue
+				}
+
+				// Check ignore rules for files
+				if opts.ignoreRules != nil && opts.ignoreRules.ShouldIgnore(entryPath) {
 					continue
 				}
 
-				// Check gitignore rules for files
-				if useGitignore && ignoreRules != nil && ignoreRules.ShouldIgnore(entryPath) {
+				// With name or include patterns, only matching files are
+				// scanned
+				if !matchesName(entryPath, opts.names) {
+					continue
+				}
+				if len(opts.include) > 0 && !matchesAny(entryPath, opts.include) {
+					continue
+				}
+				if matchesAny(entryPath, opts.exclude) {
 					continue
 				}
 
 				// Skip rule files by checking extension
 				ext := strings.ToLower(filepath.Ext(entryPath))
-				if ext == ".yaml" || ext == ".yml" || ext == ".json" {
+				if ext == ".yaml" || ext == ".yml" || ext == ".json" || ext == ".toml" {
 					// For potential rule files, check content
 					data, err := os.ReadFile(entryPath)
 					if err == nil && len(data) > 0 {
@@ -254,12 +865,21 @@ func walkDirBreadthFirst(roots []string, dictPath string, jobChannels []chan []s
 					}
 				}
 
-				// Add file to the next worker's batch using round-robin
-				currentBatches[nextWorker] = append(currentBatches[nextWorker], entryPath)
-				sendBatchIfFull(nextWorker)
+				// Hard links and file symlinks share an inode
+				if opts.followLinks && !filesSeen.add(entryPath) {
+					continue
+				}
 
-				// Move to the next worker
-				nextWorker = (nextWorker + 1) % numWorkers
+				queue(entryPath, conf, nil)
+				found[dir.root]++
+			}
+		}
+	}
+
+	if len(opts.names) > 0 {
+		for i := range roots {
+			if n, ok := found[i]; ok && n == 0 {
+				slog.Warn("no files match -name in directory", "dir", roots[i], "name", opts.names)
 			}
 		}
 	}
@@ -273,75 +893,3 @@ func walkDirBreadthFirst(roots []string, dictPath string, jobChannels []chan []s
 
 	return nil
 }
-
-func analyse(path string, rules []Rule, cfg Config) Result {
-	// Use memory mapping to read file content instead of ReadFile
-	// This reduces syscall overhead by avoiding extra copies
-	mmapGate <- struct{}{} // acquire
-	data, isMapped, err := mmapFile(path)
-	<-mmapGate // release ASAP
-	if err != nil {
-		return Result{Path: path}
-	}
-
-	// Only unmap memory-mapped files
-	if isMapped {
-		defer func() {
-			mmapGate <- struct{}{} // acquire
-			if err := unmapFile(data); err != nil {
-				log.Printf("failed to unmap file: %v", err)
-			}
-			<-mmapGate // release ASAP
-		}()
-	}
-
-	// Skip binary files
-	if bytes.IndexByte(data, 0) != -1 {
-		return Result{Path: path}
-	}
-
-	// Check size limit after reading
-	if cfg.MaxSize > 0 && int64(len(data)) > cfg.MaxSize {
-		return Result{Path: path}
-	}
-
-	fileExt := filepath.Ext(path)
-	score := 0
-	detail := make(map[string]RuleHit)
-
-	// Convert to string once to avoid repeated conversions for each rule
-	content := string(data)
-	fileLen := len(data)
-
-	// Check each rule against the file content
-	for _, r := range rules {
-		// Skip rules that don't apply to this file extension
-		if !r.appliesToExt(fileExt) {
-			continue
-		}
-
-		// Count pattern occurrences using strings.Count (more efficient than bytes.Count)
-		count := strings.Count(content, r.Pattern)
-
-		// Skip patterns that don't match or don't pass thresholds
-		if count == 0 || !r.passesThresholds(count, fileLen) {
-			continue
-		}
-
-		// Calculate score and record hit
-		ruleScore := count * r.Weight
-		score += ruleScore
-		detail[r.Name] = RuleHit{
-			Rule:  r,
-			Count: count,
-		}
-	}
-
-	// Return the analysis result
-	return Result{
-		Path:   path,
-		Score:  score,
-		Detail: detail,
-		Smelly: score >= cfg.Threshold,
-	}
-}
@@ -1,8 +1,10 @@
 package sniff
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,6 +40,7 @@ func BenchmarkAnalyse_1KB_1Rule(b *testing.B) {
 	cfg := Config{
 		Threshold: 10,
 	}
+	compiled := CompileRules(rules, cfg.UnicodeNorm)
 
 	// Set up test file
 	tempFile := filepath.Join(b.TempDir(), "test.txt")
@@ -47,7 +50,7 @@ func BenchmarkAnalyse_1KB_1Rule(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		analyse(tempFile, rules, cfg)
+		analyse(tempFile, compiled, cfg)
 	}
 }
 
@@ -85,6 +88,7 @@ func BenchmarkAnalyse_128KB_6Rules(b *testing.B) {
 	cfg := Config{
 		Threshold: 10,
 	}
+	compiled := CompileRules(rules, cfg.UnicodeNorm)
 
 	// Set up test file
 	tempFile := filepath.Join(b.TempDir(), "test_large.txt")
@@ -94,7 +98,7 @@ func BenchmarkAnalyse_128KB_6Rules(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		analyse(tempFile, rules, cfg)
+		analyse(tempFile, compiled, cfg)
 	}
 }
 
@@ -171,7 +175,7 @@ func BenchmarkScan(b *testing.B) {
 			b.ReportAllocs()
 
 			for i := 0; i < b.N; i++ {
-				results, err := Scan([]string{benchDir}, cfg)
+				results, err := Scan(context.Background(), []string{benchDir}, cfg)
 				if err != nil {
 					b.Fatalf("Scan failed: %v", err)
 				}
@@ -200,3 +204,49 @@ func BenchmarkScan(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkMatch_128KB_6Rules compares one strings.Count pass per rule with a
+// single Aho-Corasick pass over the same content
+func BenchmarkMatch_128KB_6Rules(b *testing.B) {
+	data := makeRandomBytes(128 * 1024)
+	patterns := []string{
+		"pattern-one",
+		"pattern-two",
+		"pattern-three",
+		"pattern-four",
+		"pattern-five",
+		"pattern-six",
+		"—",
+		"“",
+	}
+	for i, pat := range patterns {
+		pos := (i * 1024) + 100
+		copy(data[pos:pos+len(pat)], pat)
+	}
+	content := string(data)
+
+	b.Run("strings.Count", func(b *testing.B) {
+		b.SetBytes(int64(len(content)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, p := range patterns {
+				_ = strings.Count(content, p)
+			}
+		}
+	})
+
+	b.Run("aho-corasick", func(b *testing.B) {
+		m := newACMatcher(patterns)
+		counts := make([]int, len(patterns))
+		lastEnd := make([]int, len(patterns))
+		b.SetBytes(int64(len(content)))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := range counts {
+				counts[j] = 0
+			}
+			m.count(content, counts, lastEnd)
+		}
+	})
+}
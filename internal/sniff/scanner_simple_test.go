@@ -2,6 +2,7 @@
 package sniff
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -34,14 +35,14 @@ func TestScanSimple(t *testing.T) {
 
 	// Create a test configuration with a reasonable MaxSize
 	cfg := Config{
-		DictPath:  dictFile,
+		DictPaths: []string{dictFile},
 		Threshold: 30,
 		Workers:   1,
 		MaxSize:   1 << 20, // 1MB should be more than enough
 	}
 
 	// Run the scan
-	results, err := Scan([]string{dir}, cfg)
+	results, err := Scan(context.Background(), []string{dir}, cfg)
 	require.NoError(t, err)
 
 	// We should have 2 files (dictionary file is excluded by design)
@@ -59,7 +60,7 @@ func TestScanSimple(t *testing.T) {
 	// Verify the smelly file was correctly identified
 	require.NotNil(t, smellyResult, "Could not find smelly.txt in results")
 	assert.True(t, smellyResult.Smelly, "Expected smelly.txt to be detected as smelly")
-	assert.Equal(t, 50, smellyResult.Score, "Expected score to be 50")
+	assert.Equal(t, 50.0, smellyResult.Score, "Expected score to be 50")
 	assert.NotNil(t, smellyResult.Detail, "Expected detail to be populated")
 	assert.Contains(t, smellyResult.Detail, "exact-pattern-test", "Expected 'exact-pattern-test' pattern to be detected")
 }
@@ -98,17 +99,17 @@ func TestAnalyseSimple(t *testing.T) {
 	t.Logf("Pattern found with strings.Contains: %v", isFound)
 
 	// Run the analyse function with MaxSize set
-	result := analyse(testFile, rules, Config{
+	result := analyse(testFile, CompileRules(rules, ""), Config{
 		Threshold: 30,
 		MaxSize:   1 << 20, // 1MB should be enough
 	})
 
 	// Log the result details
-	t.Logf("Result: smelly=%v, score=%d, details=%v",
+	t.Logf("Result: smelly=%v, score=%v, details=%v",
 		result.Smelly, result.Score, result.Detail)
 
 	// Verify the analysis results
 	assert.True(t, result.Smelly, "File should be detected as smelly")
-	assert.Equal(t, 50, result.Score, "Score should be 50")
+	assert.Equal(t, 50.0, result.Score, "Score should be 50")
 	assert.Contains(t, result.Detail, "exact-pattern-test", "Should contain our test pattern")
 }
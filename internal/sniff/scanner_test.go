@@ -2,6 +2,9 @@
 package sniff
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -46,7 +49,7 @@ func TestAnalyse(t *testing.T) {
 		path       string
 		cfg        Config
 		wantSmelly bool
-		wantScore  int
+		wantScore  float64
 		wantDetail int // Number of detail entries expected
 	}{
 		{
@@ -109,7 +112,7 @@ func TestAnalyse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := analyse(tt.path, rules, tt.cfg)
+			result := analyse(tt.path, CompileRules(rules, ""), tt.cfg)
 
 			assert.Equal(t, tt.path, result.Path)
 			assert.Equal(t, tt.wantSmelly, result.Smelly)
@@ -279,12 +282,12 @@ func TestScan(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Choose the appropriate dictionary for the test
 			if tt.name == "high threshold" {
-				tt.cfg.DictPath = highDict
+				tt.cfg.DictPaths = []string{highDict}
 			} else {
-				tt.cfg.DictPath = regDict
+				tt.cfg.DictPaths = []string{regDict}
 			}
 
-			results, err := Scan(tt.roots, tt.cfg)
+			results, err := Scan(context.Background(), tt.roots, tt.cfg)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -320,14 +323,128 @@ func TestScanInvalidDict(t *testing.T) {
 	require.NoError(t, os.WriteFile(invalidDict, []byte("not json or yaml"), 0644))
 
 	// Test with invalid dictionary
-	_, err := Scan([]string{tempDir}, Config{DictPath: invalidDict})
+	_, err := Scan(context.Background(), []string{tempDir}, Config{DictPaths: []string{invalidDict}})
 	assert.Error(t, err, "Scan should return error with invalid dictionary")
 
 	// Test with non-existent dictionary
-	_, err = Scan([]string{tempDir}, Config{DictPath: "nonexistent.dict"})
+	_, err = Scan(context.Background(), []string{tempDir}, Config{DictPaths: []string{"nonexistent.dict"}})
 	assert.Error(t, err, "Scan should return error with non-existent dictionary")
 }
 
+// TestScanCancelled verifies a cancelled context aborts the scan.
+func TestScanCancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("text"), 0644))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := Scan(ctx, []string{tempDir}, Config{Threshold: 30, Workers: 2})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results)
+}
+
+// TestScanStream verifies results are streamed and both channels close.
+func TestScanStream(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("text"), 0644))
+	}
+
+	results, errs := ScanStream(context.Background(), []string{tempDir}, Config{Threshold: 30, Workers: 2})
+
+	var paths []string
+	for r := range results {
+		paths = append(paths, filepath.Base(r.Path))
+	}
+	sort.Strings(paths)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.txt"}, paths)
+
+	err, open := <-errs
+	assert.NoError(t, err)
+	assert.False(t, open, "error channel should be closed")
+}
+
+// TestScanStreamCancelled verifies a consumer can stop after one result,
+// cancel and drain the rest, and that both channels close.
+func TestScanStreamCancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := range 50 {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("%02d.txt", i)), []byte("text"), 0644))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := ScanStream(ctx, []string{tempDir}, Config{Threshold: 30, Workers: 2})
+	first, ok := <-results
+	require.True(t, ok)
+	cancel()
+
+	rest, err := DrainResults(results, errs)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, len(rest), 50, "only part of the scan was read")
+	for _, r := range rest {
+		assert.NotEqual(t, first.Path, r.Path)
+	}
+	_, open := <-results
+	assert.False(t, open, "result channel should be closed")
+	_, open = <-errs
+	assert.False(t, open, "error channel should be closed")
+}
+
+// TestScanStreamSetupError verifies setup errors close the result channel.
+func TestScanStreamSetupError(t *testing.T) {
+	results, errs := ScanStream(context.Background(), []string{t.TempDir()}, Config{DictPaths: []string{"nonexistent.dict"}})
+
+	_, open := <-results
+	assert.False(t, open, "result channel should be closed")
+	assert.Error(t, <-errs)
+}
+
+// TestScanner verifies a Scanner loads its rules once, reuses them across
+// scans and refuses to scan after Close.
+func TestScanner(t *testing.T) {
+	tempDir := t.TempDir()
+	dict := filepath.Join(tempDir, "rules.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte("- name: delve\n  pattern: delve\n  weight: 5\n"), 0644))
+	docs := filepath.Join(tempDir, "docs")
+	require.NoError(t, os.Mkdir(docs, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "a.md"), []byte("We delve into it."), 0644))
+
+	s, err := NewScanner(WithDictPaths(dict), WithThreshold(5), WithWorkers(2))
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), s.Config().Threshold)
+	var names []string
+	for _, r := range s.Rules() {
+		names = append(names, r.Name)
+	}
+	assert.Contains(t, names, "delve")
+
+	results, err := s.Scan(context.Background(), []string{docs})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Smelly)
+
+	// The dictionary is not read again
+	require.NoError(t, os.Remove(dict))
+	results, err = s.Scan(context.Background(), []string{docs})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Smelly)
+
+	require.NoError(t, s.Close())
+	_, err = s.Scan(context.Background(), []string{docs})
+	assert.ErrorIs(t, err, ErrScannerClosed)
+	stream, errs := s.ScanStream(context.Background(), []string{docs})
+	_, open := <-stream
+	assert.False(t, open)
+	assert.ErrorIs(t, <-errs, ErrScannerClosed)
+
+	_, err = NewScanner(WithDictPaths(dict))
+	assert.Error(t, err, "a missing dictionary fails at construction")
+}
+
 // TestAnalyseWithCustomRules verifies analysis with custom rule dictionaries.
 func TestAnalyseWithCustomRules(t *testing.T) {
 	// Create a temporary directory
@@ -341,8 +458,248 @@ func TestAnalyseWithCustomRules(t *testing.T) {
 	rules := setupTestPatterns(t)
 
 	// Test with custom rules
-	result := analyse(testFile, rules, Config{Threshold: 30})
+	result := analyse(testFile, CompileRules(rules, ""), Config{Threshold: 30})
 	assert.True(t, result.Smelly, "File should be detected as smelly with custom rule")
-	assert.GreaterOrEqual(t, result.Score, 50, "Score should include custom rule weight")
+	assert.GreaterOrEqual(t, result.Score, 50.0, "Score should include custom rule weight")
 	assert.Contains(t, result.Detail, "custom-test-pattern", "Detail should include custom rule")
 }
+
+// TestScanMaxDepth verifies the walk stops descending at Config.MaxDepth.
+func TestScanMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	files := []string{"top.txt", "a/one.txt", "a/b/two.txt", "a/b/c/three.txt"}
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+	explicit := filepath.Join(root, "a", "b", "c", "three.txt")
+
+	depth := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		maxDepth *int
+		roots    []string
+		want     []string
+	}{
+		{"unlimited", nil, []string{root}, files},
+		{"named directory only", depth(0), []string{root}, files[:1]},
+		{"immediate children", depth(1), []string{root}, files[:2]},
+		{"explicit files ignore depth", depth(0), []string{root, explicit}, []string{"top.txt", "a/b/c/three.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Scan(context.Background(), tt.roots, Config{
+				MaxDepth:   tt.maxDepth,
+				ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}},
+			})
+			require.NoError(t, err)
+			var got []string
+			for _, r := range results {
+				rel, err := filepath.Rel(root, r.Path)
+				require.NoError(t, err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// TestScanInclude verifies include globs whitelist walked files.
+func TestScanInclude(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.md", "b.txt", "docs/c.md", "docs/d.txt", "vendor/e.md"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("vendor/\n"), 0644))
+	explicit := filepath.Join(root, "b.txt")
+
+	scan := func(roots []string, include ...string) []string {
+		results, err := Scan(context.Background(), roots, Config{
+			IncludePatterns: include,
+			UseGitignore:    true,
+			ExtraRules:      []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}},
+		})
+		require.NoError(t, err)
+		var got []string
+		for _, r := range results {
+			rel, err := filepath.Rel(root, r.Path)
+			require.NoError(t, err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	assert.Equal(t, []string{"a.md", "docs/c.md"}, scan([]string{root}, "*.md"), ".txt files are skipped and ignores still apply")
+	assert.Equal(t, []string{"docs/d.txt"}, scan([]string{root}, filepath.Join(root, "docs", "*.txt")), "full paths match too")
+	assert.Equal(t, []string{"a.md", "b.txt", "docs/c.md"}, scan([]string{root, explicit}, "*.md"), "file arguments are always scanned")
+
+	_, err := Scan(context.Background(), []string{root}, Config{IncludePatterns: []string{"["}})
+	assert.Error(t, err)
+}
+
+// TestScanName verifies -name globs match walked files by base name.
+func TestScanName(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.md", "b.txt", "c.go", "docs/d.md", "docs/e.txt", "src/f.go"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+
+	scan := func(roots []string, names ...string) []string {
+		results, err := Scan(context.Background(), roots, Config{NamePatterns: names})
+		require.NoError(t, err)
+		var got []string
+		for _, r := range results {
+			rel, err := filepath.Rel(root, r.Path)
+			require.NoError(t, err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return got
+	}
+
+	assert.Equal(t, []string{"a.md", "docs/d.md"}, scan([]string{root}, "*.md"))
+	assert.Equal(t, []string{"a.md", "b.txt", "docs/d.md", "docs/e.txt"}, scan([]string{root}, "*.md", "*.txt"), "any name may match")
+	assert.Empty(t, scan([]string{root}, "docs/*.md"), "only the base name is matched")
+	assert.Equal(t, []string{"c.go", "docs/d.md"}, scan([]string{root, filepath.Join(root, "c.go")}, "d.*"), "file arguments are always scanned")
+
+	logs := captureLog(t, slog.LevelWarn)
+	assert.Equal(t, []string{"docs/e.txt"}, scan([]string{filepath.Join(root, "src"), filepath.Join(root, "docs")}, "*.txt"))
+	assert.Contains(t, logs.String(), "no files match -name in directory")
+	assert.Contains(t, logs.String(), "src")
+	assert.NotContains(t, logs.String(), "docs")
+
+	_, err := Scan(context.Background(), []string{root}, Config{NamePatterns: []string{"["}})
+	assert.Error(t, err)
+}
+
+// TestScanFileErrors verifies a file that cannot be read gets a result
+// with Err while the other files are still scored, and that MaxErrors
+// ends the scan with the results so far.
+func TestScanFileErrors(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.md"), []byte("MARK"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.zip"), []byte("not a zip"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "c.zip"), []byte("not a zip"), 0644))
+	captureLog(t, slog.LevelError)
+	cfg := Config{Threshold: 1, ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}}
+
+	results, err := Scan(context.Background(), []string{root}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Smelly)
+	assert.Empty(t, results[0].Err)
+	assert.Contains(t, results[1].Err, "not a valid zip file")
+	assert.NotEmpty(t, results[2].Err)
+
+	cfg.MaxErrors = 2
+	_, err = Scan(context.Background(), []string{root}, cfg)
+	assert.NoError(t, err, "two errors are within the limit")
+
+	cfg.MaxErrors, cfg.Workers = 1, 1
+	results, err = Scan(context.Background(), []string{root}, cfg)
+	require.ErrorIs(t, err, ErrTooManyErrors)
+	assert.NotEmpty(t, results, "the results so far come back with the error")
+}
+
+// TestScanUnreadableDir verifies a directory the walk cannot read is
+// reported as a failed result rather than ending the scan.
+func TestScanUnreadableDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs Unix permissions that apply to the current user")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	require.NoError(t, os.Mkdir(locked, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.md"), []byte("MARK"), 0644))
+	require.NoError(t, os.Chmod(locked, 0))
+	t.Cleanup(func() { _ = os.Chmod(locked, 0755) })
+
+	results, err := Scan(context.Background(), []string{root}, Config{Threshold: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, locked, results[1].Path)
+	assert.NotEmpty(t, results[1].Err)
+}
+
+// TestScanExclude verifies exclude globs skip walked and named files.
+func TestScanExclude(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"main.go", "api.generated.go", "pkg/x.generated.go", "pkg/y.go"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("MARK"), 0644))
+	}
+	generated := filepath.Join(root, "api.generated.go")
+
+	scan := func(roots []string, exclude ...string) []string {
+		results, err := Scan(context.Background(), roots, Config{
+			ExcludePatterns: exclude,
+			ExtraRules:      []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}},
+		})
+		require.NoError(t, err)
+		var got []string
+		for _, r := range results {
+			rel, err := filepath.Rel(root, r.Path)
+			require.NoError(t, err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	assert.Equal(t, []string{"main.go", "pkg/y.go"}, scan([]string{root}, "*.generated.go"), "no .gitignore needed")
+	assert.Empty(t, scan([]string{generated}, "*.generated.go"), "file arguments are excluded too")
+	assert.Equal(t, []string{"api.generated.go"}, scan([]string{generated}, "*.txt"))
+	assert.Equal(t, []string{"api.generated.go", "main.go"}, scan([]string{root}, filepath.Join(root, "pkg", "*")), "full paths match too")
+
+	_, err := Scan(context.Background(), []string{root}, Config{ExcludePatterns: []string{"["}})
+	assert.Error(t, err)
+}
+
+// TestScanTiming verifies per-file durations are only recorded with
+// TimingMode.
+func TestScanTiming(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, f), []byte("some — text"), 0644))
+	}
+
+	results, err := Scan(context.Background(), []string{root}, Config{Threshold: 30})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.Zero(t, r.Duration, r.Path)
+	}
+
+	results, err = Scan(context.Background(), []string{root}, Config{Threshold: 30, TimingMode: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.Positive(t, r.Duration, r.Path)
+	}
+}
+
+// TestScanDebugLog verifies the debug log covers rule loading, file reads
+// and per-worker counts.
+func TestScanDebugLog(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("text"), 0644))
+	logBuf := captureLog(t, slog.LevelDebug)
+
+	_, err := Scan(context.Background(), []string{dir}, Config{Threshold: 1, Workers: 1})
+	require.NoError(t, err)
+	out := logBuf.String()
+	assert.Contains(t, out, "msg=\"rules loaded\"")
+	assert.Contains(t, out, "msg=\"read file\"")
+	assert.Contains(t, out, "mmap=false")
+	assert.Contains(t, out, "msg=\"worker finished\" worker=0 files=1")
+}
@@ -0,0 +1,227 @@
+package sniff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ScanRequest is the body of POST /scan. Cfg overrides the scoring
+// settings listed in requestOverrides for this request only; any other
+// field is rejected, since the rule set, limits and workers belong to
+// the server.
+type ScanRequest struct {
+	Content string          `json:"content"` // base64
+	Name    string          `json:"name"`    // file name; its extension selects rules
+	Cfg     json.RawMessage `json:"cfg,omitempty"`
+}
+
+// requestOverrides are the Config fields a scan request may set, under
+// their Config JSON names. Nil fields keep the server's value.
+type requestOverrides struct {
+	Threshold         *float64 `json:"threshold"`
+	WarnThreshold     *float64 `json:"warnThreshold"`
+	ConfidenceScale   *float64 `json:"confidenceScale"`
+	Normalize         *bool    `json:"normalize"`
+	UnicodeNorm       *string  `json:"unicodeNorm"`
+	MinSize           *int64   `json:"minSize"`
+	MaxSize           *int64   `json:"maxSize"` // at most the server's MaxSize
+	DetectMIME        *bool    `json:"detectMime"`
+	DetectLanguage    *bool    `json:"detectLanguage"`
+	ExcludeCodeBlocks *bool    `json:"excludeCodeBlocks"`
+	StripFrontMatter  *bool    `json:"stripFrontMatter"`
+	CollectLines      *bool    `json:"collectLines"`
+	Snippets          *bool    `json:"snippets"`
+	SnippetWidth      *int     `json:"snippetWidth"`
+	Grades            *bool    `json:"grades"`
+}
+
+// Server exposes scanning over HTTP:
+//
+//	POST /scan    score one file sent as a ScanRequest, returns a Result
+//	POST /reload  reload the rule files, see Reload
+//	GET  /rules   the loaded rule set
+//	GET  /health  {"status":"ok"}
+//	GET  /metrics Prometheus metrics, cumulative since start
+//
+// At most cfg.Workers scans (default: CPUs, up to 4) run at once; other
+// requests wait for a slot. With cfg.MaxSize set, a request body longer
+// than that content in base64 plus maxBodySlack is refused unread.
+type Server struct {
+	cfg   Config
+	rules atomic.Pointer[[]CompiledRule]
+	slots chan struct{}
+	mux   *http.ServeMux
+}
+
+// NewServer loads the rules for cfg and returns the handler. It records
+// into cfg.Metrics, or into new Metrics when that is nil.
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.Metrics == nil {
+		cfg.Metrics = NewMetrics()
+	}
+	rules, err := loadScanRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = getMaxProcs()
+	}
+	s := &Server{cfg: cfg, slots: make(chan struct{}, workers), mux: http.NewServeMux()}
+	s.rules.Store(&rules)
+	s.mux.HandleFunc("POST /scan", s.handleScan)
+	s.mux.HandleFunc("POST /reload", s.handleReload)
+	s.mux.HandleFunc("GET /rules", s.handleRules)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.Handle("GET /metrics", cfg.Metrics.Handler())
+	return s, nil
+}
+
+// Reload loads the rule files again and swaps the new rules in. Scans
+// already running finish with the old rules. On error, e.g. a dict that
+// no longer parses, the old rules stay and the error is logged.
+func (s *Server) Reload() error {
+	rules, err := loadScanRules(s.cfg)
+	if err != nil {
+		slog.Error("rule reload failed, keeping the old rules", "err", err)
+		return err
+	}
+	s.rules.Store(&rules)
+	slog.Info("rules reloaded", "rules", len(rules))
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// maxBodySlack is the room a scan request has for its name, cfg and JSON
+// syntax around the base64 content.
+const maxBodySlack = 64 << 10
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	// Cap the body before decoding, so a huge request is never buffered
+	if s.cfg.MaxSize > 0 {
+		limit := int64(base64.StdEncoding.EncodedLen(int(s.cfg.MaxSize))) + maxBodySlack
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	var req ScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.fail(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request larger than %d bytes", tooLarge.Limit))
+			return
+		}
+		s.fail(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		s.fail(w, http.StatusBadRequest, fmt.Errorf("content: %w", err))
+		return
+	}
+	cfg, err := s.requestConfig(req.Cfg)
+	if err != nil {
+		s.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if cfg.MaxSize > 0 && int64(len(content)) > cfg.MaxSize {
+		s.fail(w, http.StatusRequestEntityTooLarge, fmt.Errorf("content larger than %d bytes", cfg.MaxSize))
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-r.Context().Done():
+		return
+	}
+	start := time.Now()
+	res := AnalyseCompiled(content, req.Name, *s.rules.Load(), cfg)
+	<-s.slots
+	s.cfg.Metrics.observeDuration(time.Since(start))
+	s.cfg.Metrics.observe(res)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// fail answers with an error and counts it.
+func (s *Server) fail(w http.ResponseWriter, status int, err error) {
+	s.cfg.Metrics.addError()
+	writeError(w, status, err)
+}
+
+// requestConfig applies a request's overrides to the server defaults.
+func (s *Server) requestConfig(raw json.RawMessage) (Config, error) {
+	cfg := s.cfg
+	if len(raw) == 0 {
+		return cfg, nil
+	}
+	var o requestOverrides
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&o); err != nil {
+		return cfg, fmt.Errorf("cfg: %w", err)
+	}
+	if o.MaxSize != nil && s.cfg.MaxSize > 0 && (*o.MaxSize <= 0 || *o.MaxSize > s.cfg.MaxSize) {
+		return cfg, fmt.Errorf("cfg: maxSize must be from 1 to the server's %d", s.cfg.MaxSize)
+	}
+	overrideValue(&cfg.Threshold, o.Threshold)
+	overrideValue(&cfg.WarnThreshold, o.WarnThreshold)
+	overrideValue(&cfg.ConfidenceScale, o.ConfidenceScale)
+	overrideValue(&cfg.Normalize, o.Normalize)
+	overrideValue(&cfg.UnicodeNorm, o.UnicodeNorm)
+	overrideValue(&cfg.MinSize, o.MinSize)
+	overrideValue(&cfg.MaxSize, o.MaxSize)
+	overrideValue(&cfg.DetectMIME, o.DetectMIME)
+	overrideValue(&cfg.DetectLanguage, o.DetectLanguage)
+	overrideValue(&cfg.ExcludeCodeBlocks, o.ExcludeCodeBlocks)
+	overrideValue(&cfg.StripFrontMatter, o.StripFrontMatter)
+	overrideValue(&cfg.CollectLines, o.CollectLines)
+	overrideValue(&cfg.Snippets, o.Snippets)
+	overrideValue(&cfg.SnippetWidth, o.SnippetWidth)
+	overrideValue(&cfg.Grades, o.Grades)
+	form, err := ParseNormForm(cfg.UnicodeNorm)
+	if err != nil {
+		return cfg, fmt.Errorf("cfg: %w", err)
+	}
+	cfg.UnicodeNorm = form
+	if cfg.Threshold <= 0 {
+		return cfg, fmt.Errorf("cfg: threshold must be positive")
+	}
+	return cfg, nil
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, _ *http.Request) {
+	if err := s.Reload(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "rules": len(*s.rules.Load())})
+}
+
+func (s *Server) handleRules(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, *s.rules.Load())
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("server write failed", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
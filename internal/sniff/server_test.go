@@ -0,0 +1,192 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves the base rules plus one marker rule.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s, err := NewServer(Config{
+		Threshold:  20,
+		Workers:    2,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postScan(t *testing.T, url string, req any) *http.Response {
+	t.Helper()
+	b, err := json.Marshal(req)
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/scan", "application/json", bytes.NewReader(b))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerScan(t *testing.T) {
+	srv := newTestServer(t)
+	content := base64.StdEncoding.EncodeToString([]byte("MARK MARK MARK"))
+
+	resp := postScan(t, srv.URL, ScanRequest{Content: content, Name: "a.md"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var res Result
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, "a.md", res.Path)
+	assert.Equal(t, 30.0, res.Score)
+	assert.True(t, res.Smelly)
+	assert.Equal(t, 3, res.Detail["mark"].Count)
+
+	// Per-request overrides apply to that request only
+	resp = postScan(t, srv.URL, ScanRequest{Content: content, Name: "a.md", Cfg: json.RawMessage(`{"threshold": 50}`)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.False(t, res.Smelly)
+}
+
+func TestServerScanErrors(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"not JSON", "{", http.StatusBadRequest},
+		{"bad base64", `{"content": "***", "name": "a.md"}`, http.StatusBadRequest},
+		{"bad cfg", `{"content": "", "name": "a.md", "cfg": {"unicodeNorm": "NFX"}}`, http.StatusBadRequest},
+		{"server setting", `{"content": "", "name": "a.md", "cfg": {"workers": 64}}`, http.StatusBadRequest},
+		{"rule setting", `{"content": "", "name": "a.md", "cfg": {"dict": ["/etc/passwd"]}}`, http.StatusBadRequest},
+		{"too large", `{"content": "` + base64.StdEncoding.EncodeToString([]byte("MARK MARK")) + `", "cfg": {"maxSize": 4}}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/scan", "application/json", bytes.NewReader([]byte(tt.body)))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+
+	resp, err := http.Get(srv.URL + "/scan")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServerScanBodyLimit(t *testing.T) {
+	s, err := NewServer(Config{Threshold: 20, MaxSize: 16})
+	require.NoError(t, err)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	resp := postScan(t, srv.URL, ScanRequest{Content: base64.StdEncoding.EncodeToString([]byte("small")), Name: "a.md"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Refused while decoding, before the content is base64-decoded
+	huge := strings.Repeat("A", 2*maxBodySlack)
+	resp = postScan(t, srv.URL, ScanRequest{Content: huge, Name: "a.md"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body["error"], "request larger than")
+
+	// A request may lower the limit but not raise it
+	resp = postScan(t, srv.URL, ScanRequest{Content: base64.StdEncoding.EncodeToString([]byte("small")), Name: "a.md", Cfg: json.RawMessage(`{"maxSize": 1024}`)})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postScan(t, srv.URL, ScanRequest{Content: base64.StdEncoding.EncodeToString([]byte("small")), Name: "a.md", Cfg: json.RawMessage(`{"maxSize": 4}`)})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestServerRulesAndHealth(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/rules")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rules []Rule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rules))
+	assert.Len(t, rules, len(baseRules)+1)
+	assert.Equal(t, "mark", rules[len(rules)-1].Name)
+
+	resp, err = http.Get(srv.URL + "/health")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var health map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	assert.Equal(t, map[string]string{"status": "ok"}, health)
+}
+
+// TestServerReload verifies a reload swaps in the edited dict, and that
+// a dict that no longer parses leaves the old rules in place.
+func TestServerReload(t *testing.T) {
+	dict := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte("- name: mark\n  pattern: MARK\n  weight: 10\n"), 0644))
+	s, err := NewServer(Config{Threshold: 20, DictPaths: []string{dict}})
+	require.NoError(t, err)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	content := base64.StdEncoding.EncodeToString([]byte("MARK NEW"))
+	score := func() float64 {
+		t.Helper()
+		resp := postScan(t, srv.URL, ScanRequest{Content: content, Name: "a.md"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var res Result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		return res.Score
+	}
+	reload := func() *http.Response {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/reload", "application/json", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	require.Equal(t, 10.0, score())
+
+	// Edited on disk: nothing changes until the reload
+	require.NoError(t, os.WriteFile(dict, []byte("- name: new\n  pattern: NEW\n  weight: 7\n"), 0644))
+	assert.Equal(t, 10.0, score())
+	captureLog(t, slog.LevelInfo)
+	require.NoError(t, s.Reload())
+	assert.Equal(t, 7.0, score())
+
+	require.NoError(t, os.WriteFile(dict, []byte("- name: mark\n  pattern: MARK\n  weight: 3\n"), 0644))
+	resp := reload()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, 3.0, score())
+
+	// A broken dict is reported and the old rules stay
+	require.NoError(t, os.WriteFile(dict, []byte("- name: [unterminated\n"), 0644))
+	logs := captureLog(t, slog.LevelInfo)
+	resp = reload()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Contains(t, logs.String(), "rule reload failed")
+	assert.Equal(t, 3.0, score())
+}
@@ -0,0 +1,78 @@
+package sniff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule severities, lowest first. A rule without one is SeverityWarn.
+const (
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+// severityRank orders severities; unknown ones rank 0.
+var severityRank = map[string]int{SeverityInfo: 1, SeverityWarn: 2, SeverityError: 3}
+
+// ParseSeverity canonicalizes a severity name, case-insensitively. ""
+// stays "", meaning the default.
+func ParseSeverity(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	sev := strings.ToLower(s)
+	if severityRank[sev] == 0 {
+		return "", fmt.Errorf("unknown severity %q (want info, warn or error)", s)
+	}
+	return sev, nil
+}
+
+// severity returns the rule's severity, SeverityWarn when unset.
+func (r Rule) severity() string {
+	if r.Severity == "" {
+		return SeverityWarn
+	}
+	return r.Severity
+}
+
+// checkSeverities validates and canonicalizes each rule's Severity.
+func checkSeverities(rules []Rule) error {
+	for i := range rules {
+		sev, err := ParseSeverity(rules[i].Severity)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rules[i].Name, err)
+		}
+		rules[i].Severity = sev
+	}
+	return nil
+}
+
+// filterSeverity returns the rules at or above min; "" keeps them all.
+func filterSeverity(rules []Rule, min string) []Rule {
+	if min == "" {
+		return rules
+	}
+	out := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if severityRank[r.severity()] >= severityRank[min] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// maxSeverity returns the highest severity among r's scoring hits, or ""
+// when none scored.
+func maxSeverity(r Result) string {
+	top := ""
+	for _, h := range r.Detail {
+		if h.Excluded {
+			continue
+		}
+		if sev := h.Rule.severity(); severityRank[sev] > severityRank[top] {
+			top = sev
+		}
+	}
+	return top
+}
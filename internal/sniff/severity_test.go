@@ -0,0 +1,80 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseSeverity verifies names are canonicalized and unknown ones fail.
+func TestParseSeverity(t *testing.T) {
+	for in, want := range map[string]string{"": "", "info": SeverityInfo, "WARN": SeverityWarn, "Error": SeverityError} {
+		got, err := ParseSeverity(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseSeverity("fatal")
+	assert.ErrorContains(t, err, `unknown severity "fatal"`)
+}
+
+// TestBaseRuleSeverities verifies every base rule has a valid severity.
+func TestBaseRuleSeverities(t *testing.T) {
+	for _, r := range baseRules {
+		sev, err := ParseSeverity(r.Severity)
+		require.NoError(t, err, r.Name)
+		assert.NotEmpty(t, sev, r.Name)
+	}
+}
+
+// TestMinSeverity verifies -min-severity=error loads only error rules and
+// a scan then reports nothing from lower-severity ones.
+func TestMinSeverity(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "dict.json")
+	require.NoError(t, os.WriteFile(dict, []byte(`[
+		{"name": "loud", "pattern": "LOUD", "weight": 10, "severity": "error"},
+		{"name": "quiet", "pattern": "QUIET", "weight": 10, "severity": "info"},
+		{"name": "plain", "pattern": "PLAIN", "weight": 10}
+	]`), 0644))
+	doc := filepath.Join(dir, "doc.md")
+	require.NoError(t, os.WriteFile(doc, []byte("LOUD QUIET PLAIN — –\n---\n"), 0644))
+
+	cfg := Config{DictPaths: PathList{dict}, Threshold: 1, MinSeverity: SeverityError}
+	rules, err := EffectiveRules(cfg)
+	require.NoError(t, err)
+	for _, r := range rules {
+		assert.Equal(t, SeverityError, r.severity(), r.Name)
+	}
+	assert.Len(t, rules, 2, "markdown-hrule and loud")
+
+	results, err := Scan(context.Background(), []string{doc}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Len(t, results[0].Detail, 2)
+	assert.Contains(t, results[0].Detail, "loud")
+	assert.Contains(t, results[0].Detail, "markdown-hrule")
+
+	cfg.MinSeverity = SeverityWarn
+	rules, err = EffectiveRules(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, ruleNames(rules), "quiet")
+	assert.Contains(t, ruleNames(rules), "plain", "rules default to warn")
+
+	cfg.MinSeverity = ""
+	rules, err = EffectiveRules(cfg)
+	require.NoError(t, err)
+	assert.Len(t, rules, len(baseRules)+3)
+}
+
+// TestRuleSeverityInvalid verifies a dict with an unknown severity fails.
+func TestRuleSeverityInvalid(t *testing.T) {
+	dict := filepath.Join(t.TempDir(), "dict.json")
+	require.NoError(t, os.WriteFile(dict, []byte(`[{"name": "x", "pattern": "X", "weight": 1, "severity": "loud"}]`), 0644))
+	_, err := EffectiveRules(Config{DictPaths: PathList{dict}})
+	assert.ErrorContains(t, err, "rule x: unknown severity")
+}
@@ -0,0 +1,83 @@
+package sniff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSnippetWidth is the context shown around a match when
+// Config.SnippetWidth is unset.
+const DefaultSnippetWidth = 80
+
+// Markers placed around the matched text in a snippet.
+const (
+	snippetOpen  = ">>>"
+	snippetClose = "<<<"
+)
+
+// snippetWidth returns the configured context width in characters.
+func (c Config) snippetWidth() int {
+	if c.SnippetWidth > 0 {
+		return c.SnippetWidth
+	}
+	return DefaultSnippetWidth
+}
+
+// snippetsAt returns one snippet per match: up to width characters
+// of context split around the match, e.g. "said >>>—<<< then".
+func snippetsAt(content string, spans []span, width int) []string {
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		off, matchLen := sp.off, sp.len
+		before := lastRunes(content[:off], width/2)
+		after := firstRunes(content[off+matchLen:], width-width/2)
+
+		var b strings.Builder
+		b.Grow(len(before) + matchLen + len(after) + len(snippetOpen) + len(snippetClose))
+		writeSafe(&b, before)
+		b.WriteString(snippetOpen)
+		writeSafe(&b, content[off:off+matchLen])
+		b.WriteString(snippetClose)
+		writeSafe(&b, after)
+		out[i] = b.String()
+	}
+	return out
+}
+
+// lastRunes returns the trailing n runes of s.
+func lastRunes(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return s[i:]
+}
+
+// firstRunes returns the leading n runes of s.
+func firstRunes(s string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i]
+}
+
+// writeSafe copies s to b with whitespace flattened to spaces and
+// binary-looking bytes (invalid UTF-8, control characters) shown as "·".
+func writeSafe(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r == utf8.RuneError && size == 1, unicode.IsControl(r):
+			b.WriteString("·")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnippetsAt(t *testing.T) {
+	content := "0123456789MARKabcdefghij"
+	got := snippetsAt(content, []span{{10, 4}}, 8)
+	assert.Equal(t, []string{"6789>>>MARK<<<abcd"}, got)
+
+	// Context stops at the edges of the file
+	got = snippetsAt("MARK!", []span{{0, 4}}, 80)
+	assert.Equal(t, []string{">>>MARK<<<!"}, got)
+}
+
+func TestSnippetsAtRuneSafe(t *testing.T) {
+	// Multi-byte runes are never split; width counts characters
+	content := "ééé—ééé"
+	off := len("ééé")
+	got := snippetsAt(content, []span{{off, len("—")}}, 4)
+	assert.Equal(t, []string{"éé>>>—<<<éé"}, got)
+}
+
+func TestSnippetsAtSanitises(t *testing.T) {
+	content := "a\x01\xff\nb\tMARKc\r\n"
+	got := snippetsAt(content, []span{{6, 4}}, 80)
+	assert.Equal(t, []string{"a·· b >>>MARK<<<c  "}, got)
+}
+
+func TestAnalyseSnippets(t *testing.T) {
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}}
+	content := "first MARK here\nsecond MARK there"
+
+	r := AnalyseString(content, "f.txt", rules, Config{Threshold: 1, Snippets: true, SnippetWidth: 10})
+	assert.Equal(t, []string{"irst >>>MARK<<< here", "cond >>>MARK<<< ther"}, r.Detail["mark"].Snippets)
+
+	r = AnalyseString(content, "f.txt", rules, Config{Threshold: 1})
+	assert.Nil(t, r.Detail["mark"].Snippets)
+}
+
+func TestRenderSnippets(t *testing.T) {
+	hit := RuleHit{Rule: Rule{Name: "mark"}, Count: 1, Snippets: []string{"a >>>MARK<<< b"}}
+	list := []Result{{Path: "f.txt", Score: 1, Smelly: true, Detail: map[string]RuleHit{"mark": hit}}}
+
+	var buf bytes.Buffer
+	Render(&buf, list, Config{VeryVerbose: true})
+	assert.Contains(t, buf.String(), "    a >>>MARK<<< b\n")
+
+	buf.Reset()
+	Render(&buf, list, Config{Format: FormatJSON})
+	var decoded jsonReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, hit.Snippets, decoded.Results[0].Detail["mark"].Snippets)
+}
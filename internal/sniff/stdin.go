@@ -0,0 +1,44 @@
+package sniff
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// StdinPath is the path argument that makes Scan read standard input.
+// It is also the Path of the resulting Result.
+const StdinPath = "-"
+
+// stdinName is how standard input is shown in text and HTML output.
+const stdinName = "<stdin>"
+
+// stdin is the reader behind StdinPath; tests swap it out.
+var stdin io.Reader = os.Stdin
+
+// analyseStdin reads standard input into memory, or in chunks with
+// cfg.ChunkedScan, and scores it. The content counts as plain text
+// unless cfg.StdinExt names an extension for per-rule filters.
+func analyseStdin(rules []CompiledRule, cfg Config) Result {
+	ext := cfg.StdinExt
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	res, err := analyseReader(stdin, stdinName+ext, rules, cfg)
+	if err != nil {
+		slog.Error("stdin read failed", "err", err)
+		return cfg.failedResult(StdinPath, err)
+	}
+	res.Path = StdinPath
+	return res
+}
+
+// displayPath is the path as shown to people: standard input reads as
+// "<stdin>" rather than "-".
+func displayPath(p string) string {
+	if p == StdinPath {
+		return stdinName
+	}
+	return p
+}
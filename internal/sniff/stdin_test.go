@@ -0,0 +1,159 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withStdin makes StdinPath read from s for the rest of the test.
+func withStdin(t *testing.T, s string) {
+	t.Helper()
+	old := stdin
+	stdin = strings.NewReader(s)
+	t.Cleanup(func() { stdin = old })
+}
+
+func TestScanStdin(t *testing.T) {
+	withStdin(t, "MARK MARK MARK")
+	cfg := Config{
+		Threshold:  20,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	results, err := Scan(context.Background(), []string{StdinPath}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, StdinPath, results[0].Path)
+	assert.Equal(t, 30.0, results[0].Score)
+	assert.True(t, results[0].Smelly)
+
+	var text bytes.Buffer
+	Render(&text, results, cfg)
+	assert.Contains(t, text.String(), "<stdin>")
+
+	var out bytes.Buffer
+	cfg.Format = FormatJSON
+	Render(&out, results, cfg)
+	var decoded jsonReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "-", decoded.Results[0].Path)
+}
+
+func TestScanStdinWithFiles(t *testing.T) {
+	withStdin(t, "MARK")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("MARK MARK"), 0644))
+	cfg := Config{
+		Threshold:    100,
+		UseGitignore: true,
+		ExtraRules:   []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	results, err := Scan(context.Background(), []string{StdinPath, file}, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, StdinPath, results[0].Path)
+	assert.Equal(t, 10.0, results[0].Score)
+	assert.Equal(t, 20.0, results[1].Score)
+}
+
+func TestAnalyseStdinExt(t *testing.T) {
+	rules := []Rule{{Name: "md-only", Pattern: "MARK", Weight: 10, Ext: ".md"}}
+
+	withStdin(t, "MARK")
+	assert.Equal(t, 0.0, analyseStdin(CompileRules(rules, ""), Config{}).Score, "plain text by default")
+
+	withStdin(t, "MARK")
+	assert.Equal(t, 10.0, analyseStdin(CompileRules(rules, ""), Config{StdinExt: ".md"}).Score)
+
+	withStdin(t, "MARK")
+	assert.Equal(t, 10.0, analyseStdin(CompileRules(rules, ""), Config{StdinExt: "md"}).Score, "leading dot is optional")
+}
+
+func TestAnalyseStdinLimits(t *testing.T) {
+	rules := []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}
+
+	withStdin(t, "MARK MARK MARK")
+	r := analyseStdin(CompileRules(rules, ""), Config{MaxSize: 8})
+	assert.Equal(t, StdinPath, r.Path)
+	assert.Zero(t, r.Score, "input over MaxSize is skipped")
+
+	withStdin(t, "MARK\x00")
+	assert.Zero(t, analyseStdin(CompileRules(rules, ""), Config{}).Score, "binary input is skipped")
+}
+
+func TestAnalyseStdinReadError(t *testing.T) {
+	old := stdin
+	stdin = io.MultiReader(strings.NewReader("MARK"), errReader{})
+	t.Cleanup(func() { stdin = old })
+
+	r := analyseStdin(CompileRules([]Rule{{Name: "mark", Pattern: "MARK", Weight: 10}}, ""), Config{})
+	assert.Equal(t, Result{Path: StdinPath, Err: "unexpected EOF"}, r)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+// blockingReader stalls until release is closed, then closes done.
+type blockingReader struct{ release, done chan struct{} }
+
+func (b blockingReader) Read([]byte) (int, error) {
+	<-b.release
+	close(b.done)
+	return 0, io.EOF
+}
+
+func TestScanTimeout(t *testing.T) {
+	r := blockingReader{make(chan struct{}), make(chan struct{})}
+	old, oldGrace := stdin, scanGracePeriod
+	stdin, scanGracePeriod = r, 50*time.Millisecond
+	t.Cleanup(func() {
+		// Let the stalled worker finish before stdin is swapped back
+		close(r.release)
+		<-r.done
+		stdin, scanGracePeriod = old, oldGrace
+	})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("MARK"), 0644))
+	cfg := Config{
+		Threshold:  1,
+		Workers:    2,
+		Timeout:    100 * time.Millisecond,
+		ExtraRules: []Rule{{Name: "mark", Pattern: "MARK", Weight: 10}},
+	}
+
+	// Standard input stalls like a hung network mount; the file still counts
+	start := time.Now()
+	results, err := Scan(context.Background(), []string{StdinPath, file}, cfg)
+	assert.Less(t, time.Since(start), 2*time.Second, "the scan gives up after the grace period")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "timed out after 100ms")
+	require.Len(t, results, 1, "partial results are returned")
+	assert.Equal(t, file, results[0].Path)
+}
+
+func TestScanCancelledContext(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("MARK"), 0644))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := Scan(ctx, []string{dir}, Config{Threshold: 1, Timeout: time.Minute})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, results)
+}
@@ -0,0 +1,67 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ScanSummary aggregates a scan's results.
+type ScanSummary struct {
+	Files       int           `json:"files"`
+	Smelly      int           `json:"smelly"`
+	Warnings    int           `json:"warnings"`
+	Clean       int           `json:"clean"` // neither smelly nor warning
+	Allowlisted int           `json:"allowlisted,omitempty"`
+	TotalScore  float64       `json:"totalScore"`
+	MeanScore   float64       `json:"meanScore"`
+	TopPath     string        `json:"topPath,omitempty"` // highest-scoring file, first by path on a tie
+	TopScore    float64       `json:"topScore"`
+	Elapsed     time.Duration `json:"-"`         // wall-clock time of the scan
+	ElapsedMS   float64       `json:"elapsedMs"` // Elapsed in milliseconds
+}
+
+// ComputeSummary counts results by verdict and finds the mean and top
+// score. elapsed is the scan's wall-clock time, 0 when unknown.
+func ComputeSummary(results []Result, elapsed time.Duration) ScanSummary {
+	s := ScanSummary{Files: len(results), Elapsed: elapsed, ElapsedMS: durationMS(elapsed)}
+	for _, r := range results {
+		switch {
+		case r.Smelly:
+			s.Smelly++
+		case r.Warning:
+			s.Warnings++
+		default:
+			s.Clean++
+		}
+		if r.Allowlisted {
+			s.Allowlisted++
+		}
+		s.TotalScore += r.Score
+		if s.TopPath == "" || r.Score > s.TopScore || (r.Score == s.TopScore && r.Path < s.TopPath) {
+			s.TopPath, s.TopScore = r.Path, r.Score
+		}
+	}
+	if s.Files > 0 {
+		s.MeanScore = s.TotalScore / float64(s.Files)
+	}
+	return s
+}
+
+// RenderSummary prints s as one line of text, e.g.
+// "Scanned 12 file(s) in 1.2s: 2 smelly, 1 warning(s), 9 clean; mean score 8.5, top docs/a.md (42)".
+func RenderSummary(w io.Writer, s ScanSummary, cfg Config) {
+	st := newTextStyle(w, cfg)
+	line := fmt.Sprintf("Scanned %d file(s)", s.Files)
+	if s.Elapsed > 0 {
+		line += " in " + formatDuration(s.Elapsed)
+	}
+	line += fmt.Sprintf(": %d smelly, %d warning(s), %d clean", s.Smelly, s.Warnings, s.Clean)
+	if s.Allowlisted > 0 {
+		line += fmt.Sprintf(" (%d allowlisted)", s.Allowlisted)
+	}
+	if s.Files > 0 {
+		line += fmt.Sprintf("; mean score %s, top %s (%s)", FormatScore(s.MeanScore), s.TopPath, FormatScore(s.TopScore))
+	}
+	fmt.Fprintln(w, st.meta(line))
+}
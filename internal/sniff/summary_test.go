@@ -0,0 +1,65 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeSummary(t *testing.T) {
+	results := []Result{
+		{Path: "b.md", Score: 42, Smelly: true},
+		{Path: "a.md", Score: 42, Smelly: true},
+		{Path: "c.md", Score: 20, Warning: true},
+		{Path: "d.md", Score: 0},
+		{Path: "gen.md", Score: 16, Allowlisted: true},
+	}
+
+	s := ComputeSummary(results, 1500*time.Millisecond)
+	assert.Equal(t, ScanSummary{
+		Files:       5,
+		Smelly:      2,
+		Warnings:    1,
+		Clean:       2,
+		Allowlisted: 1,
+		TotalScore:  120,
+		MeanScore:   24,
+		TopPath:     "a.md",
+		TopScore:    42,
+		Elapsed:     1500 * time.Millisecond,
+		ElapsedMS:   1500,
+	}, s)
+
+	var buf bytes.Buffer
+	RenderSummary(&buf, s, Config{Color: ColorNever})
+	assert.Equal(t, "Scanned 5 file(s) in 1.5s: 2 smelly, 1 warning(s), 2 clean (1 allowlisted); mean score 24, top a.md (42)\n", buf.String())
+
+	assert.Equal(t, ScanSummary{}, ComputeSummary(nil, 0))
+	buf.Reset()
+	RenderSummary(&buf, ScanSummary{}, Config{Color: ColorNever})
+	assert.Equal(t, "Scanned 0 file(s): 0 smelly, 0 warning(s), 0 clean\n", buf.String())
+}
+
+func TestRenderJSONSummary(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, []Result{{Path: "a.md", Score: 42, Smelly: true}}, Config{Format: FormatJSON, Elapsed: time.Second})
+	var decoded struct {
+		Summary map[string]any
+		Results []Result
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, 1.0, decoded.Summary["smelly"])
+	assert.Equal(t, "a.md", decoded.Summary["topPath"])
+	assert.Equal(t, 1000.0, decoded.Summary["elapsedMs"])
+	require.Len(t, decoded.Results, 1)
+	assert.Equal(t, "a.md", decoded.Results[0].Path)
+
+	buf.Reset()
+	Render(&buf, nil, Config{Format: FormatJSON})
+	assert.Contains(t, buf.String(), `"results": []`, "an empty scan is still an array")
+}
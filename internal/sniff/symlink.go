@@ -0,0 +1,30 @@
+package sniff
+
+import "os"
+
+// fileKey identifies a file by device and inode, so hard links and
+// symlinked paths to one file compare equal.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// inodeSet remembers the files and directories a walk has already seen.
+type inodeSet map[fileKey]bool
+
+// add stats path and reports whether it was not seen before. Paths that
+// cannot be identified are always new.
+func (s inodeSet) add(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	id, ok := fileID(info)
+	if !ok {
+		return true
+	}
+	if s[id] {
+		return false
+	}
+	s[id] = true
+	return true
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+// +build !windows
+
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// symlinkTree builds:
+//
+//	root/a.txt          MARK
+//	root/hard.txt       hard link to a.txt
+//	root/link.txt    -> a.txt
+//	root/sub/loop    -> root (cycle)
+//	root/ext         -> outside/
+//	outside/b.txt       MARK MARK
+func symlinkTree(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("MARK"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "b.txt"), []byte("MARK MARK"), 0644))
+	require.NoError(t, os.Link(filepath.Join(root, "a.txt"), filepath.Join(root, "hard.txt")))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(root, "link.txt")))
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "sub", "loop")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "ext")))
+	return root
+}
+
+func scanPaths(t *testing.T, root string, cfg Config) []string {
+	t.Helper()
+	cfg.Threshold = 1
+	cfg.ExtraRules = []Rule{{Name: "mark", Pattern: "MARK", Weight: 1}}
+	results, err := Scan(context.Background(), []string{root}, cfg)
+	require.NoError(t, err)
+	var paths []string
+	for _, r := range results {
+		rel, err := filepath.Rel(root, r.Path)
+		require.NoError(t, err)
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestScanSkipsSymlinksByDefault(t *testing.T) {
+	root := symlinkTree(t)
+	assert.Equal(t, []string{"a.txt", "hard.txt"}, scanPaths(t, root, Config{}))
+}
+
+func TestScanFollowSymlinks(t *testing.T) {
+	root := symlinkTree(t)
+	// The hard link and the file symlink point at a.txt, which is scanned
+	// once; the loop back to root is skipped without failing the scan
+	assert.Equal(t, []string{"a.txt", "ext/b.txt"}, scanPaths(t, root, Config{FollowSymlinks: true}))
+}
+
+func TestScanFollowSymlinksBroken(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("MARK"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "dangling")))
+	assert.Equal(t, []string{"a.txt"}, scanPaths(t, root, Config{FollowSymlinks: true}))
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package sniff
+
+import (
+	"os"
+	"syscall"
+)
+
+// symlinksSupported reports whether FollowSymlinks can detect cycles here.
+const symlinksSupported = true
+
+// fileID returns the device and inode behind info.
+func fileID(info os.FileInfo) (fileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true // Dev is int32 on some platforms
+}
@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package sniff
+
+import "os"
+
+// symlinksSupported reports whether FollowSymlinks can detect cycles here.
+// Windows file info carries no inode, so links are never followed.
+const symlinksSupported = false
+
+// fileID is unavailable on Windows.
+func fileID(os.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
@@ -0,0 +1,42 @@
+package sniff
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Tags of the base rules.
+const (
+	TagStructure  = "structure"  // Markdown layout, e.g. horizontal rules
+	TagTypography = "typography" // dashes, curly quotes and special spaces
+)
+
+// hasTag reports whether r carries one of tags, ignoring case.
+func (r Rule) hasTag(tags []string) bool {
+	return slices.ContainsFunc(r.Tags, func(t string) bool {
+		return slices.ContainsFunc(tags, func(u string) bool { return strings.EqualFold(t, u) })
+	})
+}
+
+// FilterTags returns the rules carrying one of only, when only is set,
+// minus those carrying one of exclude; tags ignore case. Tags that no
+// rule carries are reported on stderr.
+func FilterTags(rules []Rule, only, exclude []string) []Rule {
+	if len(only) == 0 && len(exclude) == 0 {
+		return rules
+	}
+	for _, tag := range slices.Concat(only, exclude) {
+		if !slices.ContainsFunc(rules, func(r Rule) bool { return r.hasTag([]string{tag}) }) {
+			slog.Warn("tag matches no loaded rule", "tag", tag)
+		}
+	}
+
+	out := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if (len(only) == 0 || r.hasTag(only)) && !r.hasTag(exclude) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
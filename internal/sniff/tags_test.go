@@ -0,0 +1,43 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterTags(t *testing.T) {
+	rules, err := EffectiveRules(Config{OnlyTags: []string{"structure"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"markdown-hrule"}, ruleNames(rules))
+
+	rules, err = EffectiveRules(Config{ExcludeTags: []string{"Typography"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"markdown-hrule"}, ruleNames(rules), "tags ignore case")
+
+	rules, err = EffectiveRules(Config{OnlyTags: []string{"typography"}, ExcludeTags: []string{"no-such-tag"}})
+	require.NoError(t, err)
+	assert.NotContains(t, ruleNames(rules), "markdown-hrule")
+	assert.Contains(t, ruleNames(rules), "en-dash")
+	assert.Contains(t, ruleNames(rules), "em-dash")
+
+	tagged := []Rule{
+		{Name: "a", Tags: []string{"x", "y"}},
+		{Name: "b", Tags: []string{"y"}},
+		{Name: "c"},
+	}
+	assert.Equal(t, []string{"a", "b"}, ruleNames(FilterTags(tagged, []string{"y"}, nil)))
+	assert.Equal(t, []string{"b"}, ruleNames(FilterTags(tagged, []string{"y"}, []string{"x"})), "exclude wins")
+	assert.Equal(t, []string{"b", "c"}, ruleNames(FilterTags(tagged, nil, []string{"x"})), "untagged rules stay")
+	assert.Equal(t, tagged, FilterTags(tagged, nil, nil))
+}
+
+func TestDumpRulesTags(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, DumpRules(baseRules, "yaml", &buf))
+	assert.Contains(t, buf.String(), "tags:")
+	assert.Contains(t, buf.String(), "- structure")
+}
@@ -0,0 +1,53 @@
+package sniff
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// RuleTestResult is one file's outcome under TestRule.
+type RuleTestResult struct {
+	Path     string   `json:"path"`
+	Matched  bool     `json:"matched"`
+	Count    int      `json:"count"`              // every occurrence
+	Score    int      `json:"score"`              // what the rule adds to the file's raw score
+	Snippets []string `json:"snippets,omitempty"` // context around each match, see Config.Snippets
+}
+
+// TestRule runs a single rule over each file, for rule authors checking a
+// rule against examples. Files are scored as in a scan, so the rule's
+// extension and MIME filters, cfg.MaxSize and the binary check all apply.
+func TestRule(rule Rule, files []string, cfg Config) ([]RuleTestResult, error) {
+	compiled := CompileRules([]Rule{rule}, cfg.UnicodeNorm)
+	out := make([]RuleTestResult, 0, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r := AnalyseCompiled(data, path, compiled, cfg)
+		hit := r.Detail[rule.Name]
+		out = append(out, RuleTestResult{
+			Path:     path,
+			Matched:  hit.Count > 0,
+			Count:    hit.Count,
+			Score:    r.RawScore,
+			Snippets: hit.Snippets,
+		})
+	}
+	return out, nil
+}
+
+// RenderRuleTest prints one MATCH or NO MATCH line per file, with the
+// snippets collected under cfg.Snippets.
+func RenderRuleTest(w io.Writer, results []RuleTestResult) {
+	for _, r := range results {
+		if !r.Matched {
+			fmt.Fprintf(w, "NO MATCH  %s\n", r.Path)
+			continue
+		}
+		fmt.Fprintf(w, "MATCH     %s × %d (score +%d)\n", r.Path, r.Count, r.Score)
+		printSnippets(w, r.Snippets)
+	}
+}
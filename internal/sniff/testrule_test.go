@@ -0,0 +1,39 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestRule(t *testing.T) {
+	dir := t.TempDir()
+	hit := filepath.Join(dir, "hit.md")
+	miss := filepath.Join(dir, "miss.md")
+	require.NoError(t, os.WriteFile(hit, []byte("a — b — c — d"), 0644))
+	require.NoError(t, os.WriteFile(miss, []byte("a - b"), 0644))
+	rule := Rule{Name: "em-dash", Pattern: "—", Weight: 3, MaxMatches: 2}
+
+	got, err := TestRule(rule, []string{hit, miss}, Config{Snippets: true})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, hit, got[0].Path)
+	assert.True(t, got[0].Matched)
+	assert.Equal(t, 3, got[0].Count)
+	assert.Equal(t, 6, got[0].Score, "capped at MaxMatches")
+	assert.Len(t, got[0].Snippets, 3)
+	assert.Equal(t, RuleTestResult{Path: miss}, got[1])
+
+	var buf bytes.Buffer
+	RenderRuleTest(&buf, got)
+	assert.Contains(t, buf.String(), "MATCH     "+hit+" × 3 (score +6)\n")
+	assert.Contains(t, buf.String(), "NO MATCH  "+miss+"\n")
+
+	_, err = TestRule(rule, []string{filepath.Join(dir, "missing.md")}, Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package sniff
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// isTOML reports whether path names a TOML file. The format is picked by
+// extension only; the content is never sniffed.
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// decodeTOML parses TOML into v by way of JSON, so the json tags (and
+// unmarshalers such as PathList's) define the accepted keys and TOML
+// files use the same field names as YAML and JSON ones.
+func decodeTOML(b []byte, v any) error {
+	var m map[string]any
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	j, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
@@ -0,0 +1,93 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadRulesTOML verifies [[rules]] tables use the YAML field names.
+func TestLoadRulesTOML(t *testing.T) {
+	rules, err := loadDict(filepath.Join("testdata", "toml", "rules.toml"))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	assert.Equal(t, "toml-marker", rules[0].Name)
+	assert.Equal(t, "TOML_MARKER", rules[0].Pattern)
+	assert.Equal(t, 15, rules[0].Weight)
+	assert.Equal(t, "marker used by the TOML tests", rules[0].Description)
+
+	assert.True(t, rules[1].CaseInsensitive)
+	assert.Equal(t, []string{".md", ".txt"}, rules[1].Exts)
+	assert.Equal(t, 8, rules[1].minGroupScore, "groups table applies")
+
+	require.NotNil(t, rules[2].Proximity)
+	assert.Equal(t, Proximity{PatternA: "In conclusion", PatternB: "Furthermore", MaxDistance: 200}, *rules[2].Proximity)
+}
+
+// TestLoadRulesTOMLErrors verifies invalid TOML fails and that the format
+// follows the extension, not the content.
+func TestLoadRulesTOMLErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(body), 0644))
+		return p
+	}
+
+	_, err := LoadRules([]string{write("bad.toml", "[[rules]\nname = \"x\"\n")})
+	assert.ErrorContains(t, err, "bad.toml")
+
+	_, err = LoadRules([]string{write("json.toml", `[{"name": "x", "pattern": "X", "weight": 1}]`)})
+	assert.Error(t, err, "JSON content in a .toml file is parsed as TOML")
+
+	tomlBody := "[[rules]]\nname = \"x\"\npattern = \"X\"\nweight = 1\n"
+	_, err = LoadRules([]string{write("toml.yaml", tomlBody)})
+	assert.Error(t, err, "TOML content in a .yaml file is not sniffed")
+
+	set, err := LoadRules([]string{write("upper.TOML", tomlBody)})
+	require.NoError(t, err)
+	rules := set.Active()
+	assert.Equal(t, "x", rules[len(rules)-1].Name)
+}
+
+// TestLoadConfigFileTOML verifies .synthsniff.toml parsing and discovery.
+func TestLoadConfigFileTOML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".synthsniff.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+threshold = 12.5
+dict = ["rules/a.toml", "rules/b.yaml"]
+useGitignore = true
+
+[[rules]]
+name = "config-rule"
+pattern = "CONFIG_MARKER"
+weight = 40
+`), 0644))
+
+	found, err := FindConfigFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, cfg.Threshold)
+	assert.Equal(t, PathList{filepath.Join(dir, "rules", "a.toml"), filepath.Join(dir, "rules", "b.yaml")}, cfg.DictPaths)
+	assert.True(t, cfg.UseGitignore)
+	require.Len(t, cfg.ExtraRules, 1)
+	assert.Equal(t, "CONFIG_MARKER", cfg.ExtraRules[0].Pattern)
+
+	require.NoError(t, os.WriteFile(path, []byte("dict = \"one.toml\"\n"), 0644))
+	cfg, err = LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, PathList{filepath.Join(dir, "one.toml")}, cfg.DictPaths, "a single string is accepted")
+
+	require.NoError(t, os.WriteFile(path, []byte("threshold = \n"), 0644))
+	_, err = LoadConfigFile(path)
+	assert.ErrorContains(t, err, ".synthsniff.toml")
+}
@@ -0,0 +1,60 @@
+package sniff
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normForms are the Unicode normalization forms accepted by Rule.Normalize
+// and Config.UnicodeNorm.
+var normForms = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+// ParseNormForm validates a Unicode normalization form name, ignoring
+// case. An empty name means no normalization.
+func ParseNormForm(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	f := strings.ToUpper(s)
+	if _, ok := normForms[f]; !ok {
+		return "", fmt.Errorf("invalid normalization form %q (want NFC, NFD, NFKC or NFKD)", s)
+	}
+	return f, nil
+}
+
+// checkNormForms validates and canonicalizes each rule's Normalize form.
+func checkNormForms(rules []Rule) error {
+	for i := range rules {
+		f, err := ParseNormForm(rules[i].Normalize)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rules[i].Name, err)
+		}
+		rules[i].Normalize = f
+	}
+	return nil
+}
+
+// normForm returns the form the rule matches in: its own, else global.
+func (r Rule) normForm(global string) string {
+	if r.Normalize != "" {
+		return r.Normalize
+	}
+	return global
+}
+
+// normalizeText returns s in the named form. Unknown or empty forms and
+// text that is already normal come back unchanged without copying.
+func normalizeText(s, form string) string {
+	f, ok := normForms[strings.ToUpper(form)]
+	if !ok || f.IsNormalString(s) {
+		return s
+	}
+	return f.String(s)
+}
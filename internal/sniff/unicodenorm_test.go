@@ -0,0 +1,82 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// "é" as one precomposed rune and as "e" plus a combining acute accent.
+const (
+	eComposed   = "café"
+	eDecomposed = "café"
+)
+
+func TestParseNormForm(t *testing.T) {
+	for in, want := range map[string]string{"": "", "NFC": "NFC", "nfkd": "NFKD"} {
+		got, err := ParseNormForm(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseNormForm("NFX")
+	assert.Error(t, err)
+}
+
+func TestAnalyseUnicodeNorm(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mixed.txt")
+	content := "one " + eComposed + "\ntwo " + eDecomposed + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	rules := []Rule{{Name: "cafe", Pattern: eComposed, Weight: 10}}
+
+	r := analyse(path, CompileRules(rules, ""), Config{Threshold: 1})
+	assert.Equal(t, 1, r.Detail["cafe"].Count, "without normalization only the exact bytes match")
+
+	for _, form := range []string{"NFC", "NFD"} {
+		r = analyse(path, CompileRules(rules, ""), Config{Threshold: 1, UnicodeNorm: form, CollectLines: true, Snippets: true, SnippetWidth: 4})
+		assert.Equal(t, 2, r.Detail["cafe"].Count, form)
+		assert.Equal(t, []int{1, 2}, r.Detail["cafe"].Lines, form)
+		assert.Len(t, r.Detail["cafe"].Snippets, 2, form)
+	}
+}
+
+func TestAnalyseRuleNormalizeOverridesGlobal(t *testing.T) {
+	rules := []Rule{
+		{Name: "global", Pattern: eComposed, Weight: 1},
+		{Name: "own", Pattern: eComposed, Weight: 1, Normalize: "NFD", CaseInsensitive: true},
+		{Name: "ligature", Pattern: "fi", Weight: 1, Normalize: "NFKC"},
+	}
+	content := eDecomposed + " CAFÉ ﬁne"
+
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 1})
+	assert.Zero(t, r.Detail["global"].Count, "no global form")
+	assert.Equal(t, 2, r.Detail["own"].Count, "the rule's own form applies, case-folded")
+	assert.Equal(t, 1, r.Detail["ligature"].Count, "NFKC turns the ﬁ ligature into fi")
+
+	r = AnalyseString(content, "a.txt", rules, Config{Threshold: 1, UnicodeNorm: "NFC"})
+	assert.Equal(t, 1, r.Detail["global"].Count)
+	assert.Equal(t, 2, r.Detail["own"].Count)
+}
+
+func TestLoadRulesNormalize(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "dict.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte("- {name: cafe, pattern: café, weight: 1, normalize: nfd}\n"), 0644))
+	set, err := LoadRules([]string{dict})
+	require.NoError(t, err)
+	rules := set.Active()
+	assert.Equal(t, "NFD", rules[len(rules)-1].Normalize)
+
+	require.NoError(t, os.WriteFile(dict, []byte("- {name: cafe, pattern: café, weight: 1, normalize: nfx}\n"), 0644))
+	_, err = LoadRules([]string{dict})
+	assert.ErrorContains(t, err, "rule cafe")
+}
+
+func TestFingerprintUnicodeNorm(t *testing.T) {
+	rules := []Rule{{Name: "x", Pattern: eComposed}}
+	assert.NotEqual(t, cacheFingerprint(CompileRules(rules, ""), Config{}), cacheFingerprint(CompileRules(rules, "NFD"), Config{UnicodeNorm: "NFD"}))
+}
@@ -0,0 +1,44 @@
+package sniff
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/JoobyPM/synthsniff/internal/version"
+)
+
+// VersionInfo describes the running build.
+type VersionInfo struct {
+	Version string `json:"version"` // e.g. 1.2.3, "dev" for a local build
+	Commit  string `json:"commit"`  // short git commit, "unknown" when not recorded
+	Date    string `json:"date"`    // build or commit date, YYYY-MM-DD, "unknown" when not recorded
+}
+
+// String formats v as "synthsniff version 1.2.3 (commit abc1234, built
+// 2024-01-01)".
+func (v VersionInfo) String() string {
+	return fmt.Sprintf("%s version %s (commit %s, built %s)", toolName, v.Version, v.Commit, v.Date)
+}
+
+// Version returns the version, commit and date stamped in with -ldflags
+// (see internal/version). What a build without them lacks is taken from
+// the Go build info, so "go install ...@v1.2.3" still reports v1.2.3.
+func Version() VersionInfo {
+	v := VersionInfo{Version: version.Version, Commit: version.Commit, Date: version.Date}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	if v.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && v.Commit == "unknown":
+			v.Commit = s.Value[:min(len(s.Value), 7)]
+		case s.Key == "vcs.time" && v.Date == "unknown":
+			v.Date = s.Value[:min(len(s.Value), len("2006-01-02"))]
+		}
+	}
+	return v
+}
@@ -0,0 +1,24 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"testing"
+
+	"github.com/JoobyPM/synthsniff/internal/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	v := VersionInfo{Version: "1.2.3", Commit: "abc1234", Date: "2024-01-01"}
+	assert.Equal(t, "synthsniff version 1.2.3 (commit abc1234, built 2024-01-01)", v.String())
+
+	got := Version()
+	assert.NotEmpty(t, got.Version)
+	assert.NotEmpty(t, got.Commit)
+	assert.NotEmpty(t, got.Date)
+
+	old := version.Version
+	version.Version = "1.2.3"
+	t.Cleanup(func() { version.Version = old })
+	assert.Equal(t, "1.2.3", Version().Version, "-ldflags win over the build info")
+}
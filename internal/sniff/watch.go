@@ -0,0 +1,252 @@
+package sniff
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long Watch waits after the last file event
+// before rescanning, so a burst of writes is scanned once.
+const DefaultWatchDebounce = 200 * time.Millisecond
+
+// WatchUpdate is what one rescan in Watch changed.
+type WatchUpdate struct {
+	Changed []Result // files scored in this round, sorted by path
+	Removed []string // files gone since the last round, sorted
+	Results []Result // every current result, sorted by path
+}
+
+// Watch scans roots, then watches them and rescans files as they are
+// written, created or removed, until ctx is cancelled. update gets the
+// initial scan and then each round of changes; events are coalesced
+// until none arrive for debounce (DefaultWatchDebounce when 0).
+//
+// Only changed files are rescored. New files are held to the include and
+// exclude globs but not to ignore files, and rescored files are scored
+// with the scan's own settings, not a directory's config file. Watch
+// returns nil when ctx is cancelled, or the error of the initial scan.
+func (s *Scanner) Watch(ctx context.Context, roots []string, debounce time.Duration, update func(WatchUpdate)) error {
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	w := &watchState{
+		s:       s,
+		fw:      fw,
+		dirs:    make(map[string]bool),
+		files:   make(map[string]bool),
+		skip:    ruleFiles(s.cfg, s.rules),
+		results: make(map[string]Result),
+	}
+	// Clean paths, so event names and result paths agree
+	clean := make([]string, len(roots))
+	for i, root := range roots {
+		clean[i] = root
+		if root != StdinPath {
+			clean[i] = filepath.Clean(root)
+		}
+		if err := w.add(clean[i]); err != nil {
+			return err
+		}
+	}
+	roots = clean
+
+	initial, err := s.Scan(ctx, roots)
+	if err != nil {
+		return err
+	}
+	for _, r := range initial {
+		w.results[r.Path] = r
+	}
+	update(WatchUpdate{Changed: initial, Results: w.sorted()})
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(ev.Name)
+			if !w.watched(name) || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[name] = true
+			timer.Reset(debounce)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("watch error", "err", err)
+		case <-timer.C:
+			if u, ok := w.rescan(ctx, pending); ok {
+				update(u)
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// watchState is the bookkeeping of one Watch call.
+type watchState struct {
+	s       *Scanner
+	fw      *fsnotify.Watcher
+	dirs    map[string]bool // watched directories under a directory root
+	files   map[string]bool // file roots; only their own events count
+	skip    map[string]bool // dictionaries and word lists, see ruleFiles
+	results map[string]Result
+}
+
+// add watches root: every directory below it, or a file's directory.
+func (w *watchState) add(root string) error {
+	if root == StdinPath {
+		return nil
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		w.files[root] = true
+		return w.fw.Add(filepath.Dir(root))
+	}
+	return w.addDir(root)
+}
+
+// addDir watches dir and the directories below it, .git excepted.
+func (w *watchState) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == ".git" && path != dir {
+			return filepath.SkipDir
+		}
+		if err := w.fw.Add(path); err != nil {
+			return err
+		}
+		w.dirs[path] = true
+		return nil
+	})
+}
+
+// watched reports whether an event for path concerns the watched roots.
+func (w *watchState) watched(path string) bool {
+	return w.files[path] || w.dirs[path] || w.dirs[filepath.Dir(path)]
+}
+
+// wants reports whether a file that turned up after the initial scan
+// would have been scanned.
+func (w *watchState) wants(path string) bool {
+	cfg := w.s.cfg
+	if isRuleFile(path, w.skip) || matchesAny(path, cfg.ExcludePatterns) {
+		return false
+	}
+	if w.files[path] {
+		return true
+	}
+	return matchesName(path, cfg.NamePatterns) && (len(cfg.IncludePatterns) == 0 || matchesAny(path, cfg.IncludePatterns))
+}
+
+// rescan handles one debounced round of events, reporting false when
+// nothing changed.
+func (w *watchState) rescan(ctx context.Context, pending map[string]bool) (WatchUpdate, bool) {
+	var u WatchUpdate
+	var changed []string
+	for _, path := range sortedKeys(pending) {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			u.Removed = append(u.Removed, w.drop(path)...)
+		case info.IsDir():
+			if w.dirs[path] {
+				continue // a file inside changed; that file has its own event
+			}
+			if err := w.addDir(path); err != nil {
+				slog.Warn("watch failed", "path", path, "err", err)
+			}
+			_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && d.Type().IsRegular() && w.wants(p) {
+					changed = append(changed, p)
+				}
+				return nil
+			})
+		case info.Mode().IsRegular():
+			if _, known := w.results[path]; known || w.wants(path) {
+				changed = append(changed, path)
+			}
+		}
+	}
+	if len(changed) > 0 {
+		results, err := w.s.Scan(ctx, changed)
+		if err != nil {
+			slog.Warn("rescan failed", "err", err)
+		}
+		for _, r := range results {
+			w.results[r.Path] = r
+		}
+		u.Changed = results
+	}
+	if len(u.Changed) == 0 && len(u.Removed) == 0 {
+		return u, false
+	}
+	sort.Strings(u.Removed)
+	u.Results = w.sorted()
+	return u, true
+}
+
+// drop forgets path, and everything below it when it was a directory,
+// returning the results it removed.
+func (w *watchState) drop(path string) []string {
+	var removed []string
+	prefix := path + string(filepath.Separator)
+	for p := range w.results {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(w.results, p)
+			removed = append(removed, p)
+		}
+	}
+	for d := range w.dirs {
+		if d == path || strings.HasPrefix(d, prefix) {
+			delete(w.dirs, d)
+		}
+	}
+	return removed
+}
+
+// sorted returns the current results sorted by path.
+func (w *watchState) sorted() []Result {
+	list := make([]Result, 0, len(w.results))
+	for _, r := range w.results {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
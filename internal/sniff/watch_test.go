@@ -0,0 +1,85 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextUpdate waits for Watch's next update.
+func nextUpdate(t *testing.T, updates <-chan WatchUpdate) WatchUpdate {
+	t.Helper()
+	select {
+	case u := <-updates:
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch update")
+		return WatchUpdate{}
+	}
+}
+
+func TestScannerWatch(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(a, []byte("plain text"), 0644))
+
+	s, err := NewScanner(WithRules(Rule{Name: "delve", Pattern: "delve", Weight: 5}), WithThreshold(10), WithIgnorePatterns("*.tmp"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan WatchUpdate, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, []string{dir}, 100*time.Millisecond, func(u WatchUpdate) { updates <- u })
+	}()
+
+	u := nextUpdate(t, updates)
+	assert.Equal(t, []string{a}, resultPaths(u.Changed), "the initial scan")
+	assert.False(t, u.Results[0].Smelly)
+
+	// Several quick writes are coalesced into one rescan
+	require.NoError(t, os.WriteFile(a, []byte("delve"), 0644))
+	require.NoError(t, os.WriteFile(a, []byte("delve delve"), 0644))
+	u = nextUpdate(t, updates)
+	require.Equal(t, []string{a}, resultPaths(u.Changed))
+	assert.True(t, u.Changed[0].Smelly)
+	assert.Equal(t, float64(10), u.Changed[0].Score)
+
+	// New files are scanned, excluded ones are not
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "skip.tmp"), []byte("delve"), 0644))
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	b := filepath.Join(sub, "b.md")
+	require.NoError(t, os.WriteFile(b, []byte("text"), 0644))
+	u = nextUpdate(t, updates)
+	for len(u.Results) < 2 {
+		u = nextUpdate(t, updates)
+	}
+	assert.Equal(t, []string{a, b}, resultPaths(u.Results))
+
+	// Removed files leave the result set
+	require.NoError(t, os.Remove(a))
+	u = nextUpdate(t, updates)
+	assert.Equal(t, []string{a}, u.Removed)
+	assert.Equal(t, []string{b}, resultPaths(u.Results))
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
+
+func TestScannerWatchBadRoot(t *testing.T) {
+	s, err := NewScanner()
+	require.NoError(t, err)
+	err = s.Watch(context.Background(), []string{filepath.Join(t.TempDir(), "missing")}, 0, func(WatchUpdate) {})
+	assert.Error(t, err)
+}
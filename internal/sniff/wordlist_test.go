@@ -0,0 +1,82 @@
+// Package sniff provides functionality to detect AI-generated text.
+package sniff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWordListDict writes a dict with one word-list rule and its list
+// under dir/rules, returning the dict path.
+func writeWordListDict(t *testing.T, dir, words string) string {
+	t.Helper()
+	rulesDir := filepath.Join(dir, "rules")
+	require.NoError(t, os.MkdirAll(rulesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rulesDir, "words.txt"), []byte(words), 0644))
+	dict := filepath.Join(rulesDir, "dict.yaml")
+	require.NoError(t, os.WriteFile(dict, []byte("- name: ai-words\n  weight: 5\n  wordList: words.txt\n"), 0644))
+	return dict
+}
+
+func TestLoadRulesWordList(t *testing.T) {
+	dir := t.TempDir()
+	dict := writeWordListDict(t, dir, "# AI phrasing\ndelve\n\n  nuanced  \ndelve\nrich tapestry\n")
+
+	set, err := LoadRules([]string{dict})
+	require.NoError(t, err)
+	rules := set.Active()
+	got := rules[len(rules)-1]
+	assert.Equal(t, filepath.Join(dir, "rules", "words.txt"), got.WordList, "resolved against the dict directory")
+	assert.Equal(t, []string{"delve", "nuanced", "rich tapestry"}, got.words)
+	assert.Equal(t, "@words.txt (3 words)", got.displayPattern())
+
+	require.NoError(t, os.WriteFile(dict, []byte("- name: x\n  weight: 1\n  wordList: missing.txt\n"), 0644))
+	_, err = LoadRules([]string{dict})
+	assert.Error(t, err)
+}
+
+func TestAnalyseWordList(t *testing.T) {
+	rules := []Rule{{Name: "ai-words", Weight: 5, WordList: "words.txt", words: []string{"delve", "rich tapestry"}}}
+	content := "Let us delve into this rich tapestry.\nWe delve again."
+
+	r := AnalyseString(content, "a.txt", rules, Config{Threshold: 10, CollectLines: true})
+	assert.Equal(t, 15.0, r.Score, "each occurrence adds the weight")
+	assert.Equal(t, 3, r.Detail["ai-words"].Count)
+	assert.Equal(t, []int{1, 1, 2}, r.Detail["ai-words"].Lines)
+
+	// Matching is case-sensitive
+	r = AnalyseString("Delve into a Rich Tapestry.", "a.txt", rules, Config{Threshold: 10})
+	assert.Zero(t, r.Score)
+}
+
+func TestScanWordList(t *testing.T) {
+	dir := t.TempDir()
+	dict := writeWordListDict(t, dir, "delve\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "essay.txt"), []byte("delve delve"), 0644))
+
+	results, err := Scan(context.Background(), []string{dir}, Config{DictPaths: []string{dict}, Threshold: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1, "the word list itself is not scanned")
+	assert.Equal(t, filepath.Join(dir, "essay.txt"), results[0].Path)
+	assert.Equal(t, 10.0, results[0].Score)
+}
+
+func TestWordListChangeInvalidatesCache(t *testing.T) {
+	dir := t.TempDir()
+	dict := writeWordListDict(t, dir, "delve\n")
+	set, err := LoadRules([]string{dict})
+	require.NoError(t, err)
+	before := set.Compile("")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rules", "words.txt"), []byte("delve\nnuanced\n"), 0644))
+	set, err = LoadRules([]string{dict})
+	require.NoError(t, err)
+	after := set.Compile("")
+
+	assert.NotEqual(t, cacheFingerprint(before, Config{}), cacheFingerprint(after, Config{}))
+}
@@ -0,0 +1,16 @@
+// Package version holds the build's version, commit and date, set at
+// link time:
+//
+//	go build -ldflags "-X github.com/JoobyPM/synthsniff/internal/version.Version=1.2.3 \
+//	  -X github.com/JoobyPM/synthsniff/internal/version.Commit=abc1234 \
+//	  -X github.com/JoobyPM/synthsniff/internal/version.Date=2024-01-01" ./cmd/sniff4ai
+package version
+
+// Version is the release, "dev" in builds without -ldflags.
+var Version = "dev"
+
+// Commit is the git commit built from, "unknown" without -ldflags.
+var Commit = "unknown"
+
+// Date is the build date, "unknown" without -ldflags.
+var Date = "unknown"